@@ -1,10 +1,13 @@
 package app
 
 import (
+	"log"
 	"os"
 
 	"github.com/gin-gonic/gin"
+	"resturants-hub.com/m/v2/configs"
 	"resturants-hub.com/m/v2/database"
+	"resturants-hub.com/m/v2/jobs"
 )
 
 var (
@@ -12,7 +15,12 @@ var (
 )
 
 func StartApplication() {
+	if err := configs.LoadSsoProviders(); err != nil {
+		log.Fatal(err)
+	}
 	database.RunMigrations()
+	jobs.StartPurgeDeleted()
+	jobs.StartPageScheduler()
 	mapRoutes()
 
 	// Use env variable for port configuration if available, default to 3000 otherwise
@@ -10,11 +10,20 @@ import (
 )
 
 var (
-	ssoHandler         handlers.SsoHandler         = handlers.NewSsoHandler()
-	usersHandler       handlers.UsersHandler       = handlers.NewUsersHandler()
-	invitationsHandler handlers.InvitationsHandler = handlers.NewInvitationsHandler()
-	restaurantsHandler handlers.RestaurantsHandler = handlers.NewAdminRestaurantsHandler()
-	pagesHandler       handlers.PagesHandler       = handlers.NewPagesHandler()
+	ssoHandler            handlers.SsoHandler            = handlers.NewSsoHandler()
+	usersHandler          handlers.UsersHandler          = handlers.NewUsersHandler()
+	invitationsHandler    handlers.InvitationsHandler    = handlers.NewInvitationsHandler()
+	restaurantsHandler    handlers.RestaurantsHandler    = handlers.NewAdminRestaurantsHandler()
+	pagesHandler          handlers.PagesHandler          = handlers.NewPagesHandler()
+	pageRevisionsHandler  handlers.PageRevisionsHandler  = handlers.NewPageRevisionsHandler()
+	dishesHandler         handlers.DishesHandler         = handlers.NewDishesHandler()
+	menuCategoriesHandler handlers.MenuCategoriesHandler = handlers.NewMenuCategoriesHandler()
+	tablesHandler         handlers.TablesHandler         = handlers.NewTablesHandler()
+	reservationsHandler   handlers.ReservationsHandler   = handlers.NewReservationsHandler()
+	ordersHandler         handlers.OrdersHandler         = handlers.NewOrdersHandler()
+	reviewsHandler        handlers.ReviewsHandler        = handlers.NewReviewsHandler()
+	publicHandler         handlers.PublicHandler         = handlers.NewPublicHandler()
+	searchHandler         handlers.SearchHandler         = handlers.NewSearchHandler()
 )
 
 func mapRoutes() {
@@ -42,12 +51,41 @@ func mapRoutes() {
 		adminRestaurantsRoutes.GET("/:id", restaurantsHandler.Get)
 		adminRestaurantsRoutes.PUT("/:id", restaurantsHandler.Update)
 		adminRestaurantsRoutes.PATCH("/:id", restaurantsHandler.Update)
+		adminRestaurantsRoutes.DELETE("/:id", restaurantsHandler.Delete)
+		adminRestaurantsRoutes.POST("/:id/restore", restaurantsHandler.Restore)
+
+		/* Admin Restaurant dishes routes */
+		adminRestaurantsRoutes.GET("/:id/dishes", dishesHandler.List)
+		adminRestaurantsRoutes.POST("/:id/dishes", dishesHandler.Create)
+		adminRestaurantsRoutes.GET("/:id/dishes/:dishId", dishesHandler.Get)
+		adminRestaurantsRoutes.PUT("/:id/dishes/:dishId", dishesHandler.Update)
+		adminRestaurantsRoutes.PATCH("/:id/dishes/:dishId", dishesHandler.Update)
+		adminRestaurantsRoutes.DELETE("/:id/dishes/:dishId", dishesHandler.Delete)
+		mapMenuCategoryRoutes(adminRestaurantsRoutes.Group("/:id/menu-categories"))
+
+		/* Admin Restaurant pages routes: slugs are unique per restaurant */
+		mapPageRoutes(adminRestaurantsRoutes.Group("/:id/pages"))
+
+		/* Admin Restaurant tables and reservations routes */
+		mapTableRoutes(adminRestaurantsRoutes.Group("/:id/tables"))
+		mapReservationRoutes(adminRestaurantsRoutes.Group("/:id/reservations"))
+
+		/* Admin Restaurant orders routes */
+		mapOrderRoutes(adminRestaurantsRoutes.Group("/:id/orders"))
+
+		/* Admin Restaurant reviews routes, hidden reviews of all restaurants are moderated from the queue */
+		mapReviewRoutes(adminRestaurantsRoutes.Group("/:id/reviews"))
+		adminRoutes.GET("/reviews/moderation", reviewsHandler.ModerationQueue)
 
 		adminUsersRoutes := adminRoutes.Group("/users")
 		adminUsersRoutes.POST("/", usersHandler.Create)
 		adminUsersRoutes.GET("/", usersHandler.List)
 		adminUsersRoutes.GET("/profile", usersHandler.Profile)
+		adminUsersRoutes.GET("/profile/identities", usersHandler.ListIdentities)
+		adminUsersRoutes.DELETE("/profile/identities/:identityId", usersHandler.UnlinkIdentity)
 		adminUsersRoutes.GET("/:id", usersHandler.Get)
+		adminUsersRoutes.DELETE("/:id", usersHandler.Delete)
+		adminUsersRoutes.POST("/:id/restore", usersHandler.Restore)
 
 		/* Admin Invitations routes */
 		adminInvitationsRoutes := adminRoutes.Group("/invitations")
@@ -61,22 +99,139 @@ func mapRoutes() {
 	restaurantsRoutes := router.Group("/api/my-restaurant", middleware.RequireAuth)
 	{
 		restaurantsRoutes.GET("/", restaurantsHandler.MyRestaurant)
+
+		/* Manager's Restaurant opening hours routes */
+		restaurantsRoutes.GET("/hours", restaurantsHandler.Hours)
+		restaurantsRoutes.PUT("/hours", restaurantsHandler.UpdateHours)
+
+		/* Manager's Restaurant dishes routes */
+		restaurantsRoutes.GET("/dishes", dishesHandler.List)
+		restaurantsRoutes.POST("/dishes", dishesHandler.Create)
+		restaurantsRoutes.GET("/dishes/:dishId", dishesHandler.Get)
+		restaurantsRoutes.PUT("/dishes/:dishId", dishesHandler.Update)
+		restaurantsRoutes.PATCH("/dishes/:dishId", dishesHandler.Update)
+		restaurantsRoutes.DELETE("/dishes/:dishId", dishesHandler.Delete)
+		mapMenuCategoryRoutes(restaurantsRoutes.Group("/menu-categories"))
+
+		/* Manager's Restaurant pages routes */
+		mapPageRoutes(restaurantsRoutes.Group("/pages"))
+
+		/* Manager's Restaurant tables and reservations routes */
+		mapTableRoutes(restaurantsRoutes.Group("/tables"))
+		mapReservationRoutes(restaurantsRoutes.Group("/reservations"))
+
+		/* Manager's Restaurant orders routes */
+		mapOrderRoutes(restaurantsRoutes.Group("/orders"))
+
+		/* Manager's Restaurant reviews routes */
+		mapReviewRoutes(restaurantsRoutes.Group("/reviews"))
 	}
 
 	/* Pages routes */
-	pagesRoutes := router.Group("/api/pages", middleware.RequireAuth)
+	mapPageRoutes(router.Group("/api/pages", middleware.RequireAuth))
+
+	/* Full text search across restaurants, pages and dishes */
+	router.GET("/api/search", middleware.RequireAuth, searchHandler.Search)
+
+	/* Public routes: accessible without a session, read only apart from table bookings, orders and reviews */
+	publicRoutes := router.Group("/api/public")
 	{
-		pagesRoutes.GET("/", pagesHandler.ListPages)
-		pagesRoutes.POST("/", pagesHandler.CreatePage)
-		pagesRoutes.GET("/:slug", pagesHandler.GetPage)
+		publicRoutes.GET("/restaurants", publicHandler.ListRestaurants)
+		publicRoutes.GET("/restaurants/:id", publicHandler.GetRestaurant)
+		publicRoutes.GET("/restaurants/:id/pages", publicHandler.ListPages)
+		publicRoutes.GET("/restaurants/:id/pages/:slug", publicHandler.GetPage)
+		publicRoutes.GET("/restaurants/:id/dishes", publicHandler.ListDishes)
+		publicRoutes.GET("/restaurants/:id/menu-categories", publicHandler.ListMenuCategories)
+		publicRoutes.GET("/menu-labels", publicHandler.MenuLabels)
+
+		/* Diners book tables without an account */
+		publicRoutes.GET("/restaurants/:id/availability", publicHandler.Availability)
+		publicRoutes.POST("/restaurants/:id/reservations", publicHandler.CreateReservation)
+
+		/* Diners order takeaway without an account */
+		publicRoutes.POST("/restaurants/:id/cart", publicHandler.PriceCart)
+		publicRoutes.POST("/restaurants/:id/orders", publicHandler.CreateOrder)
+
+		/* Diners review the dishes which accept reviews */
+		publicRoutes.GET("/restaurants/:id/dishes/:dishId/reviews", publicHandler.ListReviews)
+		publicRoutes.POST("/restaurants/:id/dishes/:dishId/reviews", publicHandler.CreateReview)
 	}
 
 	/* Auth routes */
 	authRoutes := router.Group("/api/auth")
 	{
+		authRoutes.GET("/providers", ssoHandler.ListProviders)
 		authRoutes.GET("/:provider", ssoHandler.SsoLogin)
 		authRoutes.GET("/:provider/callback", ssoHandler.Callback)
+		authRoutes.GET("/:provider/link", middleware.RequireAuth, ssoHandler.LinkProvider)
 		authRoutes.PUT("/renew-session", middleware.RequireAuth, ssoHandler.RenewSession)
 		authRoutes.POST("/logout", middleware.RequireAuth, ssoHandler.Logout)
+		authRoutes.GET("/sessions", middleware.RequireAuth, ssoHandler.ListSessions)
+		authRoutes.DELETE("/sessions/:id", middleware.RequireAuth, ssoHandler.RevokeSession)
 	}
 }
+
+/* mapPageRoutes maps the page routes, which are available across restaurants as well as within a restaurant */
+func mapPageRoutes(pagesRoutes *gin.RouterGroup) {
+	pagesRoutes.GET("/", pagesHandler.ListPages)
+	pagesRoutes.POST("/", pagesHandler.CreatePage)
+	pagesRoutes.GET("/tree", pagesHandler.PageTree)
+	pagesRoutes.GET("/:slug", pagesHandler.GetPage)
+	pagesRoutes.PUT("/:slug", pagesHandler.UpdatePage)
+	pagesRoutes.PATCH("/:slug", pagesHandler.UpdatePage)
+	pagesRoutes.DELETE("/:slug", pagesHandler.DeletePage)
+	pagesRoutes.POST("/:slug/restore", pagesHandler.RestorePage)
+	pagesRoutes.POST("/:slug/transitions/:event", pagesHandler.TransitionPage)
+
+	/* Page revisions routes */
+	pagesRoutes.GET("/:slug/revisions", pageRevisionsHandler.List)
+	pagesRoutes.GET("/:slug/revisions/diff", pageRevisionsHandler.Diff)
+	pagesRoutes.GET("/:slug/revisions/:revisionId", pageRevisionsHandler.Get)
+	pagesRoutes.POST("/:slug/revisions/:revisionId/restore", pageRevisionsHandler.Restore)
+}
+
+/* mapMenuCategoryRoutes maps the routes of the menu categories of a restaurant */
+func mapMenuCategoryRoutes(categoriesRoutes *gin.RouterGroup) {
+	categoriesRoutes.GET("/", menuCategoriesHandler.List)
+	categoriesRoutes.POST("/", menuCategoriesHandler.Create)
+	categoriesRoutes.GET("/:categoryId", menuCategoriesHandler.Get)
+	categoriesRoutes.PUT("/:categoryId", menuCategoriesHandler.Update)
+	categoriesRoutes.PATCH("/:categoryId", menuCategoriesHandler.Update)
+	categoriesRoutes.DELETE("/:categoryId", menuCategoriesHandler.Delete)
+}
+
+/* mapTableRoutes maps the routes of the tables of a restaurant */
+func mapTableRoutes(tablesRoutes *gin.RouterGroup) {
+	tablesRoutes.GET("/", tablesHandler.List)
+	tablesRoutes.POST("/", tablesHandler.Create)
+	tablesRoutes.GET("/:tableId", tablesHandler.Get)
+	tablesRoutes.PUT("/:tableId", tablesHandler.Update)
+	tablesRoutes.PATCH("/:tableId", tablesHandler.Update)
+	tablesRoutes.DELETE("/:tableId", tablesHandler.Delete)
+}
+
+/* mapReservationRoutes maps the routes of the reservations of a restaurant */
+func mapReservationRoutes(reservationsRoutes *gin.RouterGroup) {
+	reservationsRoutes.GET("/", reservationsHandler.List)
+	reservationsRoutes.POST("/", reservationsHandler.Create)
+	reservationsRoutes.GET("/availability", reservationsHandler.Availability)
+	reservationsRoutes.GET("/:reservationId", reservationsHandler.Get)
+	reservationsRoutes.PUT("/:reservationId", reservationsHandler.Update)
+	reservationsRoutes.PATCH("/:reservationId", reservationsHandler.Update)
+	reservationsRoutes.POST("/:reservationId/transitions/:event", reservationsHandler.Transition)
+}
+
+/* mapOrderRoutes maps the routes of the orders of a restaurant, which are placed through the public API */
+func mapOrderRoutes(ordersRoutes *gin.RouterGroup) {
+	ordersRoutes.GET("/", ordersHandler.List)
+	ordersRoutes.GET("/:orderId", ordersHandler.Get)
+	ordersRoutes.POST("/:orderId/transitions/:event", ordersHandler.Transition)
+}
+
+/* mapReviewRoutes maps the routes of the reviews of a restaurant, which are submitted through the public API */
+func mapReviewRoutes(reviewsRoutes *gin.RouterGroup) {
+	reviewsRoutes.GET("/", reviewsHandler.List)
+	reviewsRoutes.GET("/:reviewId", reviewsHandler.Get)
+	reviewsRoutes.PUT("/:reviewId/reply", reviewsHandler.Reply)
+	reviewsRoutes.POST("/:reviewId/transitions/:event", reviewsHandler.Transition)
+}
@@ -0,0 +1,85 @@
+package authorizer
+
+import (
+	"resturants-hub.com/m/v2/dto"
+	consts "resturants-hub.com/m/v2/packages/const"
+	rest_errors "resturants-hub.com/m/v2/packages/utils"
+)
+
+type DishAuthorizor interface {
+	Authorize(string) (interface{}, rest_errors.RestErr)
+	AuthorizeAccess() bool
+	AuthorizeUpdate() bool
+	AuthorizeDelete() bool
+	UserOwnsResource() bool
+}
+
+type dishesAuthUser struct {
+	*dto.BaseUser
+	DishRestaurantId int64
+}
+
+func NewDishAuthorizer(currentUser *dto.BaseUser, restaurantId ...int64) DishAuthorizor {
+	if restaurantId == nil {
+		restaurantId = []int64{0}
+	}
+	return &dishesAuthUser{currentUser, restaurantId[0]}
+}
+
+func (auth *dishesAuthUser) AuthorizeAccess() bool {
+	return auth.IsAdmin() || (auth.IsManager() && auth.UserOwnsResource())
+}
+
+func (auth *dishesAuthUser) AuthorizeUpdate() bool {
+	return auth.IsAdmin() || (auth.IsManager() && auth.UserOwnsResource())
+}
+
+func (auth *dishesAuthUser) AuthorizeDelete() bool {
+	return auth.IsAdmin() || (auth.IsManager() && auth.UserOwnsResource())
+}
+
+/* A manager owns a dish when it belongs to the restaurant they manage */
+func (auth *dishesAuthUser) UserOwnsResource() bool {
+	return auth.RestaurantId.Valid && auth.RestaurantId.Int64 == auth.DishRestaurantId
+}
+
+/*
+Use this dishPermissions and authorization for member resource only
+The idea is to authorize the user based on the action they want to perform on the resource.
+Dishes are always listed within a restaurant, so the collection is also limited to restaurants the user can access
+*/
+type dishPermissions struct {
+	CanAccess bool `json:"canAccess"`
+	CanUpdate bool `json:"canUpdate"`
+	CanDelete bool `json:"canDelete"`
+}
+
+func (auth *dishesAuthUser) Authorize(action string) (interface{}, rest_errors.RestErr) {
+	permissions := &dishPermissions{
+		CanAccess: auth.AuthorizeAccess(),
+		CanUpdate: auth.AuthorizeUpdate(),
+		CanDelete: auth.AuthorizeDelete(),
+	}
+
+	var hasPermission bool
+	switch action {
+	case "accessCollection":
+		hasPermission = auth.Can("accessCollection", consts.Dishes) && permissions.CanAccess
+	case "create":
+		hasPermission = auth.Can("create", consts.Dishes) && permissions.CanUpdate
+	case "access":
+		hasPermission = permissions.CanAccess
+	case "update":
+		hasPermission = permissions.CanUpdate
+	case "delete":
+		hasPermission = permissions.CanDelete
+	default:
+		hasPermission = false
+	}
+
+	if hasPermission {
+		return permissions, nil
+	}
+
+	return nil, rest_errors.NewForbiddenError("You are not allowed to perform this action")
+}
@@ -0,0 +1,77 @@
+package authorizer
+
+import (
+	"resturants-hub.com/m/v2/dto"
+	consts "resturants-hub.com/m/v2/packages/const"
+	rest_errors "resturants-hub.com/m/v2/packages/utils"
+)
+
+type OrderAuthorizor interface {
+	Authorize(string) (interface{}, rest_errors.RestErr)
+	AuthorizeAccess() bool
+	AuthorizeUpdate() bool
+	UserOwnsResource() bool
+}
+
+type ordersAuthUser struct {
+	*dto.BaseUser
+	OrderRestaurantId int64
+}
+
+func NewOrderAuthorizer(currentUser *dto.BaseUser, restaurantId ...int64) OrderAuthorizor {
+	if restaurantId == nil {
+		restaurantId = []int64{0}
+	}
+	return &ordersAuthUser{currentUser, restaurantId[0]}
+}
+
+func (auth *ordersAuthUser) AuthorizeAccess() bool {
+	return auth.IsAdmin() || (auth.IsManager() && auth.UserOwnsResource())
+}
+
+func (auth *ordersAuthUser) AuthorizeUpdate() bool {
+	return auth.IsAdmin() || (auth.IsManager() && auth.UserOwnsResource())
+}
+
+/* A manager owns an order when it is placed at the restaurant they manage */
+func (auth *ordersAuthUser) UserOwnsResource() bool {
+	return auth.RestaurantId.Valid && auth.RestaurantId.Int64 == auth.OrderRestaurantId
+}
+
+/*
+Use this orderPermissions and authorization for member resource only.
+Orders are placed by diners through the public API and never deleted: restaurants only move them through their lifecycle
+*/
+type orderPermissions struct {
+	CanAccess bool `json:"canAccess"`
+	CanUpdate bool `json:"canUpdate"`
+	CanCancel bool `json:"canCancel"`
+}
+
+func (auth *ordersAuthUser) Authorize(action string) (interface{}, rest_errors.RestErr) {
+	permissions := &orderPermissions{
+		CanAccess: auth.AuthorizeAccess(),
+		CanUpdate: auth.AuthorizeUpdate(),
+		CanCancel: auth.AuthorizeUpdate(),
+	}
+
+	var hasPermission bool
+	switch action {
+	case "accessCollection":
+		hasPermission = auth.Can("accessCollection", consts.Orders) && permissions.CanAccess
+	case "access":
+		hasPermission = permissions.CanAccess
+	case "accept", "prepare", "ready", "complete":
+		hasPermission = permissions.CanUpdate
+	case "cancel":
+		hasPermission = permissions.CanCancel
+	default:
+		hasPermission = false
+	}
+
+	if hasPermission {
+		return permissions, nil
+	}
+
+	return nil, rest_errors.NewForbiddenError("You are not allowed to perform this action")
+}
@@ -11,6 +11,7 @@ type PageAuthorizor interface {
 	AuthorizeAccess() bool
 	AuthorizeUpdate() bool
 	AuthorizeDelete() bool
+	AuthorizePublish() bool
 	UserOwnsResource() bool
 }
 
@@ -38,6 +39,11 @@ func (auth *pagesAuthUser) AuthorizeDelete() bool {
 	return auth.IsAdmin() || (auth.IsManager() && auth.UserOwnsResource())
 }
 
+/* Only admins approve the pages submitted for review, publish or schedule them */
+func (auth *pagesAuthUser) AuthorizePublish() bool {
+	return auth.IsAdmin()
+}
+
 func (auth *pagesAuthUser) UserOwnsResource() bool {
 	return auth.Id == auth.AuthorId
 }
@@ -48,16 +54,18 @@ The idea is to authorize the user based on the action they want to perform on th
 Authorization for collection/list of resources is handled in the handler via the AuthorizeCollection method
 */
 type pagePermissions struct {
-	CanAccess bool `json:"canAccess"`
-	CanUpdate bool `json:"canUpdate"`
-	CanDelete bool `json:"canDelete"`
+	CanAccess  bool `json:"canAccess"`
+	CanUpdate  bool `json:"canUpdate"`
+	CanDelete  bool `json:"canDelete"`
+	CanPublish bool `json:"canPublish"`
 }
 
 func (auth *pagesAuthUser) Authorize(action string) (interface{}, rest_errors.RestErr) {
 	permissions := &pagePermissions{
-		CanAccess: auth.AuthorizeAccess(),
-		CanUpdate: auth.AuthorizeUpdate(),
-		CanDelete: auth.AuthorizeDelete(),
+		CanAccess:  auth.AuthorizeAccess(),
+		CanUpdate:  auth.AuthorizeUpdate(),
+		CanDelete:  auth.AuthorizeDelete(),
+		CanPublish: auth.AuthorizePublish(),
 	}
 
 	var hasPermission bool
@@ -70,8 +78,12 @@ func (auth *pagesAuthUser) Authorize(action string) (interface{}, rest_errors.Re
 		hasPermission = permissions.CanAccess
 	case "update":
 		hasPermission = permissions.CanUpdate
-	case "delete":
+	case "delete", "restore":
 		hasPermission = permissions.CanDelete
+	case "submit", "archive", "reopen":
+		hasPermission = permissions.CanUpdate
+	case "approve", "reject", "schedule", "publish":
+		hasPermission = permissions.CanPublish
 	default:
 		hasPermission = false
 	}
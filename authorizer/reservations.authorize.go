@@ -0,0 +1,88 @@
+package authorizer
+
+import (
+	"resturants-hub.com/m/v2/dto"
+	consts "resturants-hub.com/m/v2/packages/const"
+	rest_errors "resturants-hub.com/m/v2/packages/utils"
+)
+
+type ReservationAuthorizor interface {
+	Authorize(string) (interface{}, rest_errors.RestErr)
+	AuthorizeAccess() bool
+	AuthorizeUpdate() bool
+	UserOwnsResource() bool
+}
+
+type reservationsAuthUser struct {
+	*dto.BaseUser
+	ReservationRestaurantId int64
+}
+
+func NewReservationAuthorizer(currentUser *dto.BaseUser, restaurantId ...int64) ReservationAuthorizor {
+	if restaurantId == nil {
+		restaurantId = []int64{0}
+	}
+	return &reservationsAuthUser{currentUser, restaurantId[0]}
+}
+
+func (auth *reservationsAuthUser) AuthorizeAccess() bool {
+	return auth.IsAdmin() || (auth.IsManager() && auth.UserOwnsResource())
+}
+
+func (auth *reservationsAuthUser) AuthorizeUpdate() bool {
+	return auth.IsAdmin() || (auth.IsManager() && auth.UserOwnsResource())
+}
+
+/* A manager owns a reservation when it is made at the restaurant they manage */
+func (auth *reservationsAuthUser) UserOwnsResource() bool {
+	return auth.RestaurantId.Valid && auth.RestaurantId.Int64 == auth.ReservationRestaurantId
+}
+
+/*
+Use this reservationPermissions and authorization for member resource only.
+Reservations are never deleted: they are cancelled, which frees their table.
+Diners book through the public API, which only checks the "create" permission of the visitor
+*/
+type reservationPermissions struct {
+	CanAccess     bool `json:"canAccess"`
+	CanUpdate     bool `json:"canUpdate"`
+	CanConfirm    bool `json:"canConfirm"`
+	CanCancel     bool `json:"canCancel"`
+	CanMarkNoShow bool `json:"canMarkNoShow"`
+}
+
+func (auth *reservationsAuthUser) Authorize(action string) (interface{}, rest_errors.RestErr) {
+	permissions := &reservationPermissions{
+		CanAccess:     auth.AuthorizeAccess(),
+		CanUpdate:     auth.AuthorizeUpdate(),
+		CanConfirm:    auth.AuthorizeUpdate(),
+		CanCancel:     auth.AuthorizeUpdate(),
+		CanMarkNoShow: auth.AuthorizeUpdate(),
+	}
+
+	var hasPermission bool
+	switch action {
+	case "accessCollection":
+		hasPermission = auth.Can("accessCollection", consts.Reservations) && permissions.CanAccess
+	case "create":
+		hasPermission = auth.Can("create", consts.Reservations) && permissions.CanUpdate
+	case "access":
+		hasPermission = permissions.CanAccess
+	case "update":
+		hasPermission = permissions.CanUpdate
+	case "confirm":
+		hasPermission = permissions.CanConfirm
+	case "cancel":
+		hasPermission = permissions.CanCancel
+	case "no-show":
+		hasPermission = permissions.CanMarkNoShow
+	default:
+		hasPermission = false
+	}
+
+	if hasPermission {
+		return permissions, nil
+	}
+
+	return nil, rest_errors.NewForbiddenError("You are not allowed to perform this action")
+}
@@ -10,6 +10,7 @@ type RestaurantAuthorizor interface {
 	Authorize(string) (interface{}, rest_errors.RestErr)
 	AuthorizeAccess() bool
 	AuthorizeUpdate() bool
+	AuthorizeUpdateHours() bool
 	AuthorizeDelete() bool
 	UserOwnsResource() bool
 }
@@ -33,6 +34,11 @@ func (auth *restaurantAuthUser) AuthorizeUpdate() bool {
 	return auth.IsAdmin()
 }
 
+/* Managers keep the opening hours of their restaurant up to date themselves */
+func (auth *restaurantAuthUser) AuthorizeUpdateHours() bool {
+	return auth.IsAdmin() || (auth.IsManager() && auth.UserOwnsResource())
+}
+
 func (auth *restaurantAuthUser) AuthorizeDelete() bool {
 	return auth.IsAdmin()
 }
@@ -47,16 +53,18 @@ The idea is to authorize the user based on the action they want to perform on th
 Authorization for collection/list of resources is handled in the handler via the AuthorizeCollection method
 */
 type restaurantPermissions struct {
-	CanAccess bool `json:"canAccess"`
-	CanUpdate bool `json:"canUpdate"`
-	CanDelete bool `json:"canDelete"`
+	CanAccess      bool `json:"canAccess"`
+	CanUpdate      bool `json:"canUpdate"`
+	CanUpdateHours bool `json:"canUpdateHours"`
+	CanDelete      bool `json:"canDelete"`
 }
 
 func (auth *restaurantAuthUser) Authorize(action string) (interface{}, rest_errors.RestErr) {
 	permissions := &restaurantPermissions{
-		CanAccess: auth.AuthorizeAccess(),
-		CanUpdate: auth.AuthorizeUpdate(),
-		CanDelete: auth.AuthorizeDelete(),
+		CanAccess:      auth.AuthorizeAccess(),
+		CanUpdate:      auth.AuthorizeUpdate(),
+		CanUpdateHours: auth.AuthorizeUpdateHours(),
+		CanDelete:      auth.AuthorizeDelete(),
 	}
 
 	var hasPermission bool
@@ -69,7 +77,9 @@ func (auth *restaurantAuthUser) Authorize(action string) (interface{}, rest_erro
 		hasPermission = permissions.CanAccess
 	case "update":
 		hasPermission = permissions.CanUpdate
-	case "delete":
+	case "updateHours":
+		hasPermission = permissions.CanUpdateHours
+	case "delete", "restore":
 		hasPermission = permissions.CanDelete
 	default:
 		hasPermission = false
@@ -0,0 +1,89 @@
+package authorizer
+
+import (
+	"resturants-hub.com/m/v2/dto"
+	consts "resturants-hub.com/m/v2/packages/const"
+	rest_errors "resturants-hub.com/m/v2/packages/utils"
+)
+
+type ReviewAuthorizor interface {
+	Authorize(string) (interface{}, rest_errors.RestErr)
+	AuthorizeAccess() bool
+	AuthorizeReply() bool
+	AuthorizeModerate() bool
+	UserOwnsResource() bool
+}
+
+type reviewsAuthUser struct {
+	*dto.BaseUser
+	ReviewRestaurantId int64
+}
+
+func NewReviewAuthorizer(currentUser *dto.BaseUser, restaurantId ...int64) ReviewAuthorizor {
+	if restaurantId == nil {
+		restaurantId = []int64{0}
+	}
+	return &reviewsAuthUser{currentUser, restaurantId[0]}
+}
+
+func (auth *reviewsAuthUser) AuthorizeAccess() bool {
+	return auth.IsAdmin() || (auth.IsManager() && auth.UserOwnsResource())
+}
+
+func (auth *reviewsAuthUser) AuthorizeReply() bool {
+	return auth.IsAdmin() || (auth.IsManager() && auth.UserOwnsResource())
+}
+
+/* Reviews hidden by restaurants are restored or removed by admins only */
+func (auth *reviewsAuthUser) AuthorizeModerate() bool {
+	return auth.IsAdmin()
+}
+
+/* A manager owns a review when it is about a dish of the restaurant they manage */
+func (auth *reviewsAuthUser) UserOwnsResource() bool {
+	return auth.RestaurantId.Valid && auth.RestaurantId.Int64 == auth.ReviewRestaurantId
+}
+
+/*
+Use this reviewPermissions and authorization for member resource only.
+Diners review dishes through the public API, which only checks the permissions of the visitor
+*/
+type reviewPermissions struct {
+	CanAccess   bool `json:"canAccess"`
+	CanReply    bool `json:"canReply"`
+	CanHide     bool `json:"canHide"`
+	CanModerate bool `json:"canModerate"`
+}
+
+func (auth *reviewsAuthUser) Authorize(action string) (interface{}, rest_errors.RestErr) {
+	permissions := &reviewPermissions{
+		CanAccess:   auth.AuthorizeAccess(),
+		CanReply:    auth.AuthorizeReply(),
+		CanHide:     auth.AuthorizeReply(),
+		CanModerate: auth.AuthorizeModerate(),
+	}
+
+	var hasPermission bool
+	switch action {
+	case "accessCollection":
+		hasPermission = auth.Can("accessCollection", consts.Reviews) && permissions.CanAccess
+	case "moderationQueue":
+		hasPermission = auth.Can("accessCollection", consts.Reviews) && permissions.CanModerate
+	case "access":
+		hasPermission = permissions.CanAccess
+	case "reply":
+		hasPermission = permissions.CanReply
+	case "hide":
+		hasPermission = permissions.CanHide
+	case "restore", "remove":
+		hasPermission = permissions.CanModerate
+	default:
+		hasPermission = false
+	}
+
+	if hasPermission {
+		return permissions, nil
+	}
+
+	return nil, rest_errors.NewForbiddenError("You are not allowed to perform this action")
+}
@@ -65,7 +65,7 @@ func (auth *UsersAuthorizoruthUser) Authorize(action string) (interface{}, rest_
 		hasPermission = permissions.CanAccess
 	case "update":
 		hasPermission = permissions.CanUpdate
-	case "delete":
+	case "delete", "restore":
 		hasPermission = permissions.CanDelete
 	default:
 		hasPermission = false
@@ -0,0 +1,235 @@
+package configs
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	/* Keys are fetched again after this long, or sooner when a token is signed with an unknown key */
+	jwksCacheTTL = time.Hour
+	/* Unknown keys don't trigger fetching the keys more often than this */
+	jwksRefreshInterval = time.Minute
+	/* Tolerated clock skew with the providers */
+	idTokenLeeway = time.Minute
+)
+
+/* Algorithms ID tokens may be signed with, "none" and the HMAC ones are never accepted */
+var idTokenMethods = []string{"RS256", "RS384", "RS512", "PS256", "PS384", "PS512", "ES256", "ES384", "ES512"}
+
+var oidcClient = &http.Client{Timeout: 10 * time.Second}
+
+/* OidcDiscovery is the part of the .well-known/openid-configuration document of a provider used to log users in */
+type OidcDiscovery struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	UserinfoEndpoint      string `json:"userinfo_endpoint"`
+	JwksUri               string `json:"jwks_uri"`
+}
+
+var (
+	discoveries     = map[string]*OidcDiscovery{}
+	discoveriesLock sync.Mutex
+	keySets         = map[string]*jwks{}
+	keySetsLock     sync.Mutex
+)
+
+/*
+Discover reads the configuration of the provider at issuer, once per process: failures aren't cached so that
+a provider down at startup is discovered on the next login.
+*/
+func Discover(issuer string) (*OidcDiscovery, error) {
+	issuer = strings.TrimSuffix(issuer, "/")
+	discoveriesLock.Lock()
+	defer discoveriesLock.Unlock()
+	if discovery, exists := discoveries[issuer]; exists {
+		return discovery, nil
+	}
+
+	discovery := &OidcDiscovery{}
+	if err := getJson(issuer+"/.well-known/openid-configuration", discovery); err != nil {
+		return nil, fmt.Errorf("OIDC discovery of %s failed: %w", issuer, err)
+	}
+	/* The issuer of the document has to be the one it was fetched from (OpenID Connect Discovery 1.0, section 4.3) */
+	if strings.TrimSuffix(discovery.Issuer, "/") != issuer {
+		return nil, fmt.Errorf("OIDC discovery of %s returned the issuer %s", issuer, discovery.Issuer)
+	}
+	if discovery.AuthorizationEndpoint == "" || discovery.TokenEndpoint == "" || discovery.JwksUri == "" {
+		return nil, fmt.Errorf("OIDC discovery of %s is missing endpoints", issuer)
+	}
+	discoveries[issuer] = discovery
+	return discovery, nil
+}
+
+/*
+VerifyIDToken checks the signature of the ID token against the keys of the provider, along with its claims:
+it has to be issued by the provider, for our client, not to have expired and to carry the nonce of the login.
+*/
+func (config *SsoConfig) VerifyIDToken(raw string, nonce string) (jwt.MapClaims, error) {
+	if raw == "" {
+		return nil, errors.New("the provider returned no ID token")
+	}
+	keys := keySetFor(config.Discovery.JwksUri)
+
+	claims := jwt.MapClaims{}
+	_, err := jwt.ParseWithClaims(raw, claims, func(token *jwt.Token) (interface{}, error) {
+		kid, _ := token.Header["kid"].(string)
+		return keys.key(kid)
+	},
+		jwt.WithValidMethods(idTokenMethods),
+		jwt.WithIssuer(config.Discovery.Issuer),
+		jwt.WithAudience(config.ClientID),
+		jwt.WithExpirationRequired(),
+		jwt.WithLeeway(idTokenLeeway),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("invalid ID token: %w", err)
+	}
+	if claimed, _ := claims["nonce"].(string); claimed == "" || claimed != nonce {
+		return nil, errors.New("invalid ID token: nonce mismatch")
+	}
+	if subject, _ := claims.GetSubject(); subject == "" {
+		return nil, errors.New("invalid ID token: subject is missing")
+	}
+	return claims, nil
+}
+
+/* jwks caches the signing keys of a provider by key id */
+type jwks struct {
+	uri       string
+	mutex     sync.Mutex
+	keys      map[string]crypto.PublicKey
+	fetchedAt time.Time
+}
+
+func keySetFor(uri string) *jwks {
+	keySetsLock.Lock()
+	defer keySetsLock.Unlock()
+	if keySet, exists := keySets[uri]; exists {
+		return keySet
+	}
+	keySet := &jwks{uri: uri, keys: map[string]crypto.PublicKey{}}
+	keySets[uri] = keySet
+	return keySet
+}
+
+/* key returns the key of kid, fetching the keys again when they are stale or kid is unknown (keys were rotated) */
+func (keySet *jwks) key(kid string) (crypto.PublicKey, error) {
+	keySet.mutex.Lock()
+	defer keySet.mutex.Unlock()
+
+	key, known := keySet.lookup(kid)
+	age := time.Since(keySet.fetchedAt)
+	if known && age < jwksCacheTTL {
+		return key, nil
+	}
+	if !known && age < jwksRefreshInterval {
+		return nil, fmt.Errorf("unknown signing key %q", kid)
+	}
+
+	if err := keySet.fetch(); err != nil {
+		if known {
+			/* Stale keys are better than none while the provider can't be reached */
+			return key, nil
+		}
+		return nil, err
+	}
+	if key, known = keySet.lookup(kid); !known {
+		return nil, fmt.Errorf("unknown signing key %q", kid)
+	}
+	return key, nil
+}
+
+/* lookup finds the key of kid, tokens without kid are accepted from providers having a single key */
+func (keySet *jwks) lookup(kid string) (crypto.PublicKey, bool) {
+	if kid == "" && len(keySet.keys) == 1 {
+		for _, key := range keySet.keys {
+			return key, true
+		}
+	}
+	key, known := keySet.keys[kid]
+	return key, known
+}
+
+type jsonWebKey struct {
+	Kid string `json:"kid"`
+	Kty string `json:"kty"`
+	Use string `json:"use"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+func (keySet *jwks) fetch() error {
+	document := struct {
+		Keys []jsonWebKey `json:"keys"`
+	}{}
+	if err := getJson(keySet.uri, &document); err != nil {
+		return fmt.Errorf("fetching the keys of %s failed: %w", keySet.uri, err)
+	}
+
+	keys := map[string]crypto.PublicKey{}
+	for _, webKey := range document.Keys {
+		if webKey.Use != "" && webKey.Use != "sig" {
+			continue
+		}
+		/* Keys of unsupported types are skipped, tokens signed with them are rejected */
+		if key, err := webKey.publicKey(); err == nil {
+			keys[webKey.Kid] = key
+		}
+	}
+	keySet.keys = keys
+	keySet.fetchedAt = time.Now()
+	return nil
+}
+
+func (webKey *jsonWebKey) publicKey() (crypto.PublicKey, error) {
+	switch webKey.Kty {
+	case "RSA":
+		n, nErr := base64.RawURLEncoding.DecodeString(webKey.N)
+		e, eErr := base64.RawURLEncoding.DecodeString(webKey.E)
+		if nErr != nil || eErr != nil {
+			return nil, errors.New("malformed RSA key")
+		}
+		return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}, nil
+	case "EC":
+		curves := map[string]elliptic.Curve{"P-256": elliptic.P256(), "P-384": elliptic.P384(), "P-521": elliptic.P521()}
+		curve, supported := curves[webKey.Crv]
+		x, xErr := base64.RawURLEncoding.DecodeString(webKey.X)
+		y, yErr := base64.RawURLEncoding.DecodeString(webKey.Y)
+		if !supported || xErr != nil || yErr != nil {
+			return nil, errors.New("malformed EC key")
+		}
+		return &ecdsa.PublicKey{Curve: curve, X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}, nil
+	default:
+		return nil, fmt.Errorf("unsupported key type %s", webKey.Kty)
+	}
+}
+
+func getJson(url string, target interface{}) error {
+	response, err := oidcClient.Get(url)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+	if response.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s responded with status %d", url, response.StatusCode)
+	}
+	return json.NewDecoder(response.Body).Decode(target)
+}
@@ -1,59 +1,223 @@
 package configs
 
 import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
 	"os"
+	"regexp"
+	"strconv"
 
-	"github.com/conku/cache/memory"
 	"golang.org/x/oauth2"
-	"golang.org/x/oauth2/google"
-	consts "resturants-hub.com/m/v2/packages/const"
+	"resturants-hub.com/m/v2/dto"
 )
 
-var (
-	MemoryCache = memory.New()
-)
+var ErrUnknownProvider = errors.New("unknown SSO provider")
 
-type SsoConfig struct {
-	oauth2.Config
-	UserInfoUrl string
+const defaultSsoProvidersFile = "configs/sso_providers.json"
+
+/* Provider names show up in URLs (/api/auth/:provider) */
+var providerNameFormat = regexp.MustCompile(`^[a-z0-9_-]+$`)
+
+/*
+ClaimMapping names the claims (of the ID token, or of the user info of plain OAuth2 providers)
+the identity of the user is read from. Claims which aren't mapped default to the standard OIDC ones.
+*/
+type ClaimMapping struct {
+	Subject       string `json:"subject"`
+	Email         string `json:"email"`
+	EmailVerified string `json:"emailVerified"`
+	Name          string `json:"name"`
+	GivenName     string `json:"givenName"`
+	FamilyName    string `json:"familyName"`
+	Picture       string `json:"picture"`
+}
+
+/*
+SsoProvider is a provider users log in with, as listed in the providers file. OpenID Connect providers ("oidc")
+are configured from their issuer, plain OAuth2 providers ("oauth2") list their endpoints.
+*/
+type SsoProvider struct {
+	Name         string            `json:"name"`
+	Label        string            `json:"label"`
+	Type         string            `json:"type"`
+	Issuer       string            `json:"issuer"`
+	AuthUrl      string            `json:"authUrl"`
+	TokenUrl     string            `json:"tokenUrl"`
+	UserInfoUrl  string            `json:"userInfoUrl"`
+	ClientId     string            `json:"clientId"`
+	ClientSecret string            `json:"clientSecret"`
+	RedirectUrl  string            `json:"redirectUrl"`
+	Scopes       []string          `json:"scopes"`
+	AuthParams   map[string]string `json:"authParams"`
+	Claims       ClaimMapping      `json:"claims"`
 }
 
-func AuthentikEndpoints() oauth2.Endpoint {
-	return oauth2.Endpoint{
-		AuthURL:   os.Getenv("AUTH_URL"),
-		TokenURL:  os.Getenv("TOKEN_URL"),
-		AuthStyle: oauth2.AuthStyleInParams,
+/* Identity reads the identity of the user out of claims, following the mapping */
+func (mapping ClaimMapping) Identity(claims map[string]interface{}) *dto.SsoUserInfo {
+	claim := func(name string, standard string) string {
+		if name == "" {
+			name = standard
+		}
+		switch value := claims[name].(type) {
+		case string:
+			return value
+		case float64:
+			/* e.g. the numeric ids of users of plain OAuth2 providers */
+			return strconv.FormatFloat(value, 'f', -1, 64)
+		case bool:
+			return strconv.FormatBool(value)
+		default:
+			return ""
+		}
+	}
+
+	return &dto.SsoUserInfo{
+		Sub:        claim(mapping.Subject, "sub"),
+		Name:       claim(mapping.Name, "name"),
+		GivenName:  claim(mapping.GivenName, "given_name"),
+		FamilyName: claim(mapping.FamilyName, "family_name"),
+		Picture:    claim(mapping.Picture, "picture"),
+		Email:      claim(mapping.Email, "email"),
+		/* Some providers send it as a string */
+		EmailVerified: claim(mapping.EmailVerified, "email_verified") == "true",
 	}
 }
 
-func NewSsoConfig(provider consts.SsoProvider) *SsoConfig {
-	var config SsoConfig
+func (provider *SsoProvider) IsOidc() bool {
+	return provider.Type == "oidc"
+}
 
-	switch provider {
-	case consts.Google:
-		config = SsoConfig{
-			oauth2.Config{
-				ClientID:     os.Getenv("GOOGLE_CLIENT_ID"),
-				ClientSecret: os.Getenv("GOOGLE_SECRET_KEY"),
-				Endpoint:     google.Endpoint,
-				RedirectURL:  os.Getenv("GOOGLE_SSO_CALLBACK_URL"),
-				Scopes:       []string{"email", "profile"},
-			},
-			os.Getenv("GOOGLE_SSO_USER_INFO_URL"),
+func (provider *SsoProvider) validate() error {
+	if !providerNameFormat.MatchString(provider.Name) {
+		return fmt.Errorf("invalid provider name %q", provider.Name)
+	}
+	switch provider.Type {
+	case "oidc":
+		if provider.Issuer == "" {
+			return fmt.Errorf("provider %s: issuer is required", provider.Name)
+		}
+	case "oauth2":
+		if provider.AuthUrl == "" || provider.TokenUrl == "" || provider.UserInfoUrl == "" {
+			return fmt.Errorf("provider %s: authUrl, tokenUrl and userInfoUrl are required", provider.Name)
 		}
 	default:
-		config = SsoConfig{
-			oauth2.Config{
-				ClientID:     os.Getenv("AUTHENTIK_CLIENT_ID"),
-				ClientSecret: os.Getenv("AUTHENTIK_SECRET_KEY"),
-				Endpoint:     AuthentikEndpoints(),
-				RedirectURL:  os.Getenv("AUTHENTIK_SSO_CALLBACK_URL"),
-				Scopes:       []string{"email", "profile", "offline_access", "openid"},
-			},
-			os.Getenv("AUTHENTIK_SSO_USER_INFO_URL"),
+		return fmt.Errorf("provider %s: type must be oidc or oauth2", provider.Name)
+	}
+	return nil
+}
+
+/* Providers of the registry, in the order of the providers file */
+var ssoProviders = []*SsoProvider{}
+
+/*
+LoadSsoProviders reads the provider registry from SSO_PROVIDERS_FILE (configs/sso_providers.json by default).
+References to env vars (${GOOGLE_CLIENT_ID}) are expanded so that secrets stay out of the file.
+Providers whose clientId is left unset aren't configured for this deployment: they are skipped with a warning.
+*/
+func LoadSsoProviders() error {
+	path := os.Getenv("SSO_PROVIDERS_FILE")
+	if path == "" {
+		path = defaultSsoProvidersFile
+	}
+	contents, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("reading SSO providers: %w", err)
+	}
+
+	file := struct {
+		Providers []*SsoProvider `json:"providers"`
+	}{}
+	if err := json.Unmarshal([]byte(os.ExpandEnv(string(contents))), &file); err != nil {
+		return fmt.Errorf("parsing SSO providers of %s: %w", path, err)
+	}
+
+	names := map[string]bool{}
+	providers := []*SsoProvider{}
+	for _, provider := range file.Providers {
+		if provider.ClientId == "" {
+			log.Printf("SSO provider %s of %s is skipped, its clientId is not set\n", provider.Name, path)
+			continue
+		}
+		if err := provider.validate(); err != nil {
+			return fmt.Errorf("SSO providers of %s: %w", path, err)
+		}
+		if names[provider.Name] {
+			return fmt.Errorf("SSO providers of %s: provider %s is listed twice", path, provider.Name)
+		}
+		names[provider.Name] = true
+		if provider.Label == "" {
+			provider.Label = provider.Name
+		}
+		providers = append(providers, provider)
+	}
+	ssoProviders = providers
+	return nil
+}
+
+/* SsoProviders lists the providers users can log in with */
+func SsoProviders() []*SsoProvider {
+	return ssoProviders
+}
+
+func FindSsoProvider(name string) (*SsoProvider, error) {
+	for _, provider := range ssoProviders {
+		if provider.Name == name {
+			return provider, nil
 		}
 	}
+	return nil, fmt.Errorf("%w: %s", ErrUnknownProvider, name)
+}
 
-	return &config
+type SsoConfig struct {
+	oauth2.Config
+	Provider *SsoProvider
+	/* Discovered configuration of OpenID Connect providers, nil for plain OAuth2 ones */
+	Discovery *OidcDiscovery
+}
 
+/* NewSsoConfig configures the provider of the registry named name, the endpoints of OIDC providers are discovered from their issuer */
+func NewSsoConfig(name string) (*SsoConfig, error) {
+	provider, err := FindSsoProvider(name)
+	if err != nil {
+		return nil, err
+	}
+
+	config := &SsoConfig{
+		Config: oauth2.Config{
+			ClientID:     provider.ClientId,
+			ClientSecret: provider.ClientSecret,
+			Endpoint:     oauth2.Endpoint{AuthURL: provider.AuthUrl, TokenURL: provider.TokenUrl},
+			RedirectURL:  provider.RedirectUrl,
+			Scopes:       provider.Scopes,
+		},
+		Provider: provider,
+	}
+	if provider.IsOidc() {
+		discovery, err := Discover(provider.Issuer)
+		if err != nil {
+			return nil, err
+		}
+		config.Endpoint = oauth2.Endpoint{AuthURL: discovery.AuthorizationEndpoint, TokenURL: discovery.TokenEndpoint}
+		config.Discovery = discovery
+	}
+	return config, nil
+}
+
+/* UserInfoUrl is where the profile of the user is read from when it isn't part of the ID token */
+func (config *SsoConfig) UserInfoUrl() string {
+	if config.Discovery != nil {
+		return config.Discovery.UserinfoEndpoint
+	}
+	return config.Provider.UserInfoUrl
+}
+
+/* AuthCodeOptions are the extra params of the authorization URL of the provider (e.g. access_type=offline) */
+func (config *SsoConfig) AuthCodeOptions() []oauth2.AuthCodeOption {
+	options := []oauth2.AuthCodeOption{}
+	for key, value := range config.Provider.AuthParams {
+		options = append(options, oauth2.SetAuthURLParam(key, value))
+	}
+	return options
 }
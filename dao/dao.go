@@ -1,8 +1,15 @@
 package dao
 
 import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
 	"github.com/jmoiron/sqlx"
 	"resturants-hub.com/m/v2/database"
+	"resturants-hub.com/m/v2/dto"
 	rest_errors "resturants-hub.com/m/v2/packages/utils"
 )
 
@@ -24,13 +31,20 @@ func ErrorMessage(errorKey string) string {
 func UniquenessErrors(errorKey string) *rest_errors.ValidationErrs {
 	causes := rest_errors.ValidationErrs{}
 	errKeyMaps := map[string]string{
-		"restaurants_name_key":  "name",
-		"restaurants_email_key": "email",
-		"restaurants_phone_key": "phone",
-		"fk_user":               "userId",
-		"pages_name_key":        "name",
-		"pages_email_key":       "email",
-		"pages_phone_key":       "phone",
+		"restaurants_name_key":                 "name",
+		"restaurants_email_key":                "email",
+		"restaurants_phone_key":                "phone",
+		"restaurants_slug_key":                 "slug",
+		"fk_user":                              "userId",
+		"pages_name_key":                       "name",
+		"pages_email_key":                      "email",
+		"pages_phone_key":                      "phone",
+		"pages_restaurant_slug_key":            "slug",
+		"tables_restaurant_name_key":           "name",
+		"reviews_dish_author_key":              "authorEmail",
+		"menu_categories_restaurant_name_key":  "name",
+		"user_identities_provider_subject_key": "subject",
+		"user_identities_user_provider_key":    "provider",
 	}
 
 	attr := errKeyMaps[errorKey]
@@ -39,3 +53,84 @@ func UniquenessErrors(errorKey string) *rest_errors.ValidationErrs {
 	}
 	return &causes
 }
+
+/*
+freeSlug returns baseSlug if it isn't taken yet, otherwise baseSlug followed by the number after the highest one
+taken (about-2, about-3...). taken are the slugs in use starting with baseSlug.
+*/
+func freeSlug(baseSlug string, taken []string) string {
+	baseTaken := false
+	lastNumber := 1
+	for _, takenSlug := range taken {
+		if takenSlug == baseSlug {
+			baseTaken = true
+			continue
+		}
+		suffix, hasSuffix := strings.CutPrefix(takenSlug, baseSlug+"-")
+		if number, err := strconv.Atoi(suffix); hasSuffix && err == nil && number > lastNumber {
+			lastNumber = number
+		}
+	}
+
+	if !baseTaken {
+		return baseSlug
+	}
+	return fmt.Sprintf("%s-%d", baseSlug, lastNumber+1)
+}
+
+/*
+SqlBuilderError converts an error raised while building a query into a rest error.
+Errors which already are rest errors (e.g. invalid filters) are returned as they are
+*/
+func SqlBuilderError(err error) rest_errors.RestErr {
+	if restErr, ok := err.(rest_errors.RestErr); ok {
+		return restErr
+	}
+	return rest_errors.NewInternalServerError(err)
+}
+
+/* Query param through which admins list soft deleted records: filter[deleted]=include|only */
+const DeletedFilterParam = "filter[deleted]"
+
+/*
+deletedScope hides soft deleted records, unless an admin asks for them with filter[deleted]=include
+(deleted records are listed along with the others) or filter[deleted]=only.
+The param is consumed so that it doesn't reach the sql builder as a filter
+*/
+func deletedScope(params url.Values, user *dto.BaseUser) (database.Scope, rest_errors.RestErr) {
+	filter := params.Get(DeletedFilterParam)
+	params.Del(DeletedFilterParam)
+	if !user.IsAdmin() {
+		return database.Scope{"deleted_at": nil}, nil
+	}
+
+	switch filter {
+	case "", "exclude":
+		return database.Scope{"deleted_at": nil}, nil
+	case "include":
+		return database.Scope{}, nil
+	case "only":
+		return database.Scope{"deleted_at__isnull": false}, nil
+	default:
+		causes := rest_errors.ValidationErrs{}
+		causes[DeletedFilterParam] = append(causes[DeletedFilterParam], map[string]interface{}{
+			"error":     "invalid_value",
+			"value":     filter,
+			"supported": []string{"exclude", "include", "only"},
+		})
+		return nil, rest_errors.NewRestError("Invalid filter parameters", http.StatusBadRequest, "bad_request", causes)
+	}
+}
+
+/* Count the records of tableName matching the same params and scopes used to list them */
+func (connection *connection) count(tableName string, params url.Values, scopes ...database.Scope) (uint64, rest_errors.RestErr) {
+	var total uint64
+	sqlQuery, args, buildErr := connection.sqlBuilder.Count(tableName, params, scopes...)
+	if buildErr != nil {
+		return 0, SqlBuilderError(buildErr)
+	}
+	if err := connection.db.Get(&total, sqlQuery, args...); err != nil {
+		return 0, rest_errors.NewInternalServerError(err)
+	}
+	return total, nil
+}
@@ -0,0 +1,384 @@
+package dao
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/mitchellh/mapstructure"
+	"resturants-hub.com/m/v2/database"
+	"resturants-hub.com/m/v2/dto"
+	consts "resturants-hub.com/m/v2/packages/const"
+	rest_errors "resturants-hub.com/m/v2/packages/utils"
+)
+
+type DishesDao interface {
+	CreateDish(*dto.CreateDishPayload, *dto.DishMenuPayload) (*dto.Dish, rest_errors.RestErr)
+	SearchDishes(url.Values) (dto.Dishes, uint64, rest_errors.RestErr)
+	AuthorizedDishesCollection(url.Values, *dto.BaseUser) (dto.Dishes, uint64, rest_errors.RestErr)
+	GetDish(restaurantId *int64, id *int64) (*dto.Dish, rest_errors.RestErr)
+	UpdateDish(*dto.Dish, interface{}, *dto.DishMenuPayload) (*dto.Dish, rest_errors.RestErr)
+	DeleteDish(*dto.Dish) rest_errors.RestErr
+	PublicDishesCollection(restaurantId *int64, params url.Values) (dto.Dishes, uint64, rest_errors.RestErr)
+}
+
+func NewDishesDao() DishesDao {
+	return &connection{
+		db:         database.DB,
+		sqlBuilder: database.NewSqlBuilder(),
+	}
+}
+
+/* CreateDish stores the dish along with its place on the menu (category, tags, allergens...) in a single transaction */
+func (connection *connection) CreateDish(payload *dto.CreateDishPayload, menu *dto.DishMenuPayload) (*dto.Dish, rest_errors.RestErr) {
+	tx, err := connection.db.Beginx()
+	if err != nil {
+		return nil, rest_errors.NewInternalServerError(err)
+	}
+	/* Rolling back a committed transaction is a no-op */
+	defer tx.Rollback()
+
+	dish := &dto.Dish{}
+	sqlQuery, args, buildErr := connection.sqlBuilder.Insert("dishes", payload)
+	if buildErr != nil {
+		return nil, SqlBuilderError(buildErr)
+	}
+	if err := tx.QueryRowx(sqlQuery, args...).StructScan(dish); err != nil {
+		if uniquenessViolation, constraintName := database.HasUniquenessViolation(err); uniquenessViolation {
+			return nil, rest_errors.NewValidationError(UniquenessErrors(constraintName))
+		}
+		return nil, rest_errors.NewInternalServerError(err)
+	}
+
+	if menuErr := connection.setDishMenu(tx, dish, menu); menuErr != nil {
+		return nil, menuErr
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, rest_errors.NewInternalServerError(err)
+	}
+	return dish, nil
+}
+
+/*
+setDishMenu applies the given menu attributes to the dish: the columns are updated along with the dish,
+while the tags replace the former tags of the dish, the ones the restaurant doesn't have yet being created.
+*/
+func (connection *connection) setDishMenu(tx *sqlx.Tx, dish *dto.Dish, menu *dto.DishMenuPayload) rest_errors.RestErr {
+	if columns := menu.Columns(); len(columns) > 0 {
+		sqlQuery, args, buildErr := connection.sqlBuilder.UpdateWhere("dishes", columns, map[string]interface{}{"id": dish.Id})
+		if buildErr != nil {
+			return SqlBuilderError(buildErr)
+		}
+		if err := tx.QueryRowx(sqlQuery, args...).StructScan(dish); err != nil {
+			return rest_errors.NewInternalServerError(err)
+		}
+	}
+
+	if !menu.Has("tags") {
+		return connection.withTags(tx, dish)
+	}
+
+	names := menu.TagNames()
+	sqlQuery, args, buildErr := connection.sqlBuilder.DeleteWhere("dish_tags", map[string]interface{}{"dish_id": dish.Id})
+	if buildErr != nil {
+		return SqlBuilderError(buildErr)
+	}
+	if _, err := tx.Exec(sqlQuery, args...); err != nil {
+		return rest_errors.NewInternalServerError(err)
+	}
+	dish.Tags = names
+	if len(names) == 0 {
+		return nil
+	}
+
+	newTags := make(dto.Tags, len(names))
+	for index, name := range names {
+		newTags[index] = dto.Tag{RestaurantId: dish.RestaurantId, Name: name}
+	}
+	sqlQuery, args, buildErr = connection.sqlBuilder.InsertIgnore("tags", newTags)
+	if buildErr != nil {
+		return SqlBuilderError(buildErr)
+	}
+	if _, err := tx.Exec(sqlQuery, args...); err != nil {
+		return rest_errors.NewInternalServerError(err)
+	}
+
+	var tags dto.Tags
+	sqlQuery, args, buildErr = connection.sqlBuilder.SearchBy("tags", map[string]interface{}{"restaurant_id": dish.RestaurantId, "name__in": names})
+	if buildErr != nil {
+		return SqlBuilderError(buildErr)
+	}
+	if err := sqlx.Select(tx, &tags, sqlQuery, args...); err != nil {
+		return rest_errors.NewInternalServerError(err)
+	}
+
+	dishTags := make([]dto.DishTag, len(tags))
+	for index, tag := range tags {
+		dishTags[index] = dto.DishTag{DishId: dish.Id, TagId: tag.Id}
+	}
+	sqlQuery, args, buildErr = connection.sqlBuilder.Insert("dish_tags", dishTags)
+	if buildErr != nil {
+		return SqlBuilderError(buildErr)
+	}
+	if _, err := tx.Exec(sqlQuery, args...); err != nil {
+		return rest_errors.NewInternalServerError(err)
+	}
+	return nil
+}
+
+/* dishTags reads the tag names of the dishes, by dish id and sorted by name */
+func (connection *connection) dishTags(queryer sqlx.Queryer, dishes dto.Dishes) (map[int64][]string, rest_errors.RestErr) {
+	tags := map[int64][]string{}
+	if len(dishes) == 0 {
+		return tags, nil
+	}
+	ids := make([]int64, len(dishes))
+	for index, dish := range dishes {
+		ids[index] = dish.Id
+	}
+
+	var names []dto.DishTagName
+	sqlQuery, args, buildErr := connection.sqlBuilder.SearchBy("dish_tag_names", map[string]interface{}{"dish_id__in": ids})
+	if buildErr != nil {
+		return nil, SqlBuilderError(buildErr)
+	}
+	if err := sqlx.Select(queryer, &names, sqlQuery, args...); err != nil {
+		return nil, rest_errors.NewInternalServerError(err)
+	}
+
+	sort.Slice(names, func(i, j int) bool { return names[i].Name < names[j].Name })
+	for _, name := range names {
+		tags[name.DishId] = append(tags[name.DishId], name.Name)
+	}
+	return tags, nil
+}
+
+/* withDishTags sets the tags of the dishes */
+func (connection *connection) withDishTags(queryer sqlx.Queryer, dishes dto.Dishes) rest_errors.RestErr {
+	tags, tagsErr := connection.dishTags(queryer, dishes)
+	if tagsErr != nil {
+		return tagsErr
+	}
+	for index, dish := range dishes {
+		dishes[index].Tags = tags[dish.Id]
+		if dishes[index].Tags == nil {
+			dishes[index].Tags = []string{}
+		}
+	}
+	return nil
+}
+
+/* withTags sets the tags of a single dish */
+func (connection *connection) withTags(queryer sqlx.Queryer, dish *dto.Dish) rest_errors.RestErr {
+	dishes := dto.Dishes{*dish}
+	if tagsErr := connection.withDishTags(queryer, dishes); tagsErr != nil {
+		return tagsErr
+	}
+	dish.Tags = dishes[0].Tags
+	return nil
+}
+
+/*
+tagsScope turns the tags params into scopes on the dishes of the restaurant, as tags are stored apart:
+tags=spicy,vegan (or tags__in, tags__hasany) matches the dishes having any of them, tags__has the ones
+having all of them and tags__hasnone the ones having none of them. The tags params are consumed.
+*/
+func (connection *connection) tagsScope(params url.Values, restaurantId *int64) ([]database.Scope, rest_errors.RestErr) {
+	scopes := []database.Scope{}
+	causes := rest_errors.ValidationErrs{}
+	for key, value := range params {
+		splits := strings.SplitN(key, "__", 2)
+		if splits[0] != "tags" {
+			continue
+		}
+		params.Del(key)
+
+		operator := ""
+		if len(splits) == 2 {
+			operator = splits[1]
+		}
+		filters := []map[string]interface{}{}
+		inclusion := "in"
+		switch operator {
+		case "", "in", "hasany":
+			filters = append(filters, map[string]interface{}{"name__in": value})
+		case "has":
+			for _, name := range strings.Split(strings.Join(value, ","), ",") {
+				filters = append(filters, map[string]interface{}{"name": strings.TrimSpace(name)})
+			}
+		case "hasnone":
+			inclusion = "notin"
+			filters = append(filters, map[string]interface{}{"name__in": value})
+		default:
+			causes[key] = append(causes[key], map[string]interface{}{
+				"error":     "unsupported_operator",
+				"operator":  operator,
+				"supported": []string{"in", "has", "hasany", "hasnone"},
+			})
+			continue
+		}
+
+		for _, filter := range filters {
+			if restaurantId != nil {
+				filter["restaurant_id"] = *restaurantId
+			}
+			scope, buildErr := connection.sqlBuilder.InScope("id", inclusion, "dish_tag_names", "dish_id", filter)
+			if buildErr != nil {
+				return nil, SqlBuilderError(buildErr)
+			}
+			scopes = append(scopes, scope)
+		}
+	}
+
+	if len(causes) > 0 {
+		return nil, rest_errors.NewRestError("Invalid filter parameters", http.StatusBadRequest, "bad_request", causes)
+	}
+	return scopes, nil
+}
+
+func (connection *connection) GetDish(restaurantId *int64, id *int64) (*dto.Dish, rest_errors.RestErr) {
+	dish := &dto.Dish{}
+	query, args, buildErr := connection.sqlBuilder.Find("dishes", map[string]interface{}{"id": id, "restaurant_id": restaurantId, "deleted_at": nil})
+	if buildErr != nil {
+		return nil, SqlBuilderError(buildErr)
+	}
+	err := connection.db.Get(dish, query, args...)
+
+	if err != nil {
+		message := fmt.Sprintf("Sorry, the dish with id %v doesn't exist", *id)
+		return nil, rest_errors.NewNotFoundError(message)
+	}
+
+	if tagsErr := connection.withTags(connection.db, dish); tagsErr != nil {
+		return nil, tagsErr
+	}
+	return dish, nil
+}
+
+func (connection *connection) SearchDishes(params url.Values) (dto.Dishes, uint64, rest_errors.RestErr) {
+	var restaurantId *int64
+	if id, err := strconv.ParseInt(params.Get("restaurant_id"), 10, 64); err == nil {
+		restaurantId = &id
+	}
+	scopes, scopeErr := connection.tagsScope(params, restaurantId)
+	if scopeErr != nil {
+		return nil, 0, scopeErr
+	}
+	scopes = append(scopes, database.Scope{"deleted_at": nil})
+
+	var dishes dto.Dishes
+	sqlQuery, args, buildErr := connection.sqlBuilder.Filter("dishes", params, scopes...)
+	if buildErr != nil {
+		return nil, 0, SqlBuilderError(buildErr)
+	}
+	err := connection.db.Select(&dishes, sqlQuery, args...)
+	if err != nil {
+		return nil, 0, rest_errors.NewNotFoundError(err.Error())
+	}
+	if tagsErr := connection.withDishTags(connection.db, dishes); tagsErr != nil {
+		return nil, 0, tagsErr
+	}
+
+	total, countErr := connection.count("dishes", params, scopes...)
+	if countErr != nil {
+		return nil, 0, countErr
+	}
+
+	return dishes, total, nil
+}
+
+func (connection *connection) AuthorizedDishesCollection(params url.Values, user *dto.BaseUser) (dto.Dishes, uint64, rest_errors.RestErr) {
+	switch user.Role {
+	case consts.Admin:
+		return connection.SearchDishes(params)
+	case consts.Manager:
+		/* Managers can only list the menu of their own restaurant */
+		if !user.RestaurantId.Valid {
+			return dto.Dishes{}, 0, nil
+		}
+		params.Set("restaurant_id", fmt.Sprint(user.RestaurantId.Int64))
+		return connection.SearchDishes(params)
+	default:
+		return dto.Dishes{}, 0, nil
+	}
+}
+
+/* UpdateDish updates the dish and its place on the menu in a single transaction, menu attributes which aren't given are kept */
+func (connection *connection) UpdateDish(dish *dto.Dish, payload interface{}, menu *dto.DishMenuPayload) (*dto.Dish, rest_errors.RestErr) {
+	tx, err := connection.db.Beginx()
+	if err != nil {
+		return nil, rest_errors.NewInternalServerError(err)
+	}
+	defer tx.Rollback()
+
+	/* Requests may only change the menu attributes of the dish */
+	if data, isMap := payload.(map[string]interface{}); !isMap || len(data) > 0 {
+		// Convert payload to Dish struct: this is to ensure that attribute names are mapped with db column names
+		payloadDish := &dto.Dish{}
+		mapstructure.Decode(payload, payloadDish)
+
+		sqlQuery, args, buildErr := connection.sqlBuilder.Update("dishes", &dish.Id, payloadDish)
+		if buildErr != nil {
+			return nil, SqlBuilderError(buildErr)
+		}
+		if err := tx.QueryRowx(sqlQuery, args...).StructScan(dish); err != nil {
+			if uniquenessViolation, constraintName := database.HasUniquenessViolation(err); uniquenessViolation {
+				return nil, rest_errors.NewValidationError(UniquenessErrors(constraintName))
+			}
+			return nil, rest_errors.NewInternalServerError(err)
+		}
+	}
+
+	if menuErr := connection.setDishMenu(tx, dish, menu); menuErr != nil {
+		return nil, menuErr
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, rest_errors.NewInternalServerError(err)
+	}
+	return dish, nil
+}
+
+/* DeleteDish soft deletes the dish, it leaves the menu and is purged with its reviews after the retention period */
+func (connection *connection) DeleteDish(dish *dto.Dish) rest_errors.RestErr {
+	sqlQuery, args, buildErr := connection.sqlBuilder.SoftDelete("dishes", &dish.Id)
+	if buildErr != nil {
+		return SqlBuilderError(buildErr)
+	}
+	if err := connection.db.QueryRowx(sqlQuery, args...).StructScan(dish); err != nil {
+		return rest_errors.NewInternalServerError(err)
+	}
+	return nil
+}
+
+/* Menu of a restaurant, as shown to anonymous visitors */
+func (connection *connection) PublicDishesCollection(restaurantId *int64, params url.Values) (dto.Dishes, uint64, rest_errors.RestErr) {
+	scopes, scopeErr := connection.tagsScope(params, restaurantId)
+	if scopeErr != nil {
+		return nil, 0, scopeErr
+	}
+	scopes = append(scopes, database.Scope{"restaurant_id": restaurantId, "deleted_at": nil})
+
+	var dishes dto.Dishes
+	sqlQuery, args, buildErr := connection.sqlBuilder.Filter("dishes", params, scopes...)
+	if buildErr != nil {
+		return nil, 0, SqlBuilderError(buildErr)
+	}
+	err := connection.db.Select(&dishes, sqlQuery, args...)
+	if err != nil {
+		return nil, 0, rest_errors.NewNotFoundError(err.Error())
+	}
+	if tagsErr := connection.withDishTags(connection.db, dishes); tagsErr != nil {
+		return nil, 0, tagsErr
+	}
+
+	total, countErr := connection.count("dishes", params, scopes...)
+	if countErr != nil {
+		return nil, 0, countErr
+	}
+
+	return dishes, total, nil
+}
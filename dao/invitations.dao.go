@@ -16,7 +16,7 @@ type InvitationsDao interface {
 	UpdateInvitation(*dto.Invitation, interface{}) (*dto.Invitation, rest_errors.RestErr)
 	GetInvitation(id *int64) (*dto.Invitation, rest_errors.RestErr)
 	SearchInvitations(params map[string]interface{}) *dto.Invitation
-	AuthorizedInvitationsCollection(url.Values, *dto.BaseUser) (dto.Invitations, rest_errors.RestErr)
+	AuthorizedInvitationsCollection(url.Values, *dto.BaseUser) (dto.Invitations, uint64, rest_errors.RestErr)
 }
 
 func NewInvitationDao() InvitationsDao {
@@ -28,9 +28,12 @@ func NewInvitationDao() InvitationsDao {
 
 func (connection *connection) CreateInvitation(payload *dto.CreateInvitationPayload) (*dto.Invitation, rest_errors.RestErr) {
 	invitation := &dto.Invitation{}
-	sqlQuery := connection.sqlBuilder.Insert("invitations", payload)
+	sqlQuery, args, buildErr := connection.sqlBuilder.Insert("invitations", payload)
+	if buildErr != nil {
+		return nil, SqlBuilderError(buildErr)
+	}
 
-	row := connection.db.QueryRowx(sqlQuery)
+	row := connection.db.QueryRowx(sqlQuery, args...)
 	if row.Err() != nil {
 		if uniquenessViolation, constraintName := database.HasUniquenessViolation(row.Err()); uniquenessViolation {
 			return nil, rest_errors.InvalidError(ErrorMessage(constraintName))
@@ -43,8 +46,11 @@ func (connection *connection) CreateInvitation(payload *dto.CreateInvitationPayl
 }
 
 func (connection *connection) UpdateInvitation(invitation *dto.Invitation, payload interface{}) (*dto.Invitation, rest_errors.RestErr) {
-	sqlQuery := connection.sqlBuilder.Update("invitations", &invitation.Id, payload)
-	row := connection.db.QueryRowx(sqlQuery)
+	sqlQuery, args, buildErr := connection.sqlBuilder.Update("invitations", &invitation.Id, payload)
+	if buildErr != nil {
+		return nil, SqlBuilderError(buildErr)
+	}
+	row := connection.db.QueryRowx(sqlQuery, args...)
 	if row.Err() != nil {
 		if uniquenessViolation, constraintName := database.HasUniquenessViolation(row.Err()); uniquenessViolation {
 			return nil, rest_errors.InvalidError(ErrorMessage(constraintName))
@@ -57,8 +63,11 @@ func (connection *connection) UpdateInvitation(invitation *dto.Invitation, paylo
 
 func (connection *connection) GetInvitation(id *int64) (*dto.Invitation, rest_errors.RestErr) {
 	invitation := &dto.Invitation{}
-	query := connection.sqlBuilder.Find("invitations", map[string]interface{}{"id": id})
-	err := connection.db.Get(invitation, query)
+	query, args, buildErr := connection.sqlBuilder.Find("invitations", map[string]interface{}{"id": id})
+	if buildErr != nil {
+		return nil, SqlBuilderError(buildErr)
+	}
+	err := connection.db.Get(invitation, query, args...)
 
 	if err != nil {
 		message := fmt.Sprintf("Sorry, invitation with id %v doesn't exist", *id)
@@ -70,8 +79,12 @@ func (connection *connection) GetInvitation(id *int64) (*dto.Invitation, rest_er
 func (connection *connection) SearchInvitations(params map[string]interface{}) *dto.Invitation {
 	invitation := &dto.Invitation{}
 
-	query := connection.sqlBuilder.SearchBy("invitations", params)
-	err := connection.db.Get(invitation, query)
+	query, args, buildErr := connection.sqlBuilder.SearchBy("invitations", params)
+	if buildErr != nil {
+		fmt.Println("Error Occured:", buildErr)
+		return nil
+	}
+	err := connection.db.Get(invitation, query, args...)
 	if err != nil {
 		fmt.Println("Error Occured:", err)
 		return nil
@@ -80,22 +93,30 @@ func (connection *connection) SearchInvitations(params map[string]interface{}) *
 	return invitation
 }
 
-func (connection *connection) AuthorizedInvitationsCollection(params url.Values, user *dto.BaseUser) (dto.Invitations, rest_errors.RestErr) {
+func (connection *connection) AuthorizedInvitationsCollection(params url.Values, user *dto.BaseUser) (dto.Invitations, uint64, rest_errors.RestErr) {
 	switch user.Role {
 	case consts.Admin:
 		return connection.search(params)
 	default:
-		return dto.Invitations{}, nil
+		return dto.Invitations{}, 0, nil
 	}
 }
 
-func (connection *connection) search(params url.Values) (dto.Invitations, rest_errors.RestErr) {
+func (connection *connection) search(params url.Values) (dto.Invitations, uint64, rest_errors.RestErr) {
 	var invitations dto.Invitations
-	sqlQuery := connection.sqlBuilder.Filter("invitations", params)
-	err := connection.db.Select(&invitations, sqlQuery)
+	sqlQuery, args, buildErr := connection.sqlBuilder.Filter("invitations", params)
+	if buildErr != nil {
+		return nil, 0, SqlBuilderError(buildErr)
+	}
+	err := connection.db.Select(&invitations, sqlQuery, args...)
 	if err != nil {
-		return nil, rest_errors.NewNotFoundError(err.Error())
+		return nil, 0, rest_errors.NewNotFoundError(err.Error())
+	}
+
+	total, countErr := connection.count("invitations", params)
+	if countErr != nil {
+		return nil, 0, countErr
 	}
 
-	return invitations, nil
+	return invitations, total, nil
 }
@@ -0,0 +1,137 @@
+package dao
+
+import (
+	"fmt"
+	"net/url"
+
+	"github.com/mitchellh/mapstructure"
+	"resturants-hub.com/m/v2/database"
+	"resturants-hub.com/m/v2/dto"
+	rest_errors "resturants-hub.com/m/v2/packages/utils"
+)
+
+type MenuCategoriesDao interface {
+	CreateMenuCategory(*dto.CreateMenuCategoryPayload) (*dto.MenuCategory, rest_errors.RestErr)
+	GetMenuCategory(restaurantId *int64, id *int64) (*dto.MenuCategory, rest_errors.RestErr)
+	SearchMenuCategories(url.Values, ...database.Scope) (dto.MenuCategories, uint64, rest_errors.RestErr)
+	UpdateMenuCategory(*dto.MenuCategory, interface{}) (*dto.MenuCategory, rest_errors.RestErr)
+	DeleteMenuCategory(*dto.MenuCategory) rest_errors.RestErr
+}
+
+func NewMenuCategoriesDao() MenuCategoriesDao {
+	return &connection{
+		db:         database.DB,
+		sqlBuilder: database.NewSqlBuilder(),
+	}
+}
+
+func (connection *connection) CreateMenuCategory(payload *dto.CreateMenuCategoryPayload) (*dto.MenuCategory, rest_errors.RestErr) {
+	/* New categories come last unless told otherwise */
+	if payload.Position == 0 {
+		position, positionErr := connection.nextCategoryPosition(payload.RestaurantId)
+		if positionErr != nil {
+			return nil, positionErr
+		}
+		payload.Position = position
+	}
+
+	category := &dto.MenuCategory{}
+	sqlQuery, args, buildErr := connection.sqlBuilder.Insert("menu_categories", payload)
+	if buildErr != nil {
+		return nil, SqlBuilderError(buildErr)
+	}
+	row := connection.db.QueryRowx(sqlQuery, args...)
+	if row.Err() != nil {
+		if uniquenessViolation, constraintName := database.HasUniquenessViolation(row.Err()); uniquenessViolation {
+			return nil, rest_errors.NewValidationError(UniquenessErrors(constraintName))
+		}
+		return nil, rest_errors.NewInternalServerError(row.Err())
+	}
+
+	row.StructScan(category)
+	return category, nil
+}
+
+/* nextCategoryPosition is the position following the last category of the restaurant */
+func (connection *connection) nextCategoryPosition(restaurantId int64) (int, rest_errors.RestErr) {
+	var categories dto.MenuCategories
+	sqlQuery, args, buildErr := connection.sqlBuilder.SearchBy("menu_categories", map[string]interface{}{"restaurant_id": restaurantId})
+	if buildErr != nil {
+		return 0, SqlBuilderError(buildErr)
+	}
+	if err := connection.db.Select(&categories, sqlQuery, args...); err != nil {
+		return 0, rest_errors.NewInternalServerError(err)
+	}
+
+	last := 0
+	for _, category := range categories {
+		if category.Position > last {
+			last = category.Position
+		}
+	}
+	return last + 1, nil
+}
+
+func (connection *connection) GetMenuCategory(restaurantId *int64, id *int64) (*dto.MenuCategory, rest_errors.RestErr) {
+	category := &dto.MenuCategory{}
+	query, args, buildErr := connection.sqlBuilder.Find("menu_categories", map[string]interface{}{"id": id, "restaurant_id": restaurantId})
+	if buildErr != nil {
+		return nil, SqlBuilderError(buildErr)
+	}
+	if err := connection.db.Get(category, query, args...); err != nil {
+		message := fmt.Sprintf("Sorry, the menu category with id %v doesn't exist", *id)
+		return nil, rest_errors.NewNotFoundError(message)
+	}
+
+	return category, nil
+}
+
+func (connection *connection) SearchMenuCategories(params url.Values, scopes ...database.Scope) (dto.MenuCategories, uint64, rest_errors.RestErr) {
+	var categories dto.MenuCategories
+	sqlQuery, args, buildErr := connection.sqlBuilder.Filter("menu_categories", params, scopes...)
+	if buildErr != nil {
+		return nil, 0, SqlBuilderError(buildErr)
+	}
+	if err := connection.db.Select(&categories, sqlQuery, args...); err != nil {
+		return nil, 0, rest_errors.NewInternalServerError(err)
+	}
+
+	total, countErr := connection.count("menu_categories", params, scopes...)
+	if countErr != nil {
+		return nil, 0, countErr
+	}
+
+	return categories, total, nil
+}
+
+func (connection *connection) UpdateMenuCategory(category *dto.MenuCategory, payload interface{}) (*dto.MenuCategory, rest_errors.RestErr) {
+	// Convert payload to MenuCategory struct: this is to ensure that attribute names are mapped with db column names
+	payloadCategory := &dto.MenuCategory{}
+	mapstructure.Decode(payload, payloadCategory)
+
+	sqlQuery, args, buildErr := connection.sqlBuilder.Update("menu_categories", &category.Id, payloadCategory)
+	if buildErr != nil {
+		return nil, SqlBuilderError(buildErr)
+	}
+	row := connection.db.QueryRowx(sqlQuery, args...)
+	if row.Err() != nil {
+		if uniquenessViolation, constraintName := database.HasUniquenessViolation(row.Err()); uniquenessViolation {
+			return nil, rest_errors.NewValidationError(UniquenessErrors(constraintName))
+		}
+		return nil, rest_errors.NewInternalServerError(row.Err())
+	}
+	row.StructScan(category)
+	return category, nil
+}
+
+/* The dishes of a deleted category stay on the menu, without category */
+func (connection *connection) DeleteMenuCategory(category *dto.MenuCategory) rest_errors.RestErr {
+	sqlQuery, args, buildErr := connection.sqlBuilder.Delete("menu_categories", &category.Id)
+	if buildErr != nil {
+		return SqlBuilderError(buildErr)
+	}
+	if _, err := connection.db.Exec(sqlQuery, args...); err != nil {
+		return rest_errors.NewInternalServerError(err)
+	}
+	return nil
+}
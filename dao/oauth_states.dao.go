@@ -0,0 +1,59 @@
+package dao
+
+import (
+	"fmt"
+	"time"
+
+	"resturants-hub.com/m/v2/database"
+	"resturants-hub.com/m/v2/dto"
+	rest_errors "resturants-hub.com/m/v2/packages/utils"
+)
+
+type OAuthStatesDao interface {
+	CreateOAuthState(*dto.OAuthState) rest_errors.RestErr
+	ConsumeOAuthState(state string) (*dto.OAuthState, rest_errors.RestErr)
+	PurgeExpiredOAuthStates(before time.Time) rest_errors.RestErr
+}
+
+func NewOAuthStatesDao() OAuthStatesDao {
+	return &connection{
+		db:         database.DB,
+		sqlBuilder: database.NewSqlBuilder(),
+	}
+}
+
+func (connection *connection) CreateOAuthState(payload *dto.OAuthState) rest_errors.RestErr {
+	sqlQuery, args, buildErr := connection.sqlBuilder.Insert("oauth_states", payload)
+	if buildErr != nil {
+		return SqlBuilderError(buildErr)
+	}
+	if _, err := connection.db.Exec(sqlQuery, args...); err != nil {
+		return rest_errors.NewInternalServerError(err)
+	}
+	return nil
+}
+
+/* ConsumeOAuthState deletes the state and returns it, so that a state can only be used once even across app instances */
+func (connection *connection) ConsumeOAuthState(state string) (*dto.OAuthState, rest_errors.RestErr) {
+	consumed := &dto.OAuthState{}
+	sqlQuery, args, buildErr := connection.sqlBuilder.DeleteWhere("oauth_states", map[string]interface{}{"state": state})
+	if buildErr != nil {
+		return nil, SqlBuilderError(buildErr)
+	}
+	if err := connection.db.Get(consumed, sqlQuery, args...); err != nil {
+		return nil, rest_errors.NewNotFoundError(fmt.Sprintf("Sorry, the OAuth state %s doesn't exist", state))
+	}
+	return consumed, nil
+}
+
+/* PurgeExpiredOAuthStates deletes the states of the logins abandoned before their callback */
+func (connection *connection) PurgeExpiredOAuthStates(before time.Time) rest_errors.RestErr {
+	sqlQuery, args, buildErr := connection.sqlBuilder.DeleteWhere("oauth_states", map[string]interface{}{"expires_at__lt": before})
+	if buildErr != nil {
+		return SqlBuilderError(buildErr)
+	}
+	if _, err := connection.db.Exec(sqlQuery, args...); err != nil {
+		return rest_errors.NewInternalServerError(err)
+	}
+	return nil
+}
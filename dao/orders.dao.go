@@ -0,0 +1,181 @@
+package dao
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+
+	"github.com/jmoiron/sqlx"
+	"resturants-hub.com/m/v2/database"
+	"resturants-hub.com/m/v2/dto"
+	rest_errors "resturants-hub.com/m/v2/packages/utils"
+)
+
+type OrdersDao interface {
+	PriceCart(restaurantId int64, items []dto.CreateOrderItemPayload) (*dto.Cart, rest_errors.RestErr)
+	CreateOrder(payload *dto.CreateOrderPayload) (*dto.Order, rest_errors.RestErr)
+	GetOrder(restaurantId *int64, id *int64) (*dto.Order, rest_errors.RestErr)
+	SearchOrders(url.Values, ...database.Scope) (dto.Orders, uint64, rest_errors.RestErr)
+	TransitionOrder(order *dto.Order, status string) (*dto.Order, rest_errors.RestErr)
+}
+
+func NewOrdersDao() OrdersDao {
+	return &connection{
+		db:         database.DB,
+		sqlBuilder: database.NewSqlBuilder(),
+	}
+}
+
+/* orderedDishes reads the dishes of the menu of the restaurant an order refers to, within the transaction when there is one */
+func (connection *connection) orderedDishes(queryer sqlx.Queryer, restaurantId int64, items []dto.CreateOrderItemPayload) (dto.Dishes, rest_errors.RestErr) {
+	ids := make([]int64, len(items))
+	for index, item := range items {
+		ids[index] = item.DishId
+	}
+
+	var dishes dto.Dishes
+	params := map[string]interface{}{"restaurant_id": restaurantId, "id__in": ids, "deleted_at": nil}
+	sqlQuery, args, buildErr := connection.sqlBuilder.SearchBy("dishes", params)
+	if buildErr != nil {
+		return nil, SqlBuilderError(buildErr)
+	}
+	if err := sqlx.Select(queryer, &dishes, sqlQuery, args...); err != nil {
+		return nil, rest_errors.NewInternalServerError(err)
+	}
+	return dishes, nil
+}
+
+/* PriceCart prices the items from the current menu, without placing the order */
+func (connection *connection) PriceCart(restaurantId int64, items []dto.CreateOrderItemPayload) (*dto.Cart, rest_errors.RestErr) {
+	dishes, dishesErr := connection.orderedDishes(connection.db, restaurantId, items)
+	if dishesErr != nil {
+		return nil, dishesErr
+	}
+	priced, total, causes := dto.PriceItems(dishes, items)
+	if causes != nil {
+		return nil, rest_errors.NewValidationError(causes)
+	}
+	return &dto.Cart{Items: priced, Total: total}, nil
+}
+
+/*
+CreateOrder places the order and its items in a single transaction: the dishes are read, priced and copied
+into the items along with the order, so that an order is either stored whole or not at all.
+*/
+func (connection *connection) CreateOrder(payload *dto.CreateOrderPayload) (*dto.Order, rest_errors.RestErr) {
+	tx, err := connection.db.Beginx()
+	if err != nil {
+		return nil, rest_errors.NewInternalServerError(err)
+	}
+	/* Rolling back a committed transaction is a no-op */
+	defer tx.Rollback()
+
+	dishes, dishesErr := connection.orderedDishes(tx, payload.RestaurantId, payload.Items)
+	if dishesErr != nil {
+		return nil, dishesErr
+	}
+	items, total, causes := dto.PriceItems(dishes, payload.Items)
+	if causes != nil {
+		return nil, rest_errors.NewValidationError(causes)
+	}
+	payload.Total = total
+	payload.Status = dto.OrderPlaced
+
+	order := &dto.Order{}
+	sqlQuery, args, buildErr := connection.sqlBuilder.Insert("orders", payload)
+	if buildErr != nil {
+		return nil, SqlBuilderError(buildErr)
+	}
+	if err := tx.QueryRowx(sqlQuery, args...).StructScan(order); err != nil {
+		return nil, rest_errors.NewInternalServerError(err)
+	}
+
+	for index := range items {
+		items[index].OrderId = order.Id
+	}
+	sqlQuery, args, buildErr = connection.sqlBuilder.Insert("order_items", items)
+	if buildErr != nil {
+		return nil, SqlBuilderError(buildErr)
+	}
+	if err := sqlx.Select(tx, &order.Items, sqlQuery, args...); err != nil {
+		return nil, rest_errors.NewInternalServerError(err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, rest_errors.NewInternalServerError(err)
+	}
+	return order, nil
+}
+
+func (connection *connection) GetOrder(restaurantId *int64, id *int64) (*dto.Order, rest_errors.RestErr) {
+	order := &dto.Order{}
+	query, args, buildErr := connection.sqlBuilder.Find("orders", map[string]interface{}{"id": id, "restaurant_id": restaurantId})
+	if buildErr != nil {
+		return nil, SqlBuilderError(buildErr)
+	}
+	if err := connection.db.Get(order, query, args...); err != nil {
+		message := fmt.Sprintf("Sorry, the order with id %v doesn't exist", *id)
+		return nil, rest_errors.NewNotFoundError(message)
+	}
+
+	items, itemsErr := connection.orderItems(order.Id)
+	if itemsErr != nil {
+		return nil, itemsErr
+	}
+	order.Items = items
+	return order, nil
+}
+
+/* orderItems lists the items of the order in the order they were added */
+func (connection *connection) orderItems(orderId int64) (dto.OrderItems, rest_errors.RestErr) {
+	items := dto.OrderItems{}
+	query, args, buildErr := connection.sqlBuilder.SearchBy("order_items", map[string]interface{}{"order_id": orderId})
+	if buildErr != nil {
+		return nil, SqlBuilderError(buildErr)
+	}
+	if err := connection.db.Select(&items, query, args...); err != nil {
+		return nil, rest_errors.NewInternalServerError(err)
+	}
+	sort.Slice(items, func(i, j int) bool { return items[i].Id < items[j].Id })
+	return items, nil
+}
+
+func (connection *connection) SearchOrders(params url.Values, scopes ...database.Scope) (dto.Orders, uint64, rest_errors.RestErr) {
+	var orders dto.Orders
+	sqlQuery, args, buildErr := connection.sqlBuilder.Filter("orders", params, scopes...)
+	if buildErr != nil {
+		return nil, 0, SqlBuilderError(buildErr)
+	}
+	if err := connection.db.Select(&orders, sqlQuery, args...); err != nil {
+		return nil, 0, rest_errors.NewInternalServerError(err)
+	}
+
+	total, countErr := connection.count("orders", params, scopes...)
+	if countErr != nil {
+		return nil, 0, countErr
+	}
+
+	return orders, total, nil
+}
+
+/* TransitionOrder moves the order to another status, as long as it is still in the status it was read in */
+func (connection *connection) TransitionOrder(order *dto.Order, status string) (*dto.Order, rest_errors.RestErr) {
+	items := order.Items
+	data := map[string]interface{}{"status": status}
+	params := map[string]interface{}{"id": order.Id, "status": order.Status}
+	sqlQuery, args, buildErr := connection.sqlBuilder.UpdateWhere("orders", data, params)
+	if buildErr != nil {
+		return nil, SqlBuilderError(buildErr)
+	}
+	if err := connection.db.QueryRowx(sqlQuery, args...).StructScan(order); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, rest_errors.NewRestError("The order has changed status in the meantime", http.StatusConflict, "conflict", nil)
+		}
+		return nil, rest_errors.NewInternalServerError(err)
+	}
+	order.Items = items
+	return order, nil
+}
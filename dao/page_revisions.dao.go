@@ -0,0 +1,69 @@
+package dao
+
+import (
+	"fmt"
+	"net/url"
+
+	"resturants-hub.com/m/v2/database"
+	"resturants-hub.com/m/v2/dto"
+	rest_errors "resturants-hub.com/m/v2/packages/utils"
+)
+
+/* Revisions are written by a trigger on every change of the content of a page, they are never updated */
+type PageRevisionsDao interface {
+	Revisions(pageId int64, params url.Values) (dto.PageRevisions, uint64, rest_errors.RestErr)
+	GetRevision(pageId int64, id int64) (*dto.PageRevision, rest_errors.RestErr)
+	LatestRevision(pageId int64) (*dto.PageRevision, rest_errors.RestErr)
+}
+
+func NewPageRevisionsDao() PageRevisionsDao {
+	return &connection{
+		db:         database.DB,
+		sqlBuilder: database.NewSqlBuilder(),
+	}
+}
+
+func (connection *connection) Revisions(pageId int64, params url.Values) (dto.PageRevisions, uint64, rest_errors.RestErr) {
+	var revisions dto.PageRevisions
+	scope := database.Scope{"page_id": pageId}
+	sqlQuery, args, buildErr := connection.sqlBuilder.Filter("page_revisions", params, scope)
+	if buildErr != nil {
+		return nil, 0, SqlBuilderError(buildErr)
+	}
+	if err := connection.db.Select(&revisions, sqlQuery, args...); err != nil {
+		return nil, 0, rest_errors.NewInternalServerError(err)
+	}
+
+	total, countErr := connection.count("page_revisions", params, scope)
+	if countErr != nil {
+		return nil, 0, countErr
+	}
+
+	return revisions, total, nil
+}
+
+func (connection *connection) GetRevision(pageId int64, id int64) (*dto.PageRevision, rest_errors.RestErr) {
+	revision := &dto.PageRevision{}
+	query, args, buildErr := connection.sqlBuilder.Find("page_revisions", map[string]interface{}{"id": id, "page_id": pageId})
+	if buildErr != nil {
+		return nil, SqlBuilderError(buildErr)
+	}
+	if err := connection.db.Get(revision, query, args...); err != nil {
+		message := fmt.Sprintf("Sorry, the revision with id %v doesn't exist", id)
+		return nil, rest_errors.NewNotFoundError(message)
+	}
+
+	return revision, nil
+}
+
+/* LatestRevision holds the current content of the page */
+func (connection *connection) LatestRevision(pageId int64) (*dto.PageRevision, rest_errors.RestErr) {
+	revisions, _, err := connection.Revisions(pageId, url.Values{"sort": {"-number"}, "size": {"1"}})
+	if err != nil {
+		return nil, err
+	}
+	if len(revisions) == 0 {
+		return nil, rest_errors.NewNotFoundError("Sorry, the page has no revision")
+	}
+	return &revisions[0], nil
+}
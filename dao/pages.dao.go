@@ -1,24 +1,50 @@
 package dao
 
 import (
+	"database/sql"
+	"errors"
 	"fmt"
+	"net/http"
 	"net/url"
+	"strings"
+	"time"
 
 	"github.com/gosimple/slug"
+	"github.com/jmoiron/sqlx"
 	"github.com/mitchellh/mapstructure"
 	"resturants-hub.com/m/v2/database"
 	"resturants-hub.com/m/v2/dto"
 	consts "resturants-hub.com/m/v2/packages/const"
+	"resturants-hub.com/m/v2/packages/types"
 	rest_errors "resturants-hub.com/m/v2/packages/utils"
 )
 
+const (
+	/* Unique constraint of the slugs of the pages of a restaurant */
+	pageSlugConstraint = "pages_restaurant_slug_key"
+	/* Attempts to create a page before giving up on concurrent creations taking the generated slugs */
+	slugAttempts      = 5
+	maxBaseSlugLength = 45
+)
+
 type PagesDao interface {
 	Create(*dto.CreatePagePayload) (*dto.Page, rest_errors.RestErr)
-	Search(url.Values) (dto.Pages, rest_errors.RestErr)
-	AuthorizedCollection(url.Values, *dto.BaseUser) (dto.Pages, rest_errors.RestErr)
-	Get(slug *string) (*dto.Page, rest_errors.RestErr)
+	Search(url.Values, ...database.Scope) (dto.Pages, uint64, rest_errors.RestErr)
+	AuthorizedCollection(url.Values, *dto.BaseUser) (dto.Pages, uint64, rest_errors.RestErr)
+	Get(restaurantId *int64, slug *string) (*dto.Page, rest_errors.RestErr)
 	Update(*dto.Page, interface{}) (*dto.Page, rest_errors.RestErr)
-	GenerateSlug(string) string
+	GenerateSlug(restaurantId int64, title string) (string, rest_errors.RestErr)
+	FormerSlug(restaurantId *int64, slug *string) (*dto.Page, rest_errors.RestErr)
+	PublishedCollection(restaurantId *int64, params url.Values) (dto.Pages, uint64, rest_errors.RestErr)
+	GetPublished(restaurantId *int64, slug *string) (*dto.Page, rest_errors.RestErr)
+	Delete(*dto.Page) rest_errors.RestErr
+	GetDeleted(restaurantId *int64, slug *string) (*dto.Page, rest_errors.RestErr)
+	Restore(*dto.Page) (*dto.Page, rest_errors.RestErr)
+	RestaurantPages(restaurantId int64) (dto.Pages, rest_errors.RestErr)
+	ValidateParent(pageId int64, restaurantId int64, parentPageId int64) rest_errors.RestErr
+	Move(page *dto.Page, parentPageId types.NullInt, position int) (*dto.Page, rest_errors.RestErr)
+	Transition(page *dto.Page, visibility string, publishAt types.NullTime, unpublishAt types.NullTime) (*dto.Page, rest_errors.RestErr)
+	PublishScheduled(now time.Time) (published dto.Pages, archived dto.Pages, err rest_errors.RestErr)
 }
 
 func NewPageDao() PagesDao {
@@ -29,68 +55,166 @@ func NewPageDao() PagesDao {
 }
 
 func (connection *connection) Create(payload *dto.CreatePagePayload) (*dto.Page, rest_errors.RestErr) {
-	restaurant := &dto.Page{}
-	sqlQuery := connection.sqlBuilder.Insert("pages", payload)
-	row := connection.db.QueryRowx(sqlQuery)
-	if row.Err() != nil {
+	/* New pages come last among their siblings unless told otherwise */
+	if payload.Position == 0 {
+		position, positionErr := connection.nextPosition(payload.RestaurantId.Int64, payload.ParentPageId, 0)
+		if positionErr != nil {
+			return nil, positionErr
+		}
+		payload.Position = position
+	}
+
+	page := &dto.Page{}
+	for attempt := 1; ; attempt++ {
+		sqlQuery, args, buildErr := connection.sqlBuilder.Insert("pages", payload)
+		if buildErr != nil {
+			return nil, SqlBuilderError(buildErr)
+		}
+		row := connection.db.QueryRowx(sqlQuery, args...)
+		if row.Err() == nil {
+			row.StructScan(page)
+			return page, nil
+		}
+
 		fmt.Println(row.Err())
-		if uniquenessViolation, constraintName := database.HasUniquenessViolation(row.Err()); uniquenessViolation {
+		uniquenessViolation, constraintName := database.HasUniquenessViolation(row.Err())
+		if !uniquenessViolation {
+			return nil, rest_errors.NewInternalServerError(row.Err())
+		}
+		/* A page created concurrently took the generated slug: generate the next one */
+		if constraintName != pageSlugConstraint || attempt == slugAttempts {
 			return nil, rest_errors.NewValidationError(UniquenessErrors(constraintName))
 		}
-		return nil, rest_errors.NewInternalServerError(row.Err())
+		nextSlug, slugErr := connection.GenerateSlug(payload.RestaurantId.Int64, payload.Title)
+		if slugErr != nil {
+			return nil, slugErr
+		}
+		payload.Slug = nextSlug
 	}
-
-	row.StructScan(restaurant)
-	return restaurant, nil
 }
 
-func (connection *connection) Get(slug *string) (*dto.Page, rest_errors.RestErr) {
-	restaurant := &dto.Page{}
-	query := connection.sqlBuilder.Find("pages", map[string]interface{}{"slug": slug})
-	err := connection.db.Get(restaurant, query)
+func (connection *connection) Get(restaurantId *int64, slug *string) (*dto.Page, rest_errors.RestErr) {
+	return connection.pageBySlug(restaurantId, *slug, map[string]interface{}{"deleted_at": nil})
+}
 
+/*
+pageBySlug finds the page with the slug among the pages of a restaurant. Without restaurantId, the slug is looked up
+across restaurants, which only succeeds as long as a single restaurant has a page with that slug.
+*/
+func (connection *connection) pageBySlug(restaurantId *int64, slug string, params map[string]interface{}) (*dto.Page, rest_errors.RestErr) {
+	params["slug"] = slug
+	if restaurantId != nil {
+		params["restaurant_id"] = *restaurantId
+	}
+	pages, err := connection.pagesWhere(params)
 	if err != nil {
-		message := fmt.Sprintf("Sorry, the record with slug %v doesn't exist", *slug)
-		return nil, rest_errors.NewNotFoundError(message)
+		return nil, err
 	}
 
-	return restaurant, nil
+	switch len(pages) {
+	case 0:
+		message := fmt.Sprintf("Sorry, the record with slug %v doesn't exist", slug)
+		return nil, rest_errors.NewNotFoundError(message)
+	case 1:
+		return &pages[0], nil
+	default:
+		message := fmt.Sprintf("Several restaurants have a page with slug %v, use /restaurants/:id/pages/%v", slug, slug)
+		return nil, rest_errors.NewRestError(message, http.StatusConflict, "conflict", nil)
+	}
 }
 
-func (connection *connection) Search(params url.Values) (dto.Pages, rest_errors.RestErr) {
+func (connection *connection) Search(params url.Values, scopes ...database.Scope) (dto.Pages, uint64, rest_errors.RestErr) {
 	var pages dto.Pages
-	sqlQuery := connection.sqlBuilder.Filter("pages", params)
-	err := connection.db.Select(&pages, sqlQuery)
+	sqlQuery, args, buildErr := connection.sqlBuilder.Filter("pages", params, scopes...)
+	if buildErr != nil {
+		return nil, 0, SqlBuilderError(buildErr)
+	}
+	err := connection.db.Select(&pages, sqlQuery, args...)
 	if err != nil {
-		return nil, rest_errors.NewNotFoundError(err.Error())
+		return nil, 0, rest_errors.NewNotFoundError(err.Error())
 	}
 
-	return pages, nil
+	total, countErr := connection.count("pages", params, scopes...)
+	if countErr != nil {
+		return nil, 0, countErr
+	}
+
+	return pages, total, nil
 }
 
-func (connection *connection) GenerateSlug(title string) string {
-	pageSlug := slug.Make(title)
-	slugExists := true
-	for slugExists == true {
-		_, err := connection.Get(&pageSlug)
-		/* If err == nil (the record with the generated pageSlug exist) */
-		slugExists = err == nil
-		if slugExists {
-			pageSlug = pageSlug + "-1"
-		}
+/* findPage looks a page up without any default scoping */
+func (connection *connection) findPage(params map[string]interface{}) (*dto.Page, rest_errors.RestErr) {
+	page := &dto.Page{}
+	query, args, buildErr := connection.sqlBuilder.Find("pages", params)
+	if buildErr != nil {
+		return nil, SqlBuilderError(buildErr)
+	}
+	if err := connection.db.Get(page, query, args...); err != nil {
+		return nil, rest_errors.NewNotFoundError("Sorry, the page doesn't exist")
+	}
+	return page, nil
+}
+
+/*
+GenerateSlug returns the slug of title if no page of the restaurant has it yet, otherwise the slug followed by
+the next free number (about-2, about-3...). The slugs in use are read in a single query; slugs of deleted pages
+remain taken until they are purged.
+*/
+func (connection *connection) GenerateSlug(restaurantId int64, title string) (string, rest_errors.RestErr) {
+	/* Leave room for the number within the 50 characters of a slug */
+	baseSlug := slug.Make(title)
+	if len(baseSlug) > maxBaseSlugLength {
+		baseSlug = strings.TrimRight(baseSlug[:maxBaseSlugLength], "-")
+	}
+
+	pages, err := connection.pagesWhere(map[string]interface{}{"restaurant_id": restaurantId, "slug__prefix": baseSlug})
+	if err != nil {
+		return "", err
+	}
+
+	taken := make([]string, len(pages))
+	for index, page := range pages {
+		taken[index] = page.Slug
+	}
+	return freeSlug(baseSlug, taken), nil
+}
+
+/* FormerSlug finds the page which used to have the slug, so that links to its former slug can be redirected */
+func (connection *connection) FormerSlug(restaurantId *int64, slug *string) (*dto.Page, rest_errors.RestErr) {
+	var formerSlugs []dto.PageSlug
+	params := map[string]interface{}{"slug": *slug}
+	if restaurantId != nil {
+		params["restaurant_id"] = *restaurantId
 	}
-	return pageSlug
+	sqlQuery, args, buildErr := connection.sqlBuilder.SearchBy("page_slugs", params)
+	if buildErr != nil {
+		return nil, SqlBuilderError(buildErr)
+	}
+	if err := connection.db.Select(&formerSlugs, sqlQuery, args...); err != nil {
+		return nil, rest_errors.NewInternalServerError(err)
+	}
+	if len(formerSlugs) != 1 {
+		message := fmt.Sprintf("Sorry, the record with slug %v doesn't exist", *slug)
+		return nil, rest_errors.NewNotFoundError(message)
+	}
+
+	return connection.findPage(map[string]interface{}{"id": formerSlugs[0].PageId, "deleted_at": nil})
 }
 
-func (connection *connection) AuthorizedCollection(params url.Values, user *dto.BaseUser) (dto.Pages, rest_errors.RestErr) {
+func (connection *connection) AuthorizedCollection(params url.Values, user *dto.BaseUser) (dto.Pages, uint64, rest_errors.RestErr) {
+	scope, scopeErr := deletedScope(params, user)
+	if scopeErr != nil {
+		return nil, 0, scopeErr
+	}
+
 	switch user.Role {
 	case consts.Admin:
-		return connection.Search(params)
+		return connection.Search(params, scope)
 	case consts.Manager:
 		params.Add("author_id", fmt.Sprint(user.Id))
-		return connection.Search(params)
+		return connection.Search(params, scope)
 	default:
-		return dto.Pages{}, nil
+		return dto.Pages{}, 0, nil
 	}
 }
 
@@ -99,8 +223,11 @@ func (connection *connection) Update(page *dto.Page, payload interface{}) (*dto.
 	payloadPage := &dto.Page{}
 	mapstructure.Decode(payload, payloadPage)
 
-	sqlQuery := connection.sqlBuilder.Update("pages", &page.Id, payloadPage)
-	row := connection.db.QueryRowx(sqlQuery)
+	sqlQuery, args, buildErr := connection.sqlBuilder.Update("pages", &page.Id, payloadPage)
+	if buildErr != nil {
+		return nil, SqlBuilderError(buildErr)
+	}
+	row := connection.db.QueryRowx(sqlQuery, args...)
 	if row.Err() != nil {
 		if uniquenessViolation, constraintName := database.HasUniquenessViolation(row.Err()); uniquenessViolation {
 			return nil, rest_errors.NewValidationError(UniquenessErrors(constraintName))
@@ -110,3 +237,283 @@ func (connection *connection) Update(page *dto.Page, payload interface{}) (*dto.
 	row.StructScan(page)
 	return page, nil
 }
+
+/* Published pages of a restaurant, as shown to anonymous visitors */
+func (connection *connection) PublishedCollection(restaurantId *int64, params url.Values) (dto.Pages, uint64, rest_errors.RestErr) {
+	var pages dto.Pages
+	scope := database.Scope{"restaurant_id": restaurantId, "visibility": dto.PagePublished, "deleted_at": nil}
+	sqlQuery, args, buildErr := connection.sqlBuilder.Filter("pages", params, scope)
+	if buildErr != nil {
+		return nil, 0, SqlBuilderError(buildErr)
+	}
+	err := connection.db.Select(&pages, sqlQuery, args...)
+	if err != nil {
+		return nil, 0, rest_errors.NewNotFoundError(err.Error())
+	}
+
+	total, countErr := connection.count("pages", params, scope)
+	if countErr != nil {
+		return nil, 0, countErr
+	}
+
+	return pages, total, nil
+}
+
+func (connection *connection) GetPublished(restaurantId *int64, slug *string) (*dto.Page, rest_errors.RestErr) {
+	page := &dto.Page{}
+	params := map[string]interface{}{"restaurant_id": restaurantId, "slug": slug, "visibility": dto.PagePublished, "deleted_at": nil}
+	query, args, buildErr := connection.sqlBuilder.Find("pages", params)
+	if buildErr != nil {
+		return nil, SqlBuilderError(buildErr)
+	}
+	err := connection.db.Get(page, query, args...)
+
+	if err != nil {
+		message := fmt.Sprintf("Sorry, the record with slug %v doesn't exist", *slug)
+		return nil, rest_errors.NewNotFoundError(message)
+	}
+
+	return page, nil
+}
+
+/* Delete soft deletes the page: it is hidden until restored, or purged after the retention period */
+func (connection *connection) Delete(page *dto.Page) rest_errors.RestErr {
+	sqlQuery, args, buildErr := connection.sqlBuilder.SoftDelete("pages", &page.Id)
+	if buildErr != nil {
+		return SqlBuilderError(buildErr)
+	}
+	if err := connection.db.QueryRowx(sqlQuery, args...).StructScan(page); err != nil {
+		return rest_errors.NewInternalServerError(err)
+	}
+	return nil
+}
+
+func (connection *connection) GetDeleted(restaurantId *int64, slug *string) (*dto.Page, rest_errors.RestErr) {
+	page, err := connection.pageBySlug(restaurantId, *slug, map[string]interface{}{"deleted_at__isnull": false})
+	if err != nil && err.Status() == http.StatusNotFound {
+		message := fmt.Sprintf("Sorry, there is no deleted page with slug %v", *slug)
+		return nil, rest_errors.NewNotFoundError(message)
+	}
+	return page, err
+}
+
+func (connection *connection) Restore(page *dto.Page) (*dto.Page, rest_errors.RestErr) {
+	sqlQuery, args, buildErr := connection.sqlBuilder.Restore("pages", &page.Id)
+	if buildErr != nil {
+		return nil, SqlBuilderError(buildErr)
+	}
+	row := connection.db.QueryRowx(sqlQuery, args...)
+	if row.Err() != nil {
+		return nil, rest_errors.NewInternalServerError(row.Err())
+	}
+	row.StructScan(page)
+	return page, nil
+}
+
+/* All the pages of a restaurant which aren't deleted, e.g. to build its navigation tree */
+func (connection *connection) RestaurantPages(restaurantId int64) (dto.Pages, rest_errors.RestErr) {
+	return connection.pagesWhere(map[string]interface{}{"restaurant_id": restaurantId, "deleted_at": nil})
+}
+
+func (connection *connection) pagesWhere(params map[string]interface{}) (dto.Pages, rest_errors.RestErr) {
+	var pages dto.Pages
+	sqlQuery, args, buildErr := connection.sqlBuilder.SearchBy("pages", params)
+	if buildErr != nil {
+		return nil, SqlBuilderError(buildErr)
+	}
+	if err := connection.db.Select(&pages, sqlQuery, args...); err != nil {
+		return nil, rest_errors.NewInternalServerError(err)
+	}
+	return pages, nil
+}
+
+/*
+ValidateParent makes sure parentPageId can be the parent of the page (pageId is 0 for new pages):
+it must be a page of the same restaurant, and not the page itself or one of its descendants,
+otherwise the navigation tree would contain a cycle.
+*/
+func (connection *connection) ValidateParent(pageId int64, restaurantId int64, parentPageId int64) rest_errors.RestErr {
+	invalidParent := func(cause interface{}) rest_errors.RestErr {
+		return rest_errors.NewValidationError(&rest_errors.ValidationErrs{"parentPageId": []interface{}{cause}})
+	}
+
+	parent, getErr := connection.findPage(map[string]interface{}{"id": parentPageId, "deleted_at": nil})
+	if getErr != nil {
+		return invalidParent(rest_errors.FormattedDbValidationError("parentPageId", "not_found"))
+	}
+	if parent.RestaurantId.Int64 != restaurantId {
+		return invalidParent(map[string]interface{}{"error": "must_belong_to_same_restaurant"})
+	}
+
+	/* Walk up the ancestors of the new parent: the page must not be one of them */
+	visited := map[int64]bool{}
+	for ancestor := parent; ; {
+		if ancestor.Id == pageId {
+			return invalidParent(map[string]interface{}{"error": "cycle_detected"})
+		}
+		visited[ancestor.Id] = true
+		if !ancestor.ParentPageId.Valid || visited[ancestor.ParentPageId.Int64] {
+			return nil
+		}
+		next, err := connection.findPage(map[string]interface{}{"id": ancestor.ParentPageId.Int64})
+		if err != nil {
+			return nil
+		}
+		ancestor = next
+	}
+}
+
+/*
+Move re-parents the page (an invalid parentPageId moves it to the root) and sets its position among its new siblings.
+A position of 0 (or past the last sibling) puts it after its siblings. The parent must have been checked with ValidateParent.
+Siblings are renumbered in the same transaction, making room for the page and closing the gap it leaves behind.
+*/
+func (connection *connection) Move(page *dto.Page, parentPageId types.NullInt, position int) (*dto.Page, rest_errors.RestErr) {
+	tx, err := connection.db.Beginx()
+	if err != nil {
+		return nil, rest_errors.NewInternalServerError(err)
+	}
+	/* Rolling back a committed transaction is a no-op */
+	defer tx.Rollback()
+
+	/* Concurrent moves within the restaurant wait for this one, so that they renumber up to date positions */
+	sqlQuery, args, buildErr := connection.sqlBuilder.Lock("pages", map[string]interface{}{"restaurant_id": page.RestaurantId.Int64})
+	if buildErr != nil {
+		return nil, SqlBuilderError(buildErr)
+	}
+	if _, err := tx.Exec(sqlQuery, args...); err != nil {
+		return nil, rest_errors.NewInternalServerError(err)
+	}
+
+	siblings, renumberErr := connection.renumberSiblings(tx, page, parentPageId, position)
+	if renumberErr != nil {
+		return nil, renumberErr
+	}
+	if position == 0 || position > siblings {
+		position = siblings + 1
+	}
+
+	var parent interface{}
+	if parentPageId.Valid {
+		parent = parentPageId.Int64
+	}
+	formerParentPageId := page.ParentPageId
+	sqlQuery, args, buildErr = connection.sqlBuilder.Update("pages", &page.Id, map[string]interface{}{
+		"parent_page_id": parent,
+		"position":       position,
+	})
+	if buildErr != nil {
+		return nil, SqlBuilderError(buildErr)
+	}
+	if err := tx.QueryRowx(sqlQuery, args...).StructScan(page); err != nil {
+		return nil, rest_errors.NewInternalServerError(err)
+	}
+
+	sameParent := formerParentPageId.Valid == parentPageId.Valid && (!parentPageId.Valid || formerParentPageId.Int64 == parentPageId.Int64)
+	if !sameParent {
+		if _, renumberErr := connection.renumberSiblings(tx, page, formerParentPageId, 0); renumberErr != nil {
+			return nil, renumberErr
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, rest_errors.NewInternalServerError(err)
+	}
+	return page, nil
+}
+
+/*
+renumberSiblings renumbers the children of parentPageId (or root pages) other than page, leaving position free
+for it when above 0. It returns the number of siblings.
+*/
+func (connection *connection) renumberSiblings(tx *sqlx.Tx, page *dto.Page, parentPageId types.NullInt, position int) (int, rest_errors.RestErr) {
+	params := map[string]interface{}{"restaurant_id": page.RestaurantId.Int64, "parent_page_id": nil, "deleted_at": nil, "id__neq": page.Id}
+	if parentPageId.Valid {
+		params["parent_page_id"] = parentPageId.Int64
+	}
+	sqlQuery, args, buildErr := connection.sqlBuilder.Renumber("pages", params, position)
+	if buildErr != nil {
+		return 0, SqlBuilderError(buildErr)
+	}
+	result, err := tx.Exec(sqlQuery, args...)
+	if err != nil {
+		return 0, rest_errors.NewInternalServerError(err)
+	}
+	siblings, err := result.RowsAffected()
+	if err != nil {
+		return 0, rest_errors.NewInternalServerError(err)
+	}
+	return int(siblings), nil
+}
+
+/* nextPosition is the position following the last child of parentPageId (or root page), ignoring the page being moved */
+func (connection *connection) nextPosition(restaurantId int64, parentPageId types.NullInt, pageId int64) (int, rest_errors.RestErr) {
+	params := map[string]interface{}{"restaurant_id": restaurantId, "parent_page_id": nil, "deleted_at": nil}
+	if parentPageId.Valid {
+		params["parent_page_id"] = parentPageId.Int64
+	}
+	siblings, err := connection.pagesWhere(params)
+	if err != nil {
+		return 0, err
+	}
+
+	last := 0
+	for _, sibling := range siblings {
+		if sibling.Id != pageId && sibling.Position > last {
+			last = sibling.Position
+		}
+	}
+	return last + 1, nil
+}
+
+/*
+Transition moves the page to another state of the workflow, with its publication window.
+The update only applies if the page is still in the state it was read in, so that concurrent transitions can't both succeed.
+*/
+func (connection *connection) Transition(page *dto.Page, visibility string, publishAt types.NullTime, unpublishAt types.NullTime) (*dto.Page, rest_errors.RestErr) {
+	data := map[string]interface{}{"visibility": visibility, "publish_at": publishAt, "unpublish_at": unpublishAt}
+	params := map[string]interface{}{"id": page.Id, "visibility": page.Visibility}
+	sqlQuery, args, buildErr := connection.sqlBuilder.UpdateWhere("pages", data, params)
+	if buildErr != nil {
+		return nil, SqlBuilderError(buildErr)
+	}
+	if err := connection.db.QueryRowx(sqlQuery, args...).StructScan(page); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, rest_errors.NewRestError("The page has changed state in the meantime", http.StatusConflict, "conflict", nil)
+		}
+		return nil, rest_errors.NewInternalServerError(err)
+	}
+	return page, nil
+}
+
+/* PublishScheduled publishes the scheduled pages whose publish_at has come, and archives the pages whose unpublish_at has passed */
+func (connection *connection) PublishScheduled(now time.Time) (dto.Pages, dto.Pages, rest_errors.RestErr) {
+	published, err := connection.updatePagesWhere(
+		map[string]interface{}{"visibility": dto.PagePublished},
+		map[string]interface{}{"visibility": dto.PageScheduled, "publish_at__lte": now, "deleted_at": nil},
+	)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	/* Pages published above may already be over */
+	archived, err := connection.updatePagesWhere(
+		map[string]interface{}{"visibility": dto.PageArchived},
+		map[string]interface{}{"visibility": dto.PagePublished, "unpublish_at__lte": now, "deleted_at": nil},
+	)
+	if err != nil {
+		return published, nil, err
+	}
+	return published, archived, nil
+}
+
+func (connection *connection) updatePagesWhere(data map[string]interface{}, params map[string]interface{}) (dto.Pages, rest_errors.RestErr) {
+	var pages dto.Pages
+	sqlQuery, args, buildErr := connection.sqlBuilder.UpdateWhere("pages", data, params)
+	if buildErr != nil {
+		return nil, SqlBuilderError(buildErr)
+	}
+	if err := connection.db.Select(&pages, sqlQuery, args...); err != nil {
+		return nil, rest_errors.NewInternalServerError(err)
+	}
+	return pages, nil
+}
@@ -0,0 +1,33 @@
+package dao
+
+import (
+	"time"
+
+	"resturants-hub.com/m/v2/database"
+	rest_errors "resturants-hub.com/m/v2/packages/utils"
+)
+
+type PurgeDao interface {
+	PurgeDeleted(tableName string, before time.Time, dependents map[string]string, referrers map[string]string) (int64, rest_errors.RestErr)
+}
+
+func NewPurgeDao() PurgeDao {
+	return &connection{
+		db:         database.DB,
+		sqlBuilder: database.NewSqlBuilder(),
+	}
+}
+
+/* PurgeDeleted hard deletes the records of tableName soft deleted before the given time, unless referrers still reference them, and returns how many were purged */
+func (connection *connection) PurgeDeleted(tableName string, before time.Time, dependents map[string]string, referrers map[string]string) (int64, rest_errors.RestErr) {
+	sqlQuery, args, buildErr := connection.sqlBuilder.PurgeDeleted(tableName, before, dependents, referrers)
+	if buildErr != nil {
+		return 0, SqlBuilderError(buildErr)
+	}
+	result, err := connection.db.Exec(sqlQuery, args...)
+	if err != nil {
+		return 0, rest_errors.NewInternalServerError(err)
+	}
+	purged, _ := result.RowsAffected()
+	return purged, nil
+}
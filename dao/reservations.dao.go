@@ -0,0 +1,147 @@
+package dao
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/mitchellh/mapstructure"
+	"resturants-hub.com/m/v2/database"
+	"resturants-hub.com/m/v2/dto"
+	rest_errors "resturants-hub.com/m/v2/packages/utils"
+)
+
+type ReservationsDao interface {
+	CreateReservation(payload *dto.CreateReservationPayload, candidates dto.Tables) (*dto.Reservation, rest_errors.RestErr)
+	GetReservation(restaurantId *int64, id *int64) (*dto.Reservation, rest_errors.RestErr)
+	SearchReservations(url.Values, ...database.Scope) (dto.Reservations, uint64, rest_errors.RestErr)
+	BookedReservations(restaurantId int64, from time.Time, to time.Time) (dto.Reservations, rest_errors.RestErr)
+	UpdateReservation(*dto.Reservation, interface{}) (*dto.Reservation, rest_errors.RestErr)
+	TransitionReservation(reservation *dto.Reservation, status string) (*dto.Reservation, rest_errors.RestErr)
+}
+
+func NewReservationsDao() ReservationsDao {
+	return &connection{
+		db:         database.DB,
+		sqlBuilder: database.NewSqlBuilder(),
+	}
+}
+
+func slotTakenError() rest_errors.RestErr {
+	return rest_errors.NewRestError("Sorry, no table is available at that time anymore", http.StatusConflict, "conflict", nil)
+}
+
+/*
+CreateReservation books the first of the candidate tables which is still free.
+Overlapping bookings of a table are rejected by the reservations_table_overlap_excl constraint,
+so a reservation made concurrently for the same slot makes the next candidate be tried.
+*/
+func (connection *connection) CreateReservation(payload *dto.CreateReservationPayload, candidates dto.Tables) (*dto.Reservation, rest_errors.RestErr) {
+	payload.StartsAt = payload.StartsAt.UTC()
+	payload.EndsAt = payload.StartsAt.Add(payload.Duration())
+	payload.Status = dto.ReservationPending
+
+	reservation := &dto.Reservation{}
+	for _, table := range candidates {
+		payload.TableId = table.Id
+		sqlQuery, args, buildErr := connection.sqlBuilder.Insert("reservations", payload)
+		if buildErr != nil {
+			return nil, SqlBuilderError(buildErr)
+		}
+		row := connection.db.QueryRowx(sqlQuery, args...)
+		if row.Err() == nil {
+			row.StructScan(reservation)
+			return reservation, nil
+		}
+		if exclusionViolation, _ := database.HasExclusionViolation(row.Err()); !exclusionViolation {
+			return nil, rest_errors.NewInternalServerError(row.Err())
+		}
+	}
+	return nil, slotTakenError()
+}
+
+func (connection *connection) GetReservation(restaurantId *int64, id *int64) (*dto.Reservation, rest_errors.RestErr) {
+	reservation := &dto.Reservation{}
+	query, args, buildErr := connection.sqlBuilder.Find("reservations", map[string]interface{}{"id": id, "restaurant_id": restaurantId})
+	if buildErr != nil {
+		return nil, SqlBuilderError(buildErr)
+	}
+	if err := connection.db.Get(reservation, query, args...); err != nil {
+		message := fmt.Sprintf("Sorry, the reservation with id %v doesn't exist", *id)
+		return nil, rest_errors.NewNotFoundError(message)
+	}
+
+	return reservation, nil
+}
+
+func (connection *connection) SearchReservations(params url.Values, scopes ...database.Scope) (dto.Reservations, uint64, rest_errors.RestErr) {
+	var reservations dto.Reservations
+	sqlQuery, args, buildErr := connection.sqlBuilder.Filter("reservations", params, scopes...)
+	if buildErr != nil {
+		return nil, 0, SqlBuilderError(buildErr)
+	}
+	if err := connection.db.Select(&reservations, sqlQuery, args...); err != nil {
+		return nil, 0, rest_errors.NewInternalServerError(err)
+	}
+
+	total, countErr := connection.count("reservations", params, scopes...)
+	if countErr != nil {
+		return nil, 0, countErr
+	}
+
+	return reservations, total, nil
+}
+
+/* BookedReservations lists the reservations of the restaurant holding a table at some point between from and to */
+func (connection *connection) BookedReservations(restaurantId int64, from time.Time, to time.Time) (dto.Reservations, rest_errors.RestErr) {
+	var reservations dto.Reservations
+	params := map[string]interface{}{
+		"restaurant_id": restaurantId,
+		"status__in":    dto.ActiveReservationStatuses,
+		"starts_at__lt": to.UTC(),
+		"ends_at__gt":   from.UTC(),
+	}
+	sqlQuery, args, buildErr := connection.sqlBuilder.SearchBy("reservations", params)
+	if buildErr != nil {
+		return nil, SqlBuilderError(buildErr)
+	}
+	if err := connection.db.Select(&reservations, sqlQuery, args...); err != nil {
+		return nil, rest_errors.NewInternalServerError(err)
+	}
+	return reservations, nil
+}
+
+func (connection *connection) UpdateReservation(reservation *dto.Reservation, payload interface{}) (*dto.Reservation, rest_errors.RestErr) {
+	// Convert payload to Reservation struct: this is to ensure that attribute names are mapped with db column names
+	payloadReservation := &dto.Reservation{}
+	mapstructure.Decode(payload, payloadReservation)
+
+	sqlQuery, args, buildErr := connection.sqlBuilder.Update("reservations", &reservation.Id, payloadReservation)
+	if buildErr != nil {
+		return nil, SqlBuilderError(buildErr)
+	}
+	if err := connection.db.QueryRowx(sqlQuery, args...).StructScan(reservation); err != nil {
+		return nil, rest_errors.NewInternalServerError(err)
+	}
+	return reservation, nil
+}
+
+/* TransitionReservation moves the reservation to another status, as long as it is still in the status it was read in */
+func (connection *connection) TransitionReservation(reservation *dto.Reservation, status string) (*dto.Reservation, rest_errors.RestErr) {
+	data := map[string]interface{}{"status": status}
+	params := map[string]interface{}{"id": reservation.Id, "status": reservation.Status}
+	sqlQuery, args, buildErr := connection.sqlBuilder.UpdateWhere("reservations", data, params)
+	if buildErr != nil {
+		return nil, SqlBuilderError(buildErr)
+	}
+	if err := connection.db.QueryRowx(sqlQuery, args...).StructScan(reservation); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, rest_errors.NewRestError("The reservation has changed status in the meantime", http.StatusConflict, "conflict", nil)
+		}
+		return nil, rest_errors.NewInternalServerError(err)
+	}
+	return reservation, nil
+}
@@ -3,7 +3,10 @@ package dao
 import (
 	"fmt"
 	"net/url"
+	"strconv"
+	"strings"
 
+	"github.com/gosimple/slug"
 	"github.com/mitchellh/mapstructure"
 	"resturants-hub.com/m/v2/database"
 	"resturants-hub.com/m/v2/dto"
@@ -11,13 +14,28 @@ import (
 	rest_errors "resturants-hub.com/m/v2/packages/utils"
 )
 
+const (
+	/* Unique constraint of the slugs of the restaurants */
+	restaurantSlugConstraint = "restaurants_slug_key"
+	/* Numeric slugs would be taken for ids (/restaurants/1984), they are prefixed with it */
+	numericSlugPrefix = "restaurant"
+)
+
 type RestaurantDao interface {
 	CreateRestaurant(*dto.CreateRestaurantPayload) (*dto.Restaurant, rest_errors.RestErr)
-	SearchRestaurants(url.Values) (dto.Restaurants, rest_errors.RestErr)
-	AuthorizedRestaurantCollection(url.Values, *dto.BaseUser) (dto.Restaurants, rest_errors.RestErr)
+	SearchRestaurants(url.Values, ...database.Scope) (dto.Restaurants, uint64, rest_errors.RestErr)
+	AuthorizedRestaurantCollection(url.Values, *dto.BaseUser) (dto.Restaurants, uint64, rest_errors.RestErr)
 	GetRestaurant(id *int64) (*dto.Restaurant, rest_errors.RestErr)
 	RestaurantByOwnerId(*int64) (*dto.Restaurant, rest_errors.RestErr)
 	UpdateRestaurant(*dto.Restaurant, interface{}) (*dto.Restaurant, rest_errors.RestErr)
+	UpdateOpeningHours(*dto.Restaurant, *dto.OpeningHoursPayload) (*dto.Restaurant, rest_errors.RestErr)
+	RestaurantBySlug(*string) (*dto.Restaurant, rest_errors.RestErr)
+	GenerateRestaurantSlug(string) (string, rest_errors.RestErr)
+	PublicRestaurantCollection(url.Values) (dto.Restaurants, uint64, rest_errors.RestErr)
+	GetPublicRestaurant(identifier string) (*dto.Restaurant, rest_errors.RestErr)
+	DeleteRestaurant(*dto.Restaurant) rest_errors.RestErr
+	GetDeletedRestaurant(id *int64) (*dto.Restaurant, rest_errors.RestErr)
+	RestoreRestaurant(*dto.Restaurant) (*dto.Restaurant, rest_errors.RestErr)
 }
 
 func NewRestaurantDao() RestaurantDao {
@@ -29,24 +47,41 @@ func NewRestaurantDao() RestaurantDao {
 
 func (connection *connection) CreateRestaurant(payload *dto.CreateRestaurantPayload) (*dto.Restaurant, rest_errors.RestErr) {
 	restaurant := &dto.Restaurant{}
-	sqlQuery := connection.sqlBuilder.Insert("restaurants", payload)
-	row := connection.db.QueryRowx(sqlQuery)
-	if row.Err() != nil {
+	for attempt := 1; ; attempt++ {
+		sqlQuery, args, buildErr := connection.sqlBuilder.Insert("restaurants", payload)
+		if buildErr != nil {
+			return nil, SqlBuilderError(buildErr)
+		}
+		row := connection.db.QueryRowx(sqlQuery, args...)
+		if row.Err() == nil {
+			row.StructScan(restaurant)
+			return restaurant, nil
+		}
+
 		fmt.Println(row.Err())
-		if uniquenessViolation, constraintName := database.HasUniquenessViolation(row.Err()); uniquenessViolation {
+		uniquenessViolation, constraintName := database.HasUniquenessViolation(row.Err())
+		if !uniquenessViolation {
+			return nil, rest_errors.NewInternalServerError(row.Err())
+		}
+		/* A restaurant created concurrently took the generated slug: generate the next one */
+		if constraintName != restaurantSlugConstraint || attempt == slugAttempts {
 			return nil, rest_errors.NewValidationError(UniquenessErrors(constraintName))
 		}
-		return nil, rest_errors.NewInternalServerError(row.Err())
+		nextSlug, slugErr := connection.GenerateRestaurantSlug(payload.Name)
+		if slugErr != nil {
+			return nil, slugErr
+		}
+		payload.Slug = nextSlug
 	}
-
-	row.StructScan(restaurant)
-	return restaurant, nil
 }
 
 func (connection *connection) GetRestaurant(id *int64) (*dto.Restaurant, rest_errors.RestErr) {
 	restaurant := &dto.Restaurant{}
-	query := connection.sqlBuilder.Find("restaurants", map[string]interface{}{"id": id})
-	err := connection.db.Get(restaurant, query)
+	query, args, buildErr := connection.sqlBuilder.Find("restaurants", map[string]interface{}{"id": id, "deleted_at": nil})
+	if buildErr != nil {
+		return nil, SqlBuilderError(buildErr)
+	}
+	err := connection.db.Get(restaurant, query, args...)
 
 	if err != nil {
 		message := fmt.Sprintf("Sorry, the record with id %v doesn't exist", *id)
@@ -58,10 +93,13 @@ func (connection *connection) GetRestaurant(id *int64) (*dto.Restaurant, rest_er
 
 func (connection *connection) RestaurantByOwnerId(id *int64) (*dto.Restaurant, rest_errors.RestErr) {
 	restaurant := &dto.Restaurant{}
-	params := map[string]interface{}{"manager_id": id}
+	params := map[string]interface{}{"manager_id": id, "deleted_at": nil}
 
-	query := connection.sqlBuilder.SearchBy(string(consts.Restaurants), params)
-	err := connection.db.Get(restaurant, query)
+	query, args, buildErr := connection.sqlBuilder.SearchBy(string(consts.Restaurants), params)
+	if buildErr != nil {
+		return nil, SqlBuilderError(buildErr)
+	}
+	err := connection.db.Get(restaurant, query, args...)
 	if err != nil {
 		message := fmt.Sprintf("Sorry, the record with id doesn't exist")
 		return nil, rest_errors.NewNotFoundError(message)
@@ -70,26 +108,39 @@ func (connection *connection) RestaurantByOwnerId(id *int64) (*dto.Restaurant, r
 	return restaurant, nil
 }
 
-func (connection *connection) SearchRestaurants(params url.Values) (dto.Restaurants, rest_errors.RestErr) {
+func (connection *connection) SearchRestaurants(params url.Values, scopes ...database.Scope) (dto.Restaurants, uint64, rest_errors.RestErr) {
 	var restaurants dto.Restaurants
-	sqlQuery := connection.sqlBuilder.Filter("restaurants", params)
-	err := connection.db.Select(&restaurants, sqlQuery)
+	sqlQuery, args, buildErr := connection.sqlBuilder.Filter("restaurants", params, scopes...)
+	if buildErr != nil {
+		return nil, 0, SqlBuilderError(buildErr)
+	}
+	err := connection.db.Select(&restaurants, sqlQuery, args...)
 	if err != nil {
-		return nil, rest_errors.NewNotFoundError(err.Error())
+		return nil, 0, rest_errors.NewNotFoundError(err.Error())
+	}
+
+	total, countErr := connection.count("restaurants", params, scopes...)
+	if countErr != nil {
+		return nil, 0, countErr
 	}
 
-	return restaurants, nil
+	return restaurants, total, nil
 }
 
-func (connection *connection) AuthorizedRestaurantCollection(params url.Values, user *dto.BaseUser) (dto.Restaurants, rest_errors.RestErr) {
+func (connection *connection) AuthorizedRestaurantCollection(params url.Values, user *dto.BaseUser) (dto.Restaurants, uint64, rest_errors.RestErr) {
+	scope, scopeErr := deletedScope(params, user)
+	if scopeErr != nil {
+		return nil, 0, scopeErr
+	}
+
 	switch user.Role {
 	case consts.Admin:
-		return connection.SearchRestaurants(params)
+		return connection.SearchRestaurants(params, scope)
 	case consts.Manager:
 		params.Add("user_id", fmt.Sprint(user.Id))
-		return connection.SearchRestaurants(params)
+		return connection.SearchRestaurants(params, scope)
 	default:
-		return dto.Restaurants{}, nil
+		return dto.Restaurants{}, 0, nil
 	}
 }
 
@@ -98,8 +149,158 @@ func (connection *connection) UpdateRestaurant(restaurant *dto.Restaurant, paylo
 	payloadRestaurant := &dto.Restaurant{}
 	mapstructure.Decode(payload, payloadRestaurant)
 
-	sqlQuery := connection.sqlBuilder.Update("restaurants", &restaurant.Id, payloadRestaurant)
-	row := connection.db.QueryRowx(sqlQuery)
+	sqlQuery, args, buildErr := connection.sqlBuilder.Update("restaurants", &restaurant.Id, payloadRestaurant)
+	if buildErr != nil {
+		return nil, SqlBuilderError(buildErr)
+	}
+	row := connection.db.QueryRowx(sqlQuery, args...)
+	if row.Err() != nil {
+		if uniquenessViolation, constraintName := database.HasUniquenessViolation(row.Err()); uniquenessViolation {
+			return nil, rest_errors.NewValidationError(UniquenessErrors(constraintName))
+		}
+		return nil, rest_errors.NewInternalServerError(row.Err())
+	}
+	row.StructScan(restaurant)
+	return restaurant, nil
+}
+
+/* UpdateOpeningHours replaces the timezone and the whole schedule of the restaurant */
+func (connection *connection) UpdateOpeningHours(restaurant *dto.Restaurant, payload *dto.OpeningHoursPayload) (*dto.Restaurant, rest_errors.RestErr) {
+	data := map[string]interface{}{"timezone": payload.Timezone, "opening_hours": payload.Schedule}
+	sqlQuery, args, buildErr := connection.sqlBuilder.Update("restaurants", &restaurant.Id, data)
+	if buildErr != nil {
+		return nil, SqlBuilderError(buildErr)
+	}
+	row := connection.db.QueryRowx(sqlQuery, args...)
+	if row.Err() != nil {
+		return nil, rest_errors.NewInternalServerError(row.Err())
+	}
+	row.StructScan(restaurant)
+	return restaurant, nil
+}
+
+/* RestaurantBySlug also finds deleted restaurants, as their slugs remain taken */
+func (connection *connection) RestaurantBySlug(restaurantSlug *string) (*dto.Restaurant, rest_errors.RestErr) {
+	restaurant := &dto.Restaurant{}
+	query, args, buildErr := connection.sqlBuilder.Find("restaurants", map[string]interface{}{"slug": restaurantSlug})
+	if buildErr != nil {
+		return nil, SqlBuilderError(buildErr)
+	}
+	err := connection.db.Get(restaurant, query, args...)
+
+	if err != nil {
+		message := fmt.Sprintf("Sorry, the record with slug %v doesn't exist", *restaurantSlug)
+		return nil, rest_errors.NewNotFoundError(message)
+	}
+
+	return restaurant, nil
+}
+
+/*
+GenerateRestaurantSlug returns the slug of name, followed by the next free number when restaurants have it already
+(the slugs in use are read in a single query, those of deleted restaurants remain taken until they are purged).
+Slugs are never numeric, so that they can't be mistaken for ids.
+*/
+func (connection *connection) GenerateRestaurantSlug(name string) (string, rest_errors.RestErr) {
+	baseSlug := slug.Make(name)
+	if len(baseSlug) > maxBaseSlugLength {
+		baseSlug = strings.TrimRight(baseSlug[:maxBaseSlugLength], "-")
+	}
+	if strings.Trim(baseSlug, "0123456789") == "" {
+		baseSlug = strings.TrimSuffix(numericSlugPrefix+"-"+baseSlug, "-")
+	}
+
+	var restaurants dto.Restaurants
+	sqlQuery, args, buildErr := connection.sqlBuilder.SearchBy("restaurants", map[string]interface{}{"slug__prefix": baseSlug})
+	if buildErr != nil {
+		return "", SqlBuilderError(buildErr)
+	}
+	if err := connection.db.Select(&restaurants, sqlQuery, args...); err != nil {
+		return "", rest_errors.NewInternalServerError(err)
+	}
+
+	taken := make([]string, len(restaurants))
+	for index, restaurant := range restaurants {
+		taken[index] = restaurant.Slug
+	}
+	return freeSlug(baseSlug, taken), nil
+}
+
+/* Restaurants visible to anonymous visitors: everything that isn't deleted */
+func (connection *connection) PublicRestaurantCollection(params url.Values) (dto.Restaurants, uint64, rest_errors.RestErr) {
+	var restaurants dto.Restaurants
+	sqlQuery, args, buildErr := connection.sqlBuilder.Filter("restaurants", params, database.Scope{"deleted_at": nil})
+	if buildErr != nil {
+		return nil, 0, SqlBuilderError(buildErr)
+	}
+	err := connection.db.Select(&restaurants, sqlQuery, args...)
+	if err != nil {
+		return nil, 0, rest_errors.NewNotFoundError(err.Error())
+	}
+
+	total, countErr := connection.count("restaurants", params, database.Scope{"deleted_at": nil})
+	if countErr != nil {
+		return nil, 0, countErr
+	}
+
+	return restaurants, total, nil
+}
+
+/* Find a non deleted restaurant either by its numeric id or by its slug */
+func (connection *connection) GetPublicRestaurant(identifier string) (*dto.Restaurant, rest_errors.RestErr) {
+	restaurant := &dto.Restaurant{}
+	params := map[string]interface{}{"slug": identifier, "deleted_at": nil}
+	if id, err := strconv.ParseInt(identifier, 10, 64); err == nil {
+		params = map[string]interface{}{"id": id, "deleted_at": nil}
+	}
+
+	query, args, buildErr := connection.sqlBuilder.Find("restaurants", params)
+	if buildErr != nil {
+		return nil, SqlBuilderError(buildErr)
+	}
+	err := connection.db.Get(restaurant, query, args...)
+	if err != nil {
+		message := fmt.Sprintf("Sorry, the restaurant %v doesn't exist", identifier)
+		return nil, rest_errors.NewNotFoundError(message)
+	}
+
+	return restaurant, nil
+}
+
+/* DeleteRestaurant soft deletes the restaurant: it is hidden until restored, or purged after the retention period */
+func (connection *connection) DeleteRestaurant(restaurant *dto.Restaurant) rest_errors.RestErr {
+	sqlQuery, args, buildErr := connection.sqlBuilder.SoftDelete("restaurants", &restaurant.Id)
+	if buildErr != nil {
+		return SqlBuilderError(buildErr)
+	}
+	if err := connection.db.QueryRowx(sqlQuery, args...).StructScan(restaurant); err != nil {
+		return rest_errors.NewInternalServerError(err)
+	}
+	return nil
+}
+
+func (connection *connection) GetDeletedRestaurant(id *int64) (*dto.Restaurant, rest_errors.RestErr) {
+	restaurant := &dto.Restaurant{}
+	query, args, buildErr := connection.sqlBuilder.Find("restaurants", map[string]interface{}{"id": id, "deleted_at__isnull": false})
+	if buildErr != nil {
+		return nil, SqlBuilderError(buildErr)
+	}
+	err := connection.db.Get(restaurant, query, args...)
+
+	if err != nil {
+		message := fmt.Sprintf("Sorry, there is no deleted restaurant with id %v", *id)
+		return nil, rest_errors.NewNotFoundError(message)
+	}
+
+	return restaurant, nil
+}
+
+func (connection *connection) RestoreRestaurant(restaurant *dto.Restaurant) (*dto.Restaurant, rest_errors.RestErr) {
+	sqlQuery, args, buildErr := connection.sqlBuilder.Restore("restaurants", &restaurant.Id)
+	if buildErr != nil {
+		return nil, SqlBuilderError(buildErr)
+	}
+	row := connection.db.QueryRowx(sqlQuery, args...)
 	if row.Err() != nil {
 		if uniquenessViolation, constraintName := database.HasUniquenessViolation(row.Err()); uniquenessViolation {
 			return nil, rest_errors.NewValidationError(UniquenessErrors(constraintName))
@@ -0,0 +1,113 @@
+package dao
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"resturants-hub.com/m/v2/database"
+	"resturants-hub.com/m/v2/dto"
+	rest_errors "resturants-hub.com/m/v2/packages/utils"
+)
+
+type ReviewsDao interface {
+	CreateReview(payload *dto.CreateReviewPayload) (*dto.Review, rest_errors.RestErr)
+	GetReview(restaurantId *int64, id *int64) (*dto.Review, rest_errors.RestErr)
+	SearchReviews(url.Values, ...database.Scope) (dto.Reviews, uint64, rest_errors.RestErr)
+	ReplyReview(review *dto.Review, reply string) (*dto.Review, rest_errors.RestErr)
+	TransitionReview(review *dto.Review, status string) (*dto.Review, rest_errors.RestErr)
+}
+
+func NewReviewsDao() ReviewsDao {
+	return &connection{
+		db:         database.DB,
+		sqlBuilder: database.NewSqlBuilder(),
+	}
+}
+
+/* CreateReview publishes the review, the ratings of its dish and restaurant are updated by the update_review_ratings trigger */
+func (connection *connection) CreateReview(payload *dto.CreateReviewPayload) (*dto.Review, rest_errors.RestErr) {
+	review := &dto.Review{}
+	sqlQuery, args, buildErr := connection.sqlBuilder.Insert("reviews", payload)
+	if buildErr != nil {
+		return nil, SqlBuilderError(buildErr)
+	}
+	row := connection.db.QueryRowx(sqlQuery, args...)
+	if row.Err() != nil {
+		if uniquenessViolation, constraintName := database.HasUniquenessViolation(row.Err()); uniquenessViolation {
+			return nil, rest_errors.NewValidationError(UniquenessErrors(constraintName))
+		}
+		return nil, rest_errors.NewInternalServerError(row.Err())
+	}
+
+	row.StructScan(review)
+	return review, nil
+}
+
+func (connection *connection) GetReview(restaurantId *int64, id *int64) (*dto.Review, rest_errors.RestErr) {
+	review := &dto.Review{}
+	query, args, buildErr := connection.sqlBuilder.Find("reviews", map[string]interface{}{"id": id, "restaurant_id": restaurantId})
+	if buildErr != nil {
+		return nil, SqlBuilderError(buildErr)
+	}
+	if err := connection.db.Get(review, query, args...); err != nil {
+		message := fmt.Sprintf("Sorry, the review with id %v doesn't exist", *id)
+		return nil, rest_errors.NewNotFoundError(message)
+	}
+
+	return review, nil
+}
+
+func (connection *connection) SearchReviews(params url.Values, scopes ...database.Scope) (dto.Reviews, uint64, rest_errors.RestErr) {
+	var reviews dto.Reviews
+	sqlQuery, args, buildErr := connection.sqlBuilder.Filter("reviews", params, scopes...)
+	if buildErr != nil {
+		return nil, 0, SqlBuilderError(buildErr)
+	}
+	if err := connection.db.Select(&reviews, sqlQuery, args...); err != nil {
+		return nil, 0, rest_errors.NewInternalServerError(err)
+	}
+
+	total, countErr := connection.count("reviews", params, scopes...)
+	if countErr != nil {
+		return nil, 0, countErr
+	}
+
+	return reviews, total, nil
+}
+
+/* ReplyReview sets the reply of the restaurant to the review, an empty reply withdraws it */
+func (connection *connection) ReplyReview(review *dto.Review, reply string) (*dto.Review, rest_errors.RestErr) {
+	data := map[string]interface{}{"reply": reply, "replied_at": nil}
+	if reply != "" {
+		data["replied_at"] = time.Now().UTC()
+	}
+	sqlQuery, args, buildErr := connection.sqlBuilder.UpdateWhere("reviews", data, map[string]interface{}{"id": review.Id})
+	if buildErr != nil {
+		return nil, SqlBuilderError(buildErr)
+	}
+	if err := connection.db.QueryRowx(sqlQuery, args...).StructScan(review); err != nil {
+		return nil, rest_errors.NewInternalServerError(err)
+	}
+	return review, nil
+}
+
+/* TransitionReview moves the review to another status, as long as it is still in the status it was read in */
+func (connection *connection) TransitionReview(review *dto.Review, status string) (*dto.Review, rest_errors.RestErr) {
+	data := map[string]interface{}{"status": status}
+	params := map[string]interface{}{"id": review.Id, "status": review.Status}
+	sqlQuery, args, buildErr := connection.sqlBuilder.UpdateWhere("reviews", data, params)
+	if buildErr != nil {
+		return nil, SqlBuilderError(buildErr)
+	}
+	if err := connection.db.QueryRowx(sqlQuery, args...).StructScan(review); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, rest_errors.NewRestError("The review has changed status in the meantime", http.StatusConflict, "conflict", nil)
+		}
+		return nil, rest_errors.NewInternalServerError(err)
+	}
+	return review, nil
+}
@@ -0,0 +1,130 @@
+package dao
+
+import (
+	"net/url"
+
+	"resturants-hub.com/m/v2/database"
+	"resturants-hub.com/m/v2/dto"
+	consts "resturants-hub.com/m/v2/packages/const"
+	rest_errors "resturants-hub.com/m/v2/packages/utils"
+)
+
+/*
+SearchDao runs full text searches, returning the records the user is allowed to see
+along with their rank (index aligned), most relevant first, and the number of matching records
+*/
+type SearchDao interface {
+	FullTextRestaurants(text string, params url.Values, user *dto.BaseUser) (dto.Restaurants, []float64, uint64, rest_errors.RestErr)
+	FullTextPages(text string, params url.Values, user *dto.BaseUser) (dto.Pages, []float64, uint64, rest_errors.RestErr)
+	FullTextDishes(text string, params url.Values, user *dto.BaseUser) (dto.Dishes, []float64, uint64, rest_errors.RestErr)
+}
+
+func NewSearchDao() SearchDao {
+	return &connection{
+		db:         database.DB,
+		sqlBuilder: database.NewSqlBuilder(),
+	}
+}
+
+func (connection *connection) FullTextRestaurants(text string, params url.Values, user *dto.BaseUser) (dto.Restaurants, []float64, uint64, rest_errors.RestErr) {
+	/* Only admins list restaurants, managers can't reach this search */
+	scope := database.Scope{"deleted_at": nil}
+
+	var ranked []dto.RankedRestaurant
+	sqlQuery, args, buildErr := connection.sqlBuilder.FullText("restaurants", text, params, scope)
+	if buildErr != nil {
+		return nil, nil, 0, SqlBuilderError(buildErr)
+	}
+	if err := connection.db.Select(&ranked, sqlQuery, args...); err != nil {
+		return nil, nil, 0, rest_errors.NewInternalServerError(err)
+	}
+
+	restaurants := make(dto.Restaurants, len(ranked))
+	ranks := make([]float64, len(ranked))
+	for i, record := range ranked {
+		restaurants[i], ranks[i] = record.Restaurant, record.Rank
+	}
+	total, countErr := connection.fullTextCount("restaurants", text, params, scope)
+	if countErr != nil {
+		return nil, nil, 0, countErr
+	}
+	return restaurants, ranks, total, nil
+}
+
+func (connection *connection) FullTextPages(text string, params url.Values, user *dto.BaseUser) (dto.Pages, []float64, uint64, rest_errors.RestErr) {
+	scope := database.Scope{"deleted_at": nil}
+	switch user.Role {
+	case consts.Admin:
+	case consts.Manager:
+		scope["author_id"] = user.Id
+	default:
+		scope["visibility"] = dto.PagePublished
+	}
+
+	var ranked []dto.RankedPage
+	sqlQuery, args, buildErr := connection.sqlBuilder.FullText("pages", text, params, scope)
+	if buildErr != nil {
+		return nil, nil, 0, SqlBuilderError(buildErr)
+	}
+	if err := connection.db.Select(&ranked, sqlQuery, args...); err != nil {
+		return nil, nil, 0, rest_errors.NewInternalServerError(err)
+	}
+
+	pages := make(dto.Pages, len(ranked))
+	ranks := make([]float64, len(ranked))
+	for i, record := range ranked {
+		pages[i], ranks[i] = record.Page, record.Rank
+	}
+	total, countErr := connection.fullTextCount("pages", text, params, scope)
+	if countErr != nil {
+		return nil, nil, 0, countErr
+	}
+	return pages, ranks, total, nil
+}
+
+func (connection *connection) FullTextDishes(text string, params url.Values, user *dto.BaseUser) (dto.Dishes, []float64, uint64, rest_errors.RestErr) {
+	scope := database.Scope{"deleted_at": nil}
+	if user.Role == consts.Manager {
+		/* Managers only search the menu of their own restaurant */
+		if !user.RestaurantId.Valid {
+			return dto.Dishes{}, []float64{}, 0, nil
+		}
+		scope["restaurant_id"] = user.RestaurantId.Int64
+	}
+
+	var ranked []dto.RankedDish
+	sqlQuery, args, buildErr := connection.sqlBuilder.FullText("dishes", text, params, scope)
+	if buildErr != nil {
+		return nil, nil, 0, SqlBuilderError(buildErr)
+	}
+	if err := connection.db.Select(&ranked, sqlQuery, args...); err != nil {
+		return nil, nil, 0, rest_errors.NewInternalServerError(err)
+	}
+
+	dishes := make(dto.Dishes, len(ranked))
+	ranks := make([]float64, len(ranked))
+	for i, record := range ranked {
+		dishes[i], ranks[i] = record.Dish, record.Rank
+	}
+	if tagsErr := connection.withDishTags(connection.db, dishes); tagsErr != nil {
+		return nil, nil, 0, tagsErr
+	}
+	total, countErr := connection.fullTextCount("dishes", text, params, scope)
+	if countErr != nil {
+		return nil, nil, 0, countErr
+	}
+	return dishes, ranks, total, nil
+}
+
+/* fullTextCount counts the records matching text under the same filters and scopes as the search, see count */
+func (connection *connection) fullTextCount(tableName string, text string, params url.Values, scopes ...database.Scope) (uint64, rest_errors.RestErr) {
+	var total uint64
+	sqlQuery, args, buildErr := connection.sqlBuilder.FullTextCount(tableName, text, params, scopes...)
+	if buildErr != nil {
+		return 0, SqlBuilderError(buildErr)
+	}
+	if err := connection.db.Get(&total, sqlQuery, args...); err != nil {
+		return 0, rest_errors.NewInternalServerError(err)
+	}
+	return total, nil
+}
@@ -18,7 +18,8 @@ type sessionConnection struct {
 type SessionDao interface {
 	CreateSession(*dto.Session) (*dto.Session, rest_errors.RestErr)
 	FindSession(map[string]interface{}) (*dto.Session, rest_errors.RestErr)
-	ExpireToken(*dto.Session) (bool, rest_errors.RestErr)
+	SearchActiveSessions(userId int64) (dto.Sessions, rest_errors.RestErr)
+	RevokeSession(userId int64, id int64) (*dto.Session, rest_errors.RestErr)
 	UpdateSession(*dto.Session, *int64) (*dto.Session, rest_errors.RestErr)
 }
 
@@ -31,9 +32,12 @@ func NewSessionDao() SessionDao {
 
 func (connection *sessionConnection) CreateSession(payload *dto.Session) (*dto.Session, rest_errors.RestErr) {
 	session := &dto.Session{}
-	sqlQuery := connection.sqlBuilder.Insert("sessions", payload)
+	sqlQuery, args, buildErr := connection.sqlBuilder.Insert("sessions", payload)
+	if buildErr != nil {
+		return nil, SqlBuilderError(buildErr)
+	}
 
-	row := connection.db.QueryRowx(sqlQuery)
+	row := connection.db.QueryRowx(sqlQuery, args...)
 	if row.Err() != nil {
 		fmt.Println(row.Err())
 		if uniquenessViolation, constraintName := database.HasUniquenessViolation(row.Err()); uniquenessViolation {
@@ -48,9 +52,12 @@ func (connection *sessionConnection) CreateSession(payload *dto.Session) (*dto.S
 
 func (connection *sessionConnection) UpdateSession(payload *dto.Session, sessionId *int64) (*dto.Session, rest_errors.RestErr) {
 	session := &dto.Session{}
-	sqlQuery := connection.sqlBuilder.Update("sessions", sessionId, payload)
+	sqlQuery, args, buildErr := connection.sqlBuilder.Update("sessions", sessionId, payload)
+	if buildErr != nil {
+		return nil, SqlBuilderError(buildErr)
+	}
 
-	row := connection.db.QueryRowx(sqlQuery)
+	row := connection.db.QueryRowx(sqlQuery, args...)
 	if row.Err() != nil {
 		fmt.Println(row.Err())
 		if uniquenessViolation, constraintName := database.HasUniquenessViolation(row.Err()); uniquenessViolation {
@@ -65,9 +72,12 @@ func (connection *sessionConnection) UpdateSession(payload *dto.Session, session
 func (connection *sessionConnection) FindSession(params map[string]interface{}) (*dto.Session, rest_errors.RestErr) {
 	session := &dto.Session{}
 
-	query := connection.sqlBuilder.SearchBy("sessions", params)
+	query, args, buildErr := connection.sqlBuilder.SearchBy("sessions", params)
+	if buildErr != nil {
+		return nil, SqlBuilderError(buildErr)
+	}
 
-	err := connection.db.Get(session, query)
+	err := connection.db.Get(session, query, args...)
 	if err != nil {
 		fmt.Println("cookie: ", err)
 		message := fmt.Sprintf("Failed to find token record for parameter %v", params)
@@ -77,18 +87,37 @@ func (connection *sessionConnection) FindSession(params map[string]interface{})
 	return session, nil
 }
 
-func (connection *sessionConnection) ExpireToken(session *dto.Session) (bool, rest_errors.RestErr) {
-
-	session.ExpiresAt = time.Now()
-	query := connection.sqlBuilder.Update("sessions", &session.Id, session)
-
-	row := connection.db.QueryRowx(query)
-	if row.Err() != nil {
-		if uniquenessViolation, constraintName := database.HasUniquenessViolation(row.Err()); uniquenessViolation {
-			return true, rest_errors.InvalidError(ErrorMessage(constraintName))
-		}
-		return true, rest_errors.NewInternalServerError(row.Err())
+/* SearchActiveSessions lists the sessions of the user which are neither revoked nor expired */
+func (connection *sessionConnection) SearchActiveSessions(userId int64) (dto.Sessions, rest_errors.RestErr) {
+	sessions := dto.Sessions{}
+	query, args, buildErr := connection.sqlBuilder.SearchBy("sessions", map[string]interface{}{
+		"user_id":        userId,
+		"revoked_at":     nil,
+		"expires_at__gt": time.Now().UTC(),
+	})
+	if buildErr != nil {
+		return nil, SqlBuilderError(buildErr)
 	}
+	if err := connection.db.Select(&sessions, query, args...); err != nil {
+		return nil, rest_errors.NewInternalServerError(err)
+	}
+	return sessions, nil
+}
 
-	return true, nil
+/* RevokeSession signs the session of the user out for good, its token is no longer accepted */
+func (connection *sessionConnection) RevokeSession(userId int64, id int64) (*dto.Session, rest_errors.RestErr) {
+	session := &dto.Session{}
+	query, args, buildErr := connection.sqlBuilder.UpdateWhere("sessions", map[string]interface{}{"revoked_at": time.Now().UTC()}, map[string]interface{}{
+		"id":         id,
+		"user_id":    userId,
+		"revoked_at": nil,
+	})
+	if buildErr != nil {
+		return nil, SqlBuilderError(buildErr)
+	}
+	if err := connection.db.Get(session, query, args...); err != nil {
+		message := fmt.Sprintf("Sorry, the session with id %v doesn't exist", id)
+		return nil, rest_errors.NewNotFoundError(message)
+	}
+	return session, nil
 }
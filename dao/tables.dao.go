@@ -0,0 +1,127 @@
+package dao
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/mitchellh/mapstructure"
+	"resturants-hub.com/m/v2/database"
+	"resturants-hub.com/m/v2/dto"
+	rest_errors "resturants-hub.com/m/v2/packages/utils"
+)
+
+type TablesDao interface {
+	CreateTable(*dto.CreateTablePayload) (*dto.Table, rest_errors.RestErr)
+	GetTable(restaurantId *int64, id *int64) (*dto.Table, rest_errors.RestErr)
+	SearchTables(url.Values, ...database.Scope) (dto.Tables, uint64, rest_errors.RestErr)
+	BookableTables(restaurantId int64, partySize int) (dto.Tables, rest_errors.RestErr)
+	UpdateTable(*dto.Table, interface{}) (*dto.Table, rest_errors.RestErr)
+	DeleteTable(*dto.Table) rest_errors.RestErr
+}
+
+func NewTablesDao() TablesDao {
+	return &connection{
+		db:         database.DB,
+		sqlBuilder: database.NewSqlBuilder(),
+	}
+}
+
+func (connection *connection) CreateTable(payload *dto.CreateTablePayload) (*dto.Table, rest_errors.RestErr) {
+	table := &dto.Table{}
+	sqlQuery, args, buildErr := connection.sqlBuilder.Insert("tables", payload)
+	if buildErr != nil {
+		return nil, SqlBuilderError(buildErr)
+	}
+	row := connection.db.QueryRowx(sqlQuery, args...)
+	if row.Err() != nil {
+		if uniquenessViolation, constraintName := database.HasUniquenessViolation(row.Err()); uniquenessViolation {
+			return nil, rest_errors.NewValidationError(UniquenessErrors(constraintName))
+		}
+		return nil, rest_errors.NewInternalServerError(row.Err())
+	}
+
+	row.StructScan(table)
+	return table, nil
+}
+
+func (connection *connection) GetTable(restaurantId *int64, id *int64) (*dto.Table, rest_errors.RestErr) {
+	table := &dto.Table{}
+	query, args, buildErr := connection.sqlBuilder.Find("tables", map[string]interface{}{"id": id, "restaurant_id": restaurantId})
+	if buildErr != nil {
+		return nil, SqlBuilderError(buildErr)
+	}
+	if err := connection.db.Get(table, query, args...); err != nil {
+		message := fmt.Sprintf("Sorry, the table with id %v doesn't exist", *id)
+		return nil, rest_errors.NewNotFoundError(message)
+	}
+
+	return table, nil
+}
+
+func (connection *connection) SearchTables(params url.Values, scopes ...database.Scope) (dto.Tables, uint64, rest_errors.RestErr) {
+	var tables dto.Tables
+	sqlQuery, args, buildErr := connection.sqlBuilder.Filter("tables", params, scopes...)
+	if buildErr != nil {
+		return nil, 0, SqlBuilderError(buildErr)
+	}
+	if err := connection.db.Select(&tables, sqlQuery, args...); err != nil {
+		return nil, 0, rest_errors.NewInternalServerError(err)
+	}
+
+	total, countErr := connection.count("tables", params, scopes...)
+	if countErr != nil {
+		return nil, 0, countErr
+	}
+
+	return tables, total, nil
+}
+
+/* BookableTables lists the active tables of the restaurant seating at least partySize diners */
+func (connection *connection) BookableTables(restaurantId int64, partySize int) (dto.Tables, rest_errors.RestErr) {
+	var tables dto.Tables
+	params := map[string]interface{}{"restaurant_id": restaurantId, "active": true, "seats__gte": partySize}
+	sqlQuery, args, buildErr := connection.sqlBuilder.SearchBy("tables", params)
+	if buildErr != nil {
+		return nil, SqlBuilderError(buildErr)
+	}
+	if err := connection.db.Select(&tables, sqlQuery, args...); err != nil {
+		return nil, rest_errors.NewInternalServerError(err)
+	}
+	return tables, nil
+}
+
+func (connection *connection) UpdateTable(table *dto.Table, payload interface{}) (*dto.Table, rest_errors.RestErr) {
+	// Convert payload to Table struct: this is to ensure that attribute names are mapped with db column names
+	payloadTable := &dto.Table{}
+	mapstructure.Decode(payload, payloadTable)
+
+	sqlQuery, args, buildErr := connection.sqlBuilder.Update("tables", &table.Id, payloadTable)
+	if buildErr != nil {
+		return nil, SqlBuilderError(buildErr)
+	}
+	row := connection.db.QueryRowx(sqlQuery, args...)
+	if row.Err() != nil {
+		if uniquenessViolation, constraintName := database.HasUniquenessViolation(row.Err()); uniquenessViolation {
+			return nil, rest_errors.NewValidationError(UniquenessErrors(constraintName))
+		}
+		return nil, rest_errors.NewInternalServerError(row.Err())
+	}
+	row.StructScan(table)
+	return table, nil
+}
+
+/* Tables which have been booked keep their reservations, they can only be deactivated */
+func (connection *connection) DeleteTable(table *dto.Table) rest_errors.RestErr {
+	sqlQuery, args, buildErr := connection.sqlBuilder.Delete("tables", &table.Id)
+	if buildErr != nil {
+		return SqlBuilderError(buildErr)
+	}
+	if _, err := connection.db.Exec(sqlQuery, args...); err != nil {
+		if violation, _ := database.HasForeignKeyViolation(err); violation {
+			return rest_errors.NewRestError("The table has reservations, deactivate it instead", http.StatusConflict, "conflict", nil)
+		}
+		return rest_errors.NewInternalServerError(err)
+	}
+	return nil
+}
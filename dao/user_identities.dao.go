@@ -0,0 +1,105 @@
+package dao
+
+import (
+	"fmt"
+
+	"resturants-hub.com/m/v2/database"
+	"resturants-hub.com/m/v2/dto"
+	rest_errors "resturants-hub.com/m/v2/packages/utils"
+)
+
+type UserIdentitiesDao interface {
+	CreateUserIdentity(*dto.CreateUserIdentityPayload) (*dto.UserIdentity, rest_errors.RestErr)
+	FindUserIdentity(provider string, subject string) (*dto.UserIdentity, rest_errors.RestErr)
+	GetUserIdentity(userId *int64, id *int64) (*dto.UserIdentity, rest_errors.RestErr)
+	SearchUserIdentities(userId int64) (dto.UserIdentities, rest_errors.RestErr)
+	UpdateUserIdentityEmail(identity *dto.UserIdentity, email string) (*dto.UserIdentity, rest_errors.RestErr)
+	DeleteUserIdentity(*dto.UserIdentity) rest_errors.RestErr
+}
+
+func NewUserIdentitiesDao() UserIdentitiesDao {
+	return &connection{
+		db:         database.DB,
+		sqlBuilder: database.NewSqlBuilder(),
+	}
+}
+
+func (connection *connection) CreateUserIdentity(payload *dto.CreateUserIdentityPayload) (*dto.UserIdentity, rest_errors.RestErr) {
+	identity := &dto.UserIdentity{}
+	sqlQuery, args, buildErr := connection.sqlBuilder.Insert("user_identities", payload)
+	if buildErr != nil {
+		return nil, SqlBuilderError(buildErr)
+	}
+	row := connection.db.QueryRowx(sqlQuery, args...)
+	if row.Err() != nil {
+		if uniquenessViolation, constraintName := database.HasUniquenessViolation(row.Err()); uniquenessViolation {
+			return nil, rest_errors.NewValidationError(UniquenessErrors(constraintName))
+		}
+		return nil, rest_errors.NewInternalServerError(row.Err())
+	}
+
+	row.StructScan(identity)
+	return identity, nil
+}
+
+/* FindUserIdentity finds the identity the provider knows as subject, whichever user it belongs to */
+func (connection *connection) FindUserIdentity(provider string, subject string) (*dto.UserIdentity, rest_errors.RestErr) {
+	identity := &dto.UserIdentity{}
+	query, args, buildErr := connection.sqlBuilder.SearchBy("user_identities", map[string]interface{}{"provider": provider, "subject": subject})
+	if buildErr != nil {
+		return nil, SqlBuilderError(buildErr)
+	}
+	if err := connection.db.Get(identity, query, args...); err != nil {
+		message := fmt.Sprintf("Sorry, no user is linked to this %s account", provider)
+		return nil, rest_errors.NewNotFoundError(message)
+	}
+	return identity, nil
+}
+
+func (connection *connection) GetUserIdentity(userId *int64, id *int64) (*dto.UserIdentity, rest_errors.RestErr) {
+	identity := &dto.UserIdentity{}
+	query, args, buildErr := connection.sqlBuilder.Find("user_identities", map[string]interface{}{"id": id, "user_id": userId})
+	if buildErr != nil {
+		return nil, SqlBuilderError(buildErr)
+	}
+	if err := connection.db.Get(identity, query, args...); err != nil {
+		message := fmt.Sprintf("Sorry, the identity with id %v doesn't exist", *id)
+		return nil, rest_errors.NewNotFoundError(message)
+	}
+	return identity, nil
+}
+
+func (connection *connection) SearchUserIdentities(userId int64) (dto.UserIdentities, rest_errors.RestErr) {
+	identities := dto.UserIdentities{}
+	sqlQuery, args, buildErr := connection.sqlBuilder.SearchBy("user_identities", map[string]interface{}{"user_id": userId})
+	if buildErr != nil {
+		return nil, SqlBuilderError(buildErr)
+	}
+	if err := connection.db.Select(&identities, sqlQuery, args...); err != nil {
+		return nil, rest_errors.NewInternalServerError(err)
+	}
+	return identities, nil
+}
+
+/* UpdateUserIdentityEmail keeps the email of the identity in step with the provider, it may change there */
+func (connection *connection) UpdateUserIdentityEmail(identity *dto.UserIdentity, email string) (*dto.UserIdentity, rest_errors.RestErr) {
+	sqlQuery, args, buildErr := connection.sqlBuilder.Update("user_identities", &identity.Id, map[string]interface{}{"email": email})
+	if buildErr != nil {
+		return nil, SqlBuilderError(buildErr)
+	}
+	if err := connection.db.QueryRowx(sqlQuery, args...).StructScan(identity); err != nil {
+		return nil, rest_errors.NewInternalServerError(err)
+	}
+	return identity, nil
+}
+
+func (connection *connection) DeleteUserIdentity(identity *dto.UserIdentity) rest_errors.RestErr {
+	sqlQuery, args, buildErr := connection.sqlBuilder.Delete("user_identities", &identity.Id)
+	if buildErr != nil {
+		return SqlBuilderError(buildErr)
+	}
+	if _, err := connection.db.Exec(sqlQuery, args...); err != nil {
+		return rest_errors.NewInternalServerError(err)
+	}
+	return nil
+}
@@ -16,8 +16,11 @@ type UsersDao interface {
 	UpdateUser(id *int64, payload interface{}) (*dto.User, rest_errors.RestErr)
 	GetUser(id *int64) (*dto.User, rest_errors.RestErr)
 	GetSessionUser(id *int64) (*dto.BaseUser, rest_errors.RestErr)
-	AuthorizedUsersCollection(url.Values, *dto.BaseUser) (dto.Users, rest_errors.RestErr)
+	AuthorizedUsersCollection(url.Values, *dto.BaseUser) (dto.Users, uint64, rest_errors.RestErr)
 	Where(params map[string]interface{}) *dto.User
+	DeleteUser(*dto.User) rest_errors.RestErr
+	GetDeletedUser(id *int64) (*dto.User, rest_errors.RestErr)
+	RestoreUser(*dto.User) (*dto.User, rest_errors.RestErr)
 }
 
 func NewUsersDao() UsersDao {
@@ -29,9 +32,12 @@ func NewUsersDao() UsersDao {
 
 func (connection *connection) CreateUser(payload *dto.CreateUserPayload) (*dto.User, rest_errors.RestErr) {
 	user := &dto.User{}
-	sqlQuery := connection.sqlBuilder.Insert("users", payload)
+	sqlQuery, args, buildErr := connection.sqlBuilder.Insert("users", payload)
+	if buildErr != nil {
+		return nil, SqlBuilderError(buildErr)
+	}
 
-	row := connection.db.QueryRowx(sqlQuery)
+	row := connection.db.QueryRowx(sqlQuery, args...)
 	if row.Err() != nil {
 		if uniquenessViolation, constraintName := database.HasUniquenessViolation(row.Err()); uniquenessViolation {
 			return nil, rest_errors.InvalidError(ErrorMessage(constraintName))
@@ -53,9 +59,12 @@ func (connection *connection) FindOrCreateUser(userData *dto.CreateUserPayload)
 		return user, nil
 	}
 
-	sqlQuery := connection.sqlBuilder.Insert("users", userData)
+	sqlQuery, args, buildErr := connection.sqlBuilder.Insert("users", userData)
+	if buildErr != nil {
+		return nil, SqlBuilderError(buildErr)
+	}
 
-	row := connection.db.QueryRowx(sqlQuery)
+	row := connection.db.QueryRowx(sqlQuery, args...)
 	if row.Err() != nil {
 		if uniquenessViolation, constraintName := database.HasUniquenessViolation(row.Err()); uniquenessViolation {
 			return nil, rest_errors.InvalidError(ErrorMessage(constraintName))
@@ -70,8 +79,11 @@ func (connection *connection) FindOrCreateUser(userData *dto.CreateUserPayload)
 }
 
 func (connection *connection) UpdateUser(id *int64, payload interface{}) (*dto.User, rest_errors.RestErr) {
-	sqlQuery := connection.sqlBuilder.Update("users", id, payload)
-	row := connection.db.QueryRowx(sqlQuery)
+	sqlQuery, args, buildErr := connection.sqlBuilder.Update("users", id, payload)
+	if buildErr != nil {
+		return nil, SqlBuilderError(buildErr)
+	}
+	row := connection.db.QueryRowx(sqlQuery, args...)
 	if row.Err() != nil {
 		if uniquenessViolation, constraintName := database.HasUniquenessViolation(row.Err()); uniquenessViolation {
 			return nil, rest_errors.InvalidError(ErrorMessage(constraintName))
@@ -85,8 +97,11 @@ func (connection *connection) UpdateUser(id *int64, payload interface{}) (*dto.U
 
 func (connection *connection) GetUser(id *int64) (*dto.User, rest_errors.RestErr) {
 	user := &dto.User{}
-	query := connection.sqlBuilder.Find("users", map[string]interface{}{"id": id})
-	err := connection.db.Get(user, query)
+	query, args, buildErr := connection.sqlBuilder.Find("users", map[string]interface{}{"id": id, "deleted_at": nil})
+	if buildErr != nil {
+		return nil, SqlBuilderError(buildErr)
+	}
+	err := connection.db.Get(user, query, args...)
 
 	if err != nil {
 		message := fmt.Sprintf("Sorry, user with id %v doesn't exist", *id)
@@ -98,8 +113,11 @@ func (connection *connection) GetUser(id *int64) (*dto.User, rest_errors.RestErr
 
 func (connection *connection) GetSessionUser(id *int64) (*dto.BaseUser, rest_errors.RestErr) {
 	user := &dto.User{}
-	query := connection.sqlBuilder.Find("users", map[string]interface{}{"id": id})
-	err := connection.db.Get(user, query)
+	query, args, buildErr := connection.sqlBuilder.Find("users", map[string]interface{}{"id": id, "deleted_at": nil})
+	if buildErr != nil {
+		return nil, SqlBuilderError(buildErr)
+	}
+	err := connection.db.Get(user, query, args...)
 
 	if err != nil {
 		message := fmt.Sprintf("Sorry, user with id %v doesn't exist", *id)
@@ -109,11 +127,16 @@ func (connection *connection) GetSessionUser(id *int64) (*dto.BaseUser, rest_err
 	return &user.BaseUser, nil
 }
 
+/* Where also finds deleted users, e.g. so that they can't sign up again with the same email */
 func (connection *connection) Where(params map[string]interface{}) *dto.User {
 	user := &dto.User{}
 
-	query := connection.sqlBuilder.SearchBy("users", params)
-	err := connection.db.Get(user, query)
+	query, args, buildErr := connection.sqlBuilder.SearchBy("users", params)
+	if buildErr != nil {
+		fmt.Println("Error Occured:", buildErr)
+		return nil
+	}
+	err := connection.db.Get(user, query, args...)
 	if err != nil {
 		fmt.Println("Error Occured:", err)
 		return nil
@@ -122,22 +145,76 @@ func (connection *connection) Where(params map[string]interface{}) *dto.User {
 	return user
 }
 
-func (connection *connection) AuthorizedUsersCollection(params url.Values, user *dto.BaseUser) (dto.Users, rest_errors.RestErr) {
+func (connection *connection) AuthorizedUsersCollection(params url.Values, user *dto.BaseUser) (dto.Users, uint64, rest_errors.RestErr) {
+	scope, scopeErr := deletedScope(params, user)
+	if scopeErr != nil {
+		return nil, 0, scopeErr
+	}
+
 	switch user.Role {
 	case consts.Admin:
-		return connection.searchUsers(params)
+		return connection.searchUsers(params, scope)
 	default:
-		return dto.Users{}, nil
+		return dto.Users{}, 0, nil
 	}
 }
 
-func (connection *connection) searchUsers(params url.Values) (dto.Users, rest_errors.RestErr) {
+func (connection *connection) searchUsers(params url.Values, scopes ...database.Scope) (dto.Users, uint64, rest_errors.RestErr) {
 	var users dto.Users
-	sqlQuery := connection.sqlBuilder.Filter("users", params)
-	err := connection.db.Select(&users, sqlQuery)
+	sqlQuery, args, buildErr := connection.sqlBuilder.Filter("users", params, scopes...)
+	if buildErr != nil {
+		return nil, 0, SqlBuilderError(buildErr)
+	}
+	err := connection.db.Select(&users, sqlQuery, args...)
+	if err != nil {
+		return nil, 0, rest_errors.NewNotFoundError(err.Error())
+	}
+
+	total, countErr := connection.count("users", params, scopes...)
+	if countErr != nil {
+		return nil, 0, countErr
+	}
+
+	return users, total, nil
+}
+
+/* DeleteUser soft deletes the user, who can't sign in anymore until restored */
+func (connection *connection) DeleteUser(user *dto.User) rest_errors.RestErr {
+	sqlQuery, args, buildErr := connection.sqlBuilder.SoftDelete("users", &user.Id)
+	if buildErr != nil {
+		return SqlBuilderError(buildErr)
+	}
+	if err := connection.db.QueryRowx(sqlQuery, args...).StructScan(user); err != nil {
+		return rest_errors.NewInternalServerError(err)
+	}
+	return nil
+}
+
+func (connection *connection) GetDeletedUser(id *int64) (*dto.User, rest_errors.RestErr) {
+	user := &dto.User{}
+	query, args, buildErr := connection.sqlBuilder.Find("users", map[string]interface{}{"id": id, "deleted_at__isnull": false})
+	if buildErr != nil {
+		return nil, SqlBuilderError(buildErr)
+	}
+	err := connection.db.Get(user, query, args...)
+
 	if err != nil {
-		return nil, rest_errors.NewNotFoundError(err.Error())
+		message := fmt.Sprintf("Sorry, there is no deleted user with id %v", *id)
+		return nil, rest_errors.NewNotFoundError(message)
 	}
 
-	return users, nil
+	return user, nil
+}
+
+func (connection *connection) RestoreUser(user *dto.User) (*dto.User, rest_errors.RestErr) {
+	sqlQuery, args, buildErr := connection.sqlBuilder.Restore("users", &user.Id)
+	if buildErr != nil {
+		return nil, SqlBuilderError(buildErr)
+	}
+	row := connection.db.QueryRowx(sqlQuery, args...)
+	if row.Err() != nil {
+		return nil, rest_errors.NewInternalServerError(row.Err())
+	}
+	row.StructScan(user)
+	return user, nil
 }
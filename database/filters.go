@@ -0,0 +1,397 @@
+package database
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/doug-martin/goqu/v9"
+	"github.com/doug-martin/goqu/v9/exp"
+	"github.com/lib/pq"
+	"golang.org/x/exp/maps"
+	"golang.org/x/exp/slices"
+	pagination "resturants-hub.com/m/v2/packages"
+	rest_errors "resturants-hub.com/m/v2/packages/utils"
+)
+
+/*
+FilterOperators lists the operators supported in "attr__operator" filter keys, e.g.
+expires_at__lt=2024-06-07, restaurant_id__isnull=true or role__in=admin,manager.
+A key without operator matches the value(s) exactly.
+Array columns are filtered with has (all the values), hasany and hasnone, e.g. allergens__hasnone=nuts,gluten.
+*/
+var FilterOperators = []string{
+	"eq", "neq", "lt", "lte", "gt", "gte", "in", "notin", "isnull", "between",
+	"prefix", "iprefix", "contains", "icontains", "has", "hasany", "hasnone",
+}
+
+/* Operators of array columns, which goqu can't express as an exp.Op */
+var arrayOperators = []string{"has", "hasany", "hasnone"}
+
+/* ArrayColumns lists the text[] columns the array operators apply to */
+var ArrayColumns = []string{"allergens", "dietary"}
+
+const dateLayout = "2006-01-02"
+
+/* JsonColumns lists the JSONB columns whose keys can be filtered on with dotted params, e.g. address.city */
+var JsonColumns = []string{"address"}
+
+/* Keys of JSONB documents which can be filtered on, e.g. the "city" of address.city */
+var jsonKeyFormat = regexp.MustCompile(`^[A-Za-z0-9_]+$`)
+
+/*
+filtersToSql translates filters (request params or scopes) into a where clause.
+Unsupported operators and malformed values are all reported at once in a 400 error.
+*/
+func filtersToSql(params map[string]interface{}) (exp.Expression, error) {
+	ex := goqu.Ex{}
+	literalFilters := []exp.Expression{}
+	causes := rest_errors.ValidationErrs{}
+
+	/* Keys are walked in order so that the same filters always compile to the same statement */
+	keys := maps.Keys(params)
+	slices.Sort(keys)
+	for _, key := range keys {
+		value := params[key]
+		if slices.Contains(pagination.ReservedParams, key) {
+			continue
+		}
+		splits := strings.SplitN(key, "__", 2)
+		attr := splits[0]
+		if strings.Contains(attr, ".") {
+			filter, cause := jsonFilterToSql(attr, splits[1:], value)
+			if cause != nil {
+				causes[key] = append(causes[key], cause)
+				continue
+			}
+			literalFilters = append(literalFilters, filter)
+			continue
+		}
+		if len(splits) == 2 && slices.Contains(arrayOperators, splits[1]) {
+			filter, cause := arrayFilterToSql(attr, splits[1], value)
+			if cause != nil {
+				causes[key] = append(causes[key], cause)
+				continue
+			}
+			literalFilters = append(literalFilters, filter)
+			continue
+		}
+		if len(splits) == 1 {
+			if day, ok := dateValue(attr, value); ok {
+				setFilter(ex, &literalFilters, attr, exp.Op{"between": goqu.Range(day, endOfDay(day))})
+				continue
+			}
+			if _, exists := ex[attr]; exists {
+				setFilter(ex, &literalFilters, attr, eqOp(value))
+				continue
+			}
+			ex[attr] = value
+			continue
+		}
+
+		op, cause := operatorToSql(attr, splits[1], value)
+		if cause != nil {
+			causes[key] = append(causes[key], cause)
+			continue
+		}
+		setFilter(ex, &literalFilters, attr, op)
+	}
+
+	if len(causes) > 0 {
+		return nil, rest_errors.NewRestError("Invalid filter parameters", http.StatusBadRequest, "bad_request", causes)
+	}
+	if len(literalFilters) > 0 {
+		return goqu.And(append([]exp.Expression{ex}, literalFilters...)...), nil
+	}
+	return ex, nil
+}
+
+/*
+jsonFilterToSql filters on a key of a JSONB column, e.g. address.city=Berlin or address.postalCode__prefix=10.
+Exact matches compile to a containment (address @> '{"city":"Berlin"}') which is backed by the GIN index
+of the column, other operators compare the text value of the key (address->>'postalCode').
+*/
+func jsonFilterToSql(attr string, operator []string, value interface{}) (exp.Expression, interface{}) {
+	path := strings.Split(attr, ".")
+	column := path[0]
+	if !slices.Contains(JsonColumns, column) {
+		return nil, map[string]interface{}{
+			"error":     "unsupported_attribute",
+			"attribute": attr,
+		}
+	}
+	for _, key := range path[1:] {
+		if !jsonKeyFormat.MatchString(key) {
+			return nil, map[string]interface{}{
+				"error":     "unsupported_attribute",
+				"attribute": attr,
+			}
+		}
+	}
+
+	if len(operator) == 0 {
+		if values, ok := value.([]string); ok && len(values) == 1 {
+			var document interface{} = values[0]
+			for i := len(path) - 1; i > 0; i-- {
+				document = map[string]interface{}{path[i]: document}
+			}
+			containment, _ := json.Marshal(document)
+			return goqu.L("? @> ?", goqu.I(column), string(containment)), nil
+		}
+		operator = []string{"in"}
+	}
+
+	op, cause := operatorToSql(path[len(path)-1], operator[0], value)
+	if cause != nil {
+		return nil, cause
+	}
+
+	/* Keys are inlined (they are validated above) so that expression indexes on them can be used */
+	text := goqu.L(fmt.Sprintf("?->>'%s'", path[1]), goqu.I(column))
+	if len(path) > 2 {
+		text = goqu.L(fmt.Sprintf("?#>>'{%s}'", strings.Join(path[1:], ",")), goqu.I(column))
+	}
+	return opToSql(text, op), nil
+}
+
+/*
+arrayFilterToSql filters on the values of an array column: has matches the records holding all the values
+(allergens @> '{nuts}'), hasany the ones holding some of them and hasnone the ones holding none of them.
+Other columns don't support these operators.
+*/
+func arrayFilterToSql(attr string, operator string, value interface{}) (exp.Expression, interface{}) {
+	if !slices.Contains(ArrayColumns, attr) {
+		return nil, map[string]interface{}{
+			"error":     "unsupported_operator",
+			"operator":  operator,
+			"supported": slices.DeleteFunc(slices.Clone(FilterOperators), func(supported string) bool { return slices.Contains(arrayOperators, supported) }),
+		}
+	}
+	values := []string{}
+	for _, item := range listValue(value) {
+		values = append(values, fmt.Sprint(item))
+	}
+	array := goqu.L("?::text[]", pq.Array(values))
+	switch operator {
+	case "has":
+		return goqu.L("? @> ?", goqu.I(attr), array), nil
+	case "hasany":
+		return goqu.L("? && ?", goqu.I(attr), array), nil
+	default:
+		return goqu.L("NOT (? && ?)", goqu.I(attr), array), nil
+	}
+}
+
+/* opToSql applies the conditions of op to an expression which can't be expressed as a goqu.Ex key */
+func opToSql(lhs exp.LiteralExpression, op exp.Op) exp.Expression {
+	conditions := []exp.Expression{}
+	for operator, value := range op {
+		switch operator {
+		case "eq":
+			conditions = append(conditions, lhs.Eq(value))
+		case "neq":
+			conditions = append(conditions, lhs.Neq(value))
+		case "lt":
+			conditions = append(conditions, lhs.Lt(value))
+		case "lte":
+			conditions = append(conditions, lhs.Lte(value))
+		case "gt":
+			conditions = append(conditions, lhs.Gt(value))
+		case "gte":
+			conditions = append(conditions, lhs.Gte(value))
+		case "in":
+			conditions = append(conditions, lhs.In(value))
+		case "notin":
+			conditions = append(conditions, lhs.NotIn(value))
+		case "is":
+			conditions = append(conditions, lhs.Is(value))
+		case "isnot":
+			conditions = append(conditions, lhs.IsNot(value))
+		case "between":
+			conditions = append(conditions, lhs.Between(value.(exp.RangeVal)))
+		case "like":
+			conditions = append(conditions, lhs.Like(value))
+		case "ilike":
+			conditions = append(conditions, lhs.ILike(value))
+		}
+	}
+	return goqu.And(conditions...)
+}
+
+/*
+setFilter adds op to the conditions of attr, so that e.g. created_at__gte and created_at__lt are both applied.
+goqu ORs the operators of a single exp.Op, further conditions on attr are ANDed as expressions of their own instead
+*/
+func setFilter(ex goqu.Ex, conditions *[]exp.Expression, attr string, op exp.Op) {
+	if _, exists := ex[attr]; !exists {
+		ex[attr] = op
+		return
+	}
+	*conditions = append(*conditions, goqu.Ex{attr: op})
+}
+
+func eqOp(value interface{}) exp.Op {
+	if value == nil {
+		return exp.Op{"is": nil}
+	}
+	if reflect.ValueOf(value).Kind() == reflect.Slice {
+		return exp.Op{"in": value}
+	}
+	return exp.Op{"eq": value}
+}
+
+func operatorToSql(attr string, operator string, value interface{}) (exp.Op, interface{}) {
+	switch operator {
+	case "eq", "neq":
+		return exp.Op{operator: singleValue(value)}, nil
+	case "lt", "lte", "gt", "gte":
+		bound, cause := boundValue(attr, singleValue(value), operator == "gt" || operator == "lte")
+		if cause != nil {
+			return nil, cause
+		}
+		return exp.Op{operator: bound}, nil
+	case "in", "notin":
+		return exp.Op{operator: listValue(value)}, nil
+	case "isnull":
+		isNull, err := strconv.ParseBool(fmt.Sprint(singleValue(value)))
+		if err != nil {
+			return nil, invalidValue(value, "expected true or false")
+		}
+		if isNull {
+			return exp.Op{"is": nil}, nil
+		}
+		return exp.Op{"isnot": nil}, nil
+	case "between":
+		values := listValue(value)
+		if len(values) != 2 {
+			return nil, invalidValue(value, "expected two comma separated values")
+		}
+		from, cause := boundValue(attr, values[0], false)
+		if cause != nil {
+			return nil, cause
+		}
+		to, cause := boundValue(attr, values[1], true)
+		if cause != nil {
+			return nil, cause
+		}
+		return exp.Op{"between": goqu.Range(from, to)}, nil
+	case "prefix":
+		return exp.Op{"like": escapeLike(singleValue(value)) + "%"}, nil
+	case "iprefix":
+		return exp.Op{"ilike": escapeLike(singleValue(value)) + "%"}, nil
+	case "contains":
+		return exp.Op{"like": "%" + escapeLike(singleValue(value)) + "%"}, nil
+	case "icontains":
+		return exp.Op{"ilike": "%" + escapeLike(singleValue(value)) + "%"}, nil
+	default:
+		return nil, map[string]interface{}{
+			"error":     "unsupported_operator",
+			"operator":  operator,
+			"supported": FilterOperators,
+		}
+	}
+}
+
+func invalidValue(value interface{}, message string) map[string]interface{} {
+	return map[string]interface{}{
+		"error":   "invalid_value",
+		"value":   value,
+		"message": message,
+	}
+}
+
+/* Request params come as []string while scopes hold plain values */
+func singleValue(value interface{}) interface{} {
+	if values, ok := value.([]string); ok {
+		if len(values) == 0 {
+			return ""
+		}
+		return values[0]
+	}
+	return value
+}
+
+/* listValue accepts repeated params (in=a&in=b) as well as comma separated values (in=a,b) */
+func listValue(value interface{}) []interface{} {
+	var raw []string
+	switch v := value.(type) {
+	case []string:
+		raw = v
+	case string:
+		raw = []string{v}
+	default:
+		rv := reflect.ValueOf(value)
+		if rv.Kind() != reflect.Slice {
+			return []interface{}{value}
+		}
+		list := make([]interface{}, rv.Len())
+		for i := range list {
+			list[i] = rv.Index(i).Interface()
+		}
+		return list
+	}
+
+	list := []interface{}{}
+	for _, item := range raw {
+		for _, part := range strings.Split(item, ",") {
+			list = append(list, strings.TrimSpace(part))
+		}
+	}
+	return list
+}
+
+/* escapeLike makes sure user input can't inject LIKE wildcards */
+func escapeLike(value interface{}) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(fmt.Sprint(value))
+}
+
+/* Timestamp columns follow the "*_at" naming convention (created_at, expires_at...) */
+func isTimestamp(attr string) bool {
+	return strings.HasSuffix(attr, "_at")
+}
+
+/*
+boundValue parses the bound of a range on timestamp columns. Both RFC3339 timestamps and dates are accepted:
+a date stands for the start of the day, or for its end when upper is set, so that
+created_at__lte=2024-06-07 includes the whole day.
+*/
+func boundValue(attr string, value interface{}, upper bool) (interface{}, interface{}) {
+	text, isString := value.(string)
+	if !isTimestamp(attr) || !isString {
+		return value, nil
+	}
+	if timestamp, err := time.Parse(time.RFC3339, text); err == nil {
+		return timestamp, nil
+	}
+	day, err := time.Parse(dateLayout, text)
+	if err != nil {
+		return nil, map[string]interface{}{
+			"error":   "invalid_date",
+			"value":   text,
+			"message": "expected a date (2006-01-02) or a RFC3339 timestamp",
+		}
+	}
+	if upper {
+		return endOfDay(day), nil
+	}
+	return day, nil
+}
+
+/* dateValue tells if an exact match on a timestamp column is given a date, to match the whole day */
+func dateValue(attr string, value interface{}) (time.Time, bool) {
+	values, ok := value.([]string)
+	if !isTimestamp(attr) || !ok || len(values) != 1 {
+		return time.Time{}, false
+	}
+	day, err := time.Parse(dateLayout, values[0])
+	return day, err == nil
+}
+
+func endOfDay(day time.Time) time.Time {
+	return day.AddDate(0, 0, 1).Add(-time.Microsecond)
+}
@@ -0,0 +1,199 @@
+package database
+
+import (
+	"net/http"
+	"reflect"
+	"testing"
+	"time"
+
+	rest_errors "resturants-hub.com/m/v2/packages/utils"
+)
+
+func TestFiltersToSql(t *testing.T) {
+	june1 := time.Date(2024, time.June, 1, 0, 0, 0, 0, time.UTC)
+	endOfJune1 := time.Date(2024, time.June, 1, 23, 59, 59, 999999000, time.UTC)
+	endOfJune3 := time.Date(2024, time.June, 3, 23, 59, 59, 999999000, time.UTC)
+
+	tests := []struct {
+		name     string
+		params   map[string]interface{}
+		wantSql  string
+		wantArgs []interface{}
+	}{
+		{
+			name:     "exact value",
+			params:   map[string]interface{}{"name": []string{"pasta"}},
+			wantSql:  `SELECT * FROM "dishes" WHERE ("name" IN ($1))`,
+			wantArgs: []interface{}{"pasta"},
+		},
+		{
+			name:     "null scope",
+			params:   map[string]interface{}{"deleted_at": nil},
+			wantSql:  `SELECT * FROM "dishes" WHERE ("deleted_at" IS NULL)`,
+			wantArgs: []interface{}{},
+		},
+		{
+			name:     "in with comma separated values",
+			params:   map[string]interface{}{"role__in": []string{"admin,manager"}},
+			wantSql:  `SELECT * FROM "dishes" WHERE ("role" IN ($1, $2))`,
+			wantArgs: []interface{}{"admin", "manager"},
+		},
+		{
+			name:     "notin with repeated values",
+			params:   map[string]interface{}{"role__notin": []string{"admin", "manager"}},
+			wantSql:  `SELECT * FROM "dishes" WHERE ("role" NOT IN ($1, $2))`,
+			wantArgs: []interface{}{"admin", "manager"},
+		},
+		{
+			name:     "range on one attribute",
+			params:   map[string]interface{}{"price__gte": []string{"10"}, "price__lt": []string{"20"}},
+			wantSql:  `SELECT * FROM "dishes" WHERE (("price" >= $1) AND ("price" < $2))`,
+			wantArgs: []interface{}{"10", "20"},
+		},
+		{
+			name:     "neq",
+			params:   map[string]interface{}{"status__neq": []string{"cancelled"}},
+			wantSql:  `SELECT * FROM "dishes" WHERE ("status" != $1)`,
+			wantArgs: []interface{}{"cancelled"},
+		},
+		{
+			name:     "isnull",
+			params:   map[string]interface{}{"restaurant_id__isnull": []string{"true"}},
+			wantSql:  `SELECT * FROM "dishes" WHERE ("restaurant_id" IS NULL)`,
+			wantArgs: []interface{}{},
+		},
+		{
+			name:     "is not null",
+			params:   map[string]interface{}{"restaurant_id__isnull": []string{"false"}},
+			wantSql:  `SELECT * FROM "dishes" WHERE ("restaurant_id" IS NOT NULL)`,
+			wantArgs: []interface{}{},
+		},
+		{
+			name:     "prefix escapes wildcards",
+			params:   map[string]interface{}{"name__prefix": []string{"50%_off"}},
+			wantSql:  `SELECT * FROM "dishes" WHERE ("name" LIKE $1)`,
+			wantArgs: []interface{}{`50\%\_off%`},
+		},
+		{
+			name:     "icontains",
+			params:   map[string]interface{}{"name__icontains": []string{"Pasta"}},
+			wantSql:  `SELECT * FROM "dishes" WHERE ("name" ILIKE $1)`,
+			wantArgs: []interface{}{"%Pasta%"},
+		},
+		{
+			name:     "day of a timestamp",
+			params:   map[string]interface{}{"created_at": []string{"2024-06-01"}},
+			wantSql:  `SELECT * FROM "dishes" WHERE ("created_at" BETWEEN $1 AND $2)`,
+			wantArgs: []interface{}{june1, endOfJune1},
+		},
+		{
+			name:     "lte includes the whole day",
+			params:   map[string]interface{}{"created_at__lte": []string{"2024-06-01"}},
+			wantSql:  `SELECT * FROM "dishes" WHERE ("created_at" <= $1)`,
+			wantArgs: []interface{}{endOfJune1},
+		},
+		{
+			name:     "between days",
+			params:   map[string]interface{}{"created_at__between": []string{"2024-06-01,2024-06-03"}},
+			wantSql:  `SELECT * FROM "dishes" WHERE ("created_at" BETWEEN $1 AND $2)`,
+			wantArgs: []interface{}{june1, endOfJune3},
+		},
+		{
+			name:     "key of a JSONB column",
+			params:   map[string]interface{}{"address.city": []string{"Berlin"}},
+			wantSql:  `SELECT * FROM "dishes" WHERE "address" @> $1`,
+			wantArgs: []interface{}{`{"city":"Berlin"}`},
+		},
+		{
+			name:     "key of a JSONB column with several values",
+			params:   map[string]interface{}{"address.city": []string{"Berlin", "Paris"}},
+			wantSql:  `SELECT * FROM "dishes" WHERE ("address"->>'city' IN ($1, $2))`,
+			wantArgs: []interface{}{"Berlin", "Paris"},
+		},
+		{
+			name:     "operator on a key of a JSONB column",
+			params:   map[string]interface{}{"address.postalCode__prefix": []string{"10"}},
+			wantSql:  `SELECT * FROM "dishes" WHERE ("address"->>'postalCode' LIKE $1)`,
+			wantArgs: []interface{}{"10%"},
+		},
+		{
+			name:     "nested key of a JSONB column",
+			params:   map[string]interface{}{"address.geo.lat__gt": []string{"52"}},
+			wantSql:  `SELECT * FROM "dishes" WHERE ("address"#>>'{geo,lat}' > $1)`,
+			wantArgs: []interface{}{"52"},
+		},
+		{
+			name:     "array holding all the values",
+			params:   map[string]interface{}{"dietary__has": []string{"vegan,halal"}},
+			wantSql:  `SELECT * FROM "dishes" WHERE "dietary" @> $1::text[]`,
+			wantArgs: []interface{}{`{"vegan","halal"}`},
+		},
+		{
+			name:     "array holding some of the values",
+			params:   map[string]interface{}{"dietary__hasany": []string{"vegan", "halal"}},
+			wantSql:  `SELECT * FROM "dishes" WHERE "dietary" && $1::text[]`,
+			wantArgs: []interface{}{`{"vegan","halal"}`},
+		},
+		{
+			name:     "array holding none of the values",
+			params:   map[string]interface{}{"allergens__hasnone": []string{"nuts,gluten"}},
+			wantSql:  `SELECT * FROM "dishes" WHERE NOT ("allergens" && $1::text[])`,
+			wantArgs: []interface{}{`{"nuts","gluten"}`},
+		},
+		{
+			name:     "reserved params are skipped",
+			params:   map[string]interface{}{"page": []string{"2"}, "sort": []string{"name"}},
+			wantSql:  `SELECT * FROM "dishes"`,
+			wantArgs: []interface{}{},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			sql, args, err := NewSqlBuilder().SearchBy("dishes", test.params)
+			if err != nil {
+				t.Fatalf("SearchBy() error = %v", err)
+			}
+			if sql != test.wantSql {
+				t.Errorf("SearchBy() sql =\n%s\nwant\n%s", sql, test.wantSql)
+			}
+			if !reflect.DeepEqual(args, test.wantArgs) {
+				t.Errorf("SearchBy() args = %#v, want %#v", args, test.wantArgs)
+			}
+		})
+	}
+}
+
+func TestFiltersToSqlErrors(t *testing.T) {
+	tests := []struct {
+		name      string
+		params    map[string]interface{}
+		wantError string
+	}{
+		{name: "unsupported operator", params: map[string]interface{}{"name__like": []string{"x"}}, wantError: "unsupported_operator"},
+		{name: "isnull expects a boolean", params: map[string]interface{}{"restaurant_id__isnull": []string{"maybe"}}, wantError: "invalid_value"},
+		{name: "between expects two values", params: map[string]interface{}{"price__between": []string{"10"}}, wantError: "invalid_value"},
+		{name: "key of a column which isn't JSONB", params: map[string]interface{}{"email.x": []string{"1"}}, wantError: "unsupported_attribute"},
+		{name: "malformed JSONB key", params: map[string]interface{}{"address.city')--": []string{"x"}}, wantError: "unsupported_attribute"},
+		{name: "array operator on another column", params: map[string]interface{}{"name__has": []string{"x"}}, wantError: "unsupported_operator"},
+		{name: "array operator on a JSONB key", params: map[string]interface{}{"address.tags__hasany": []string{"x"}}, wantError: "unsupported_operator"},
+		{name: "malformed day", params: map[string]interface{}{"created_at__gt": []string{"2024-13-01"}}, wantError: "invalid_date"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, _, err := NewSqlBuilder().SearchBy("dishes", test.params)
+			restErr, ok := err.(rest_errors.RestErr)
+			if !ok || restErr.Status() != http.StatusBadRequest {
+				t.Fatalf("SearchBy() error = %v, want a 400", err)
+			}
+			for key := range test.params {
+				causes := restErr.Causes()
+				if len(causes[key]) != 1 {
+					t.Fatalf("causes = %v, want one cause for %s", causes, key)
+				}
+				if cause := causes[key][0].(map[string]interface{}); cause["error"] != test.wantError {
+					t.Errorf("cause = %v, want error %s", cause, test.wantError)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,11 @@
+BEGIN;
+
+ALTER TABLE dishes
+    ALTER COLUMN category DROP NOT NULL,
+    ALTER COLUMN category DROP DEFAULT,
+    ALTER COLUMN tags DROP NOT NULL,
+    ALTER COLUMN tags DROP DEFAULT,
+    ALTER COLUMN website DROP NOT NULL,
+    ALTER COLUMN website DROP DEFAULT;
+
+COMMIT;
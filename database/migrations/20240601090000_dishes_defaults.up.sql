@@ -0,0 +1,15 @@
+BEGIN;
+
+UPDATE dishes SET category = '' WHERE category IS NULL;
+UPDATE dishes SET tags = '' WHERE tags IS NULL;
+UPDATE dishes SET website = '' WHERE website IS NULL;
+
+ALTER TABLE dishes
+    ALTER COLUMN category SET DEFAULT '',
+    ALTER COLUMN category SET NOT NULL,
+    ALTER COLUMN tags SET DEFAULT '',
+    ALTER COLUMN tags SET NOT NULL,
+    ALTER COLUMN website SET DEFAULT '',
+    ALTER COLUMN website SET NOT NULL;
+
+COMMIT;
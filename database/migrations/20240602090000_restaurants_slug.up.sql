@@ -0,0 +1,27 @@
+BEGIN;
+
+ALTER TABLE restaurants ADD COLUMN IF NOT EXISTS slug VARCHAR(60);
+
+/* Backfill slugs for existing restaurants, suffixing duplicates with their position */
+WITH slugs AS (
+    SELECT
+        id,
+        trim(both '-' from regexp_replace(lower(name), '[^a-z0-9]+', '-', 'g')) AS base
+    FROM restaurants
+),
+numbered AS (
+    SELECT
+        id,
+        base,
+        row_number() OVER (PARTITION BY base ORDER BY id) AS position
+    FROM slugs
+)
+UPDATE restaurants
+SET slug = CASE WHEN numbered.position > 1 THEN numbered.base || '-' || numbered.position ELSE numbered.base END
+FROM numbered
+WHERE restaurants.id = numbered.id;
+
+ALTER TABLE restaurants ALTER COLUMN slug SET NOT NULL;
+ALTER TABLE restaurants ADD CONSTRAINT restaurants_slug_key UNIQUE (slug);
+
+COMMIT;
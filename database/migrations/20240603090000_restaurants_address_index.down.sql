@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS restaurants_address_postal_code_idx;
+DROP INDEX IF EXISTS restaurants_address_idx;
@@ -0,0 +1,5 @@
+/* Backs exact matches on address keys (address @> '{"city":"Berlin"}') */
+CREATE INDEX IF NOT EXISTS restaurants_address_idx ON restaurants USING GIN (address jsonb_path_ops);
+
+/* Backs prefix searches on postal codes (address->>'postalCode' LIKE '10%') */
+CREATE INDEX IF NOT EXISTS restaurants_address_postal_code_idx ON restaurants ((address->>'postalCode') text_pattern_ops);
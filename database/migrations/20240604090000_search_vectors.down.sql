@@ -0,0 +1,15 @@
+BEGIN;
+
+DROP TRIGGER IF EXISTS update_restaurants_search_vector ON restaurants;
+DROP TRIGGER IF EXISTS update_pages_search_vector ON pages;
+DROP TRIGGER IF EXISTS update_dishes_search_vector ON dishes;
+
+DROP FUNCTION IF EXISTS update_restaurants_search_vector;
+DROP FUNCTION IF EXISTS update_pages_search_vector;
+DROP FUNCTION IF EXISTS update_dishes_search_vector;
+
+ALTER TABLE restaurants DROP COLUMN IF EXISTS search_vector;
+ALTER TABLE pages DROP COLUMN IF EXISTS search_vector;
+ALTER TABLE dishes DROP COLUMN IF EXISTS search_vector;
+
+COMMIT;
@@ -0,0 +1,68 @@
+BEGIN;
+
+/*
+Full text search documents. Titles/names weigh more (A) than descriptions and excerpts (B) or bodies (C).
+The 'simple' configuration is used as content is written in several languages.
+*/
+ALTER TABLE restaurants ADD COLUMN IF NOT EXISTS search_vector tsvector;
+ALTER TABLE pages ADD COLUMN IF NOT EXISTS search_vector tsvector;
+ALTER TABLE dishes ADD COLUMN IF NOT EXISTS search_vector tsvector;
+
+CREATE OR REPLACE FUNCTION update_restaurants_search_vector()
+RETURNS TRIGGER AS $$
+BEGIN
+    NEW.search_vector =
+        setweight(to_tsvector('simple', coalesce(NEW.name, '')), 'A') ||
+        setweight(to_tsvector('simple', coalesce(NEW.description, '')), 'B');
+    RETURN NEW;
+END;
+$$ language 'plpgsql';
+
+CREATE OR REPLACE FUNCTION update_pages_search_vector()
+RETURNS TRIGGER AS $$
+BEGIN
+    NEW.search_vector =
+        setweight(to_tsvector('simple', coalesce(NEW.title, '')), 'A') ||
+        setweight(to_tsvector('simple', coalesce(NEW.excerpt, '')), 'B') ||
+        setweight(to_tsvector('simple', coalesce(NEW.body, '')), 'C');
+    RETURN NEW;
+END;
+$$ language 'plpgsql';
+
+CREATE OR REPLACE FUNCTION update_dishes_search_vector()
+RETURNS TRIGGER AS $$
+BEGIN
+    NEW.search_vector =
+        setweight(to_tsvector('simple', coalesce(NEW.name, '')), 'A') ||
+        setweight(to_tsvector('simple', coalesce(NEW.description, '')), 'B');
+    RETURN NEW;
+END;
+$$ language 'plpgsql';
+
+CREATE TRIGGER update_restaurants_search_vector BEFORE
+INSERT OR UPDATE OF name, description ON restaurants FOR EACH ROW EXECUTE PROCEDURE update_restaurants_search_vector ();
+
+CREATE TRIGGER update_pages_search_vector BEFORE
+INSERT OR UPDATE OF title, excerpt, body ON pages FOR EACH ROW EXECUTE PROCEDURE update_pages_search_vector ();
+
+CREATE TRIGGER update_dishes_search_vector BEFORE
+INSERT OR UPDATE OF name, description ON dishes FOR EACH ROW EXECUTE PROCEDURE update_dishes_search_vector ();
+
+/* Backfill existing rows through the triggers above, without touching their updated_at */
+ALTER TABLE restaurants DISABLE TRIGGER update_restaurant_updated_at;
+ALTER TABLE pages DISABLE TRIGGER update_pages_updated_at;
+ALTER TABLE dishes DISABLE TRIGGER update_dish_updated_at;
+
+UPDATE restaurants SET name = name;
+UPDATE pages SET title = title;
+UPDATE dishes SET name = name;
+
+ALTER TABLE restaurants ENABLE TRIGGER update_restaurant_updated_at;
+ALTER TABLE pages ENABLE TRIGGER update_pages_updated_at;
+ALTER TABLE dishes ENABLE TRIGGER update_dish_updated_at;
+
+CREATE INDEX IF NOT EXISTS restaurants_search_vector_idx ON restaurants USING GIN (search_vector);
+CREATE INDEX IF NOT EXISTS pages_search_vector_idx ON pages USING GIN (search_vector);
+CREATE INDEX IF NOT EXISTS dishes_search_vector_idx ON dishes USING GIN (search_vector);
+
+COMMIT;
@@ -0,0 +1,7 @@
+BEGIN;
+
+DROP INDEX IF EXISTS pages_parent_position_idx;
+
+ALTER TABLE pages DROP COLUMN IF EXISTS position;
+
+COMMIT;
@@ -0,0 +1,21 @@
+BEGIN;
+
+ALTER TABLE pages ADD COLUMN IF NOT EXISTS position INT NOT NULL DEFAULT 1;
+
+/* Number existing siblings in creation order, so that the navigation doesn't change */
+ALTER TABLE pages DISABLE TRIGGER update_pages_updated_at;
+
+UPDATE pages
+SET position = ordered.position
+FROM (
+    SELECT id, ROW_NUMBER() OVER (PARTITION BY restaurant_id, parent_page_id ORDER BY id) AS position
+    FROM pages
+) AS ordered
+WHERE pages.id = ordered.id;
+
+ALTER TABLE pages ENABLE TRIGGER update_pages_updated_at;
+
+/* Backs the lookup of the children of a page */
+CREATE INDEX IF NOT EXISTS pages_parent_position_idx ON pages (restaurant_id, parent_page_id, position);
+
+COMMIT;
@@ -0,0 +1,9 @@
+BEGIN;
+
+DROP TRIGGER IF EXISTS update_page_revision ON pages;
+DROP TRIGGER IF EXISTS create_page_revision ON pages;
+DROP FUNCTION IF EXISTS create_page_revision;
+
+DROP TABLE IF EXISTS page_revisions;
+
+COMMIT;
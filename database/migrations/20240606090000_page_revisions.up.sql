@@ -0,0 +1,43 @@
+BEGIN;
+
+CREATE TABLE
+    IF NOT EXISTS page_revisions (
+        id serial PRIMARY KEY,
+        page_id int NOT NULL,
+        number int NOT NULL,
+        title VARCHAR(50) NOT NULL,
+        excerpt VARCHAR(2000) NOT NULL,
+        body TEXT NOT NULL,
+        created_at timestamp NOT NULL DEFAULT now (),
+        CONSTRAINT fk_page FOREIGN KEY (page_id) REFERENCES pages (id) ON DELETE CASCADE,
+        CONSTRAINT page_revisions_page_number_key UNIQUE (page_id, number)
+    );
+
+/*
+Every saved version of the content of a page is kept as a revision, numbered per page.
+Updates of a page lock its row, so concurrent updates can't get the same number.
+*/
+CREATE OR REPLACE FUNCTION create_page_revision()
+RETURNS TRIGGER AS $$
+BEGIN
+    INSERT INTO page_revisions (page_id, number, title, excerpt, body)
+    SELECT NEW.id, COALESCE(MAX(number), 0) + 1, NEW.title, NEW.excerpt, NEW.body
+    FROM page_revisions
+    WHERE page_id = NEW.id;
+    RETURN NEW;
+END;
+$$ language 'plpgsql';
+
+CREATE TRIGGER create_page_revision AFTER
+INSERT ON pages FOR EACH ROW EXECUTE PROCEDURE create_page_revision ();
+
+CREATE TRIGGER update_page_revision AFTER
+UPDATE OF title, excerpt, body ON pages FOR EACH ROW
+WHEN (OLD.title IS DISTINCT FROM NEW.title OR OLD.excerpt IS DISTINCT FROM NEW.excerpt OR OLD.body IS DISTINCT FROM NEW.body)
+EXECUTE PROCEDURE create_page_revision ();
+
+/* The current content of existing pages is their first revision */
+INSERT INTO page_revisions (page_id, number, title, excerpt, body, created_at)
+SELECT id, 1, title, excerpt, body, updated_at FROM pages;
+
+COMMIT;
@@ -0,0 +1,11 @@
+BEGIN;
+
+DROP INDEX IF EXISTS pages_unpublish_at_idx;
+DROP INDEX IF EXISTS pages_publish_at_idx;
+
+ALTER TABLE pages
+    DROP CONSTRAINT IF EXISTS pages_visibility_check,
+    DROP COLUMN IF EXISTS publish_at,
+    DROP COLUMN IF EXISTS unpublish_at;
+
+COMMIT;
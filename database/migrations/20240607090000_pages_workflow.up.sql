@@ -0,0 +1,15 @@
+BEGIN;
+
+/* Pages outside of the workflow go back to draft: only published pages were ever shown to visitors */
+UPDATE pages SET visibility = 'draft' WHERE visibility NOT IN ('draft', 'in_review', 'scheduled', 'published', 'archived');
+
+ALTER TABLE pages
+    ADD CONSTRAINT pages_visibility_check CHECK (visibility IN ('draft', 'in_review', 'scheduled', 'published', 'archived')),
+    ADD COLUMN IF NOT EXISTS publish_at timestamp,
+    ADD COLUMN IF NOT EXISTS unpublish_at timestamp;
+
+/* Back the lookups of the scheduler */
+CREATE INDEX IF NOT EXISTS pages_publish_at_idx ON pages (publish_at) WHERE visibility = 'scheduled';
+CREATE INDEX IF NOT EXISTS pages_unpublish_at_idx ON pages (unpublish_at) WHERE visibility = 'published';
+
+COMMIT;
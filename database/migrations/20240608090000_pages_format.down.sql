@@ -0,0 +1,29 @@
+BEGIN;
+
+DROP TRIGGER IF EXISTS update_page_revision ON pages;
+
+CREATE TRIGGER update_page_revision AFTER
+UPDATE OF title, excerpt, body ON pages FOR EACH ROW
+WHEN (OLD.title IS DISTINCT FROM NEW.title OR OLD.excerpt IS DISTINCT FROM NEW.excerpt OR OLD.body IS DISTINCT FROM NEW.body)
+EXECUTE PROCEDURE create_page_revision ();
+
+CREATE OR REPLACE FUNCTION create_page_revision()
+RETURNS TRIGGER AS $$
+BEGIN
+    INSERT INTO page_revisions (page_id, number, title, excerpt, body)
+    SELECT NEW.id, COALESCE(MAX(number), 0) + 1, NEW.title, NEW.excerpt, NEW.body
+    FROM page_revisions
+    WHERE page_id = NEW.id;
+    RETURN NEW;
+END;
+$$ language 'plpgsql';
+
+ALTER TABLE page_revisions DROP COLUMN IF EXISTS format;
+
+/* Bodies longer than the former limit are truncated */
+ALTER TABLE pages
+    DROP CONSTRAINT IF EXISTS pages_format_check,
+    DROP COLUMN IF EXISTS format,
+    ALTER COLUMN body TYPE VARCHAR(5000) USING LEFT(body, 5000);
+
+COMMIT;
@@ -0,0 +1,30 @@
+BEGIN;
+
+/* Existing bodies were written as HTML */
+ALTER TABLE pages
+    ALTER COLUMN body TYPE TEXT,
+    ADD COLUMN IF NOT EXISTS format VARCHAR(20) NOT NULL DEFAULT 'html',
+    ADD CONSTRAINT pages_format_check CHECK (format IN ('markdown', 'html', 'plain'));
+
+ALTER TABLE page_revisions ADD COLUMN IF NOT EXISTS format VARCHAR(20) NOT NULL DEFAULT 'html';
+
+/* Revisions keep the format of their body, so that restoring them renders the same */
+CREATE OR REPLACE FUNCTION create_page_revision()
+RETURNS TRIGGER AS $$
+BEGIN
+    INSERT INTO page_revisions (page_id, number, title, excerpt, body, format)
+    SELECT NEW.id, COALESCE(MAX(number), 0) + 1, NEW.title, NEW.excerpt, NEW.body, NEW.format
+    FROM page_revisions
+    WHERE page_id = NEW.id;
+    RETURN NEW;
+END;
+$$ language 'plpgsql';
+
+DROP TRIGGER IF EXISTS update_page_revision ON pages;
+
+CREATE TRIGGER update_page_revision AFTER
+UPDATE OF title, excerpt, body, format ON pages FOR EACH ROW
+WHEN (OLD.title IS DISTINCT FROM NEW.title OR OLD.excerpt IS DISTINCT FROM NEW.excerpt OR OLD.body IS DISTINCT FROM NEW.body OR OLD.format IS DISTINCT FROM NEW.format)
+EXECUTE PROCEDURE create_page_revision ();
+
+COMMIT;
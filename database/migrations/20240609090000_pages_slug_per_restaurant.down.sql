@@ -0,0 +1,12 @@
+BEGIN;
+
+DROP TRIGGER IF EXISTS record_page_slug ON pages;
+DROP FUNCTION IF EXISTS record_page_slug;
+DROP TABLE IF EXISTS page_slugs;
+
+/* Fails if several restaurants have pages with the same slug */
+ALTER TABLE pages
+    DROP CONSTRAINT IF EXISTS pages_restaurant_slug_key,
+    ADD CONSTRAINT pages_slug_key UNIQUE (slug);
+
+COMMIT;
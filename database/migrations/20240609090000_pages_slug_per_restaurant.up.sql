@@ -0,0 +1,36 @@
+BEGIN;
+
+/* Slugs are only unique among the pages of a restaurant, so that each restaurant can have its own "about" page */
+ALTER TABLE pages
+    DROP CONSTRAINT IF EXISTS pages_slug_key,
+    ADD CONSTRAINT pages_restaurant_slug_key UNIQUE (restaurant_id, slug);
+
+/* Former slugs of pages, so that links to them keep working after a page is renamed */
+CREATE TABLE
+    IF NOT EXISTS page_slugs (
+        id serial PRIMARY KEY,
+        page_id int NOT NULL,
+        restaurant_id int NOT NULL,
+        slug VARCHAR(50) NOT NULL,
+        created_at timestamp NOT NULL DEFAULT now (),
+        CONSTRAINT fk_page FOREIGN KEY (page_id) REFERENCES pages (id) ON DELETE CASCADE,
+        CONSTRAINT page_slugs_restaurant_slug_key UNIQUE (restaurant_id, slug)
+    );
+
+/* A former slug leads to the page which last used it */
+CREATE OR REPLACE FUNCTION record_page_slug()
+RETURNS TRIGGER AS $$
+BEGIN
+    INSERT INTO page_slugs (page_id, restaurant_id, slug)
+    VALUES (OLD.id, OLD.restaurant_id, OLD.slug)
+    ON CONFLICT (restaurant_id, slug) DO UPDATE SET page_id = EXCLUDED.page_id, created_at = now ();
+    RETURN NEW;
+END;
+$$ language 'plpgsql';
+
+CREATE TRIGGER record_page_slug AFTER
+UPDATE OF slug, restaurant_id ON pages FOR EACH ROW
+WHEN (OLD.slug IS DISTINCT FROM NEW.slug OR OLD.restaurant_id IS DISTINCT FROM NEW.restaurant_id)
+EXECUTE PROCEDURE record_page_slug ();
+
+COMMIT;
@@ -0,0 +1,6 @@
+BEGIN;
+
+ALTER TABLE restaurants DROP COLUMN IF EXISTS opening_hours;
+ALTER TABLE restaurants DROP COLUMN IF EXISTS timezone;
+
+COMMIT;
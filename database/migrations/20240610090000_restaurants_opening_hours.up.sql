@@ -0,0 +1,13 @@
+BEGIN;
+
+/* Timezone the opening hours are expressed in, as an IANA name (Europe/Berlin) */
+ALTER TABLE restaurants ADD COLUMN IF NOT EXISTS timezone VARCHAR(64) NOT NULL DEFAULT 'UTC';
+
+/*
+Weekly schedule and dated exceptions:
+{"weekly": {"monday": [{"opens": "11:00", "closes": "14:00"}, {"opens": "18:00", "closes": "01:00"}]},
+ "exceptions": [{"date": "2024-12-25", "closed": true, "reason": "Christmas"}]}
+*/
+ALTER TABLE restaurants ADD COLUMN IF NOT EXISTS opening_hours JSONB NOT NULL DEFAULT '{"weekly": {}, "exceptions": []}';
+
+COMMIT;
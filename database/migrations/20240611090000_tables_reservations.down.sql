@@ -0,0 +1,6 @@
+BEGIN;
+
+DROP TABLE IF EXISTS reservations;
+DROP TABLE IF EXISTS tables;
+
+COMMIT;
@@ -0,0 +1,55 @@
+BEGIN;
+
+/* Needed to mix equality on table_id with range overlaps in the exclusion constraint below */
+CREATE EXTENSION IF NOT EXISTS btree_gist;
+
+CREATE TABLE
+    IF NOT EXISTS tables (
+        id serial PRIMARY KEY,
+        restaurant_id int NOT NULL,
+        name VARCHAR(50) NOT NULL,
+        seats INT NOT NULL CHECK (seats > 0),
+        active BOOLEAN NOT NULL DEFAULT TRUE,
+        created_at timestamp NOT NULL DEFAULT now (),
+        updated_at timestamp NOT NULL DEFAULT now (),
+        CONSTRAINT fk_restaurant FOREIGN KEY (restaurant_id) REFERENCES restaurants (id),
+        CONSTRAINT tables_restaurant_name_key UNIQUE (restaurant_id, name),
+        /* Lets reservations reference a table of their own restaurant only */
+        CONSTRAINT tables_id_restaurant_key UNIQUE (id, restaurant_id)
+    );
+
+CREATE TRIGGER update_table_updated_at BEFORE
+UPDATE ON tables FOR EACH ROW EXECUTE PROCEDURE update_modified_column ();
+
+CREATE TABLE
+    IF NOT EXISTS reservations (
+        id serial PRIMARY KEY,
+        restaurant_id int NOT NULL,
+        table_id int NOT NULL,
+        party_size INT NOT NULL CHECK (party_size > 0),
+        starts_at timestamp NOT NULL,
+        ends_at timestamp NOT NULL,
+        status VARCHAR(20) NOT NULL DEFAULT 'pending',
+        customer_name VARCHAR(100) NOT NULL,
+        customer_email VARCHAR(255) NOT NULL,
+        customer_phone VARCHAR(50) NOT NULL DEFAULT '',
+        notes VARCHAR(1000) NOT NULL DEFAULT '',
+        created_at timestamp NOT NULL DEFAULT now (),
+        updated_at timestamp NOT NULL DEFAULT now (),
+        CONSTRAINT fk_restaurant FOREIGN KEY (restaurant_id) REFERENCES restaurants (id),
+        CONSTRAINT fk_table FOREIGN KEY (table_id, restaurant_id) REFERENCES tables (id, restaurant_id),
+        CONSTRAINT reservations_period_check CHECK (ends_at > starts_at),
+        CONSTRAINT reservations_status_check CHECK (status IN ('pending', 'confirmed', 'cancelled', 'no_show')),
+        /* A table can't be booked twice at the same time, cancelled and missed reservations free it */
+        CONSTRAINT reservations_table_overlap_excl EXCLUDE USING gist (
+            table_id WITH =,
+            tsrange(starts_at, ends_at) WITH &&
+        ) WHERE (status IN ('pending', 'confirmed'))
+    );
+
+CREATE TRIGGER update_reservation_updated_at BEFORE
+UPDATE ON reservations FOR EACH ROW EXECUTE PROCEDURE update_modified_column ();
+
+CREATE INDEX IF NOT EXISTS reservations_restaurant_starts_at_idx ON reservations (restaurant_id, starts_at);
+
+COMMIT;
@@ -0,0 +1,44 @@
+BEGIN;
+
+CREATE TABLE
+    IF NOT EXISTS orders (
+        id serial PRIMARY KEY,
+        restaurant_id int NOT NULL,
+        status VARCHAR(20) NOT NULL DEFAULT 'placed',
+        customer_name VARCHAR(100) NOT NULL,
+        customer_email VARCHAR(255) NOT NULL,
+        customer_phone VARCHAR(50) NOT NULL DEFAULT '',
+        notes VARCHAR(1000) NOT NULL DEFAULT '',
+        pickup_at timestamp,
+        /* Sum of the totals of the items, in cents */
+        total INT NOT NULL CHECK (total >= 0),
+        created_at timestamp NOT NULL DEFAULT now (),
+        updated_at timestamp NOT NULL DEFAULT now (),
+        CONSTRAINT fk_restaurant FOREIGN KEY (restaurant_id) REFERENCES restaurants (id),
+        CONSTRAINT orders_status_check CHECK (status IN ('placed', 'accepted', 'preparing', 'ready', 'completed', 'cancelled'))
+    );
+
+CREATE TRIGGER update_order_updated_at BEFORE
+UPDATE ON orders FOR EACH ROW EXECUTE PROCEDURE update_modified_column ();
+
+CREATE INDEX IF NOT EXISTS orders_restaurant_status_idx ON orders (restaurant_id, status);
+
+/* Name and price of the dish are copied when the order is placed, later changes of the menu don't affect it */
+CREATE TABLE
+    IF NOT EXISTS order_items (
+        id serial PRIMARY KEY,
+        order_id int NOT NULL,
+        dish_id int,
+        name VARCHAR(50) NOT NULL,
+        unit_price INT NOT NULL CHECK (unit_price >= 0),
+        quantity INT NOT NULL CHECK (quantity > 0),
+        total INT GENERATED ALWAYS AS (unit_price * quantity) STORED,
+        notes VARCHAR(500) NOT NULL DEFAULT '',
+        created_at timestamp NOT NULL DEFAULT now (),
+        CONSTRAINT fk_order FOREIGN KEY (order_id) REFERENCES orders (id) ON DELETE CASCADE,
+        CONSTRAINT fk_dish FOREIGN KEY (dish_id) REFERENCES dishes (id) ON DELETE SET NULL
+    );
+
+CREATE INDEX IF NOT EXISTS order_items_order_id_idx ON order_items (order_id);
+
+COMMIT;
@@ -0,0 +1,12 @@
+BEGIN;
+
+DROP TRIGGER IF EXISTS update_review_ratings ON reviews;
+DROP FUNCTION IF EXISTS update_review_ratings;
+DROP TABLE IF EXISTS reviews;
+
+ALTER TABLE dishes DROP COLUMN IF EXISTS rating_average;
+ALTER TABLE dishes DROP COLUMN IF EXISTS rating_count;
+ALTER TABLE restaurants DROP COLUMN IF EXISTS rating_average;
+ALTER TABLE restaurants DROP COLUMN IF EXISTS rating_count;
+
+COMMIT;
@@ -0,0 +1,64 @@
+BEGIN;
+
+/* Ratings of the published reviews, kept up to date by the update_review_ratings trigger below */
+ALTER TABLE dishes ADD COLUMN IF NOT EXISTS rating_average NUMERIC(3, 2) NOT NULL DEFAULT 0;
+ALTER TABLE dishes ADD COLUMN IF NOT EXISTS rating_count INT NOT NULL DEFAULT 0;
+ALTER TABLE restaurants ADD COLUMN IF NOT EXISTS rating_average NUMERIC(3, 2) NOT NULL DEFAULT 0;
+ALTER TABLE restaurants ADD COLUMN IF NOT EXISTS rating_count INT NOT NULL DEFAULT 0;
+
+/*
+Reviews of dishes by diners, who are identified by their email only (one review per dish).
+Reviews hidden by the restaurant wait in the moderation queue of the admins, who restore or remove them
+*/
+CREATE TABLE
+    IF NOT EXISTS reviews (
+        id serial PRIMARY KEY,
+        restaurant_id int NOT NULL,
+        dish_id int NOT NULL,
+        rating SMALLINT NOT NULL CHECK (rating BETWEEN 1 AND 5),
+        title VARCHAR(100) NOT NULL DEFAULT '',
+        body VARCHAR(2000) NOT NULL,
+        author_name VARCHAR(100) NOT NULL,
+        author_email VARCHAR(255) NOT NULL,
+        status VARCHAR(20) NOT NULL DEFAULT 'published',
+        reply VARCHAR(2000) NOT NULL DEFAULT '',
+        replied_at timestamp,
+        created_at timestamp NOT NULL DEFAULT now (),
+        updated_at timestamp NOT NULL DEFAULT now (),
+        CONSTRAINT fk_restaurant FOREIGN KEY (restaurant_id) REFERENCES restaurants (id),
+        CONSTRAINT fk_dish FOREIGN KEY (dish_id) REFERENCES dishes (id) ON DELETE CASCADE,
+        CONSTRAINT reviews_dish_author_key UNIQUE (dish_id, author_email),
+        CONSTRAINT reviews_status_check CHECK (status IN ('published', 'hidden', 'removed'))
+    );
+
+CREATE TRIGGER update_review_updated_at BEFORE
+UPDATE ON reviews FOR EACH ROW EXECUTE PROCEDURE update_modified_column ();
+
+CREATE INDEX IF NOT EXISTS reviews_dish_status_idx ON reviews (dish_id, status);
+CREATE INDEX IF NOT EXISTS reviews_restaurant_status_idx ON reviews (restaurant_id, status);
+
+/*
+Recomputes the ratings of the dish and the restaurant of the review. Their rows are locked first so that
+reviews submitted concurrently are counted by whichever transaction updates the ratings last
+*/
+CREATE OR REPLACE FUNCTION update_review_ratings()
+RETURNS TRIGGER AS $$
+BEGIN
+    PERFORM 1 FROM dishes WHERE id = NEW.dish_id FOR UPDATE;
+    PERFORM 1 FROM restaurants WHERE id = NEW.restaurant_id FOR UPDATE;
+
+    UPDATE dishes SET (rating_average, rating_count) = (
+        SELECT coalesce(round(avg(rating), 2), 0), count(*) FROM reviews WHERE dish_id = NEW.dish_id AND status = 'published'
+    ) WHERE id = NEW.dish_id;
+    UPDATE restaurants SET (rating_average, rating_count) = (
+        SELECT coalesce(round(avg(rating), 2), 0), count(*) FROM reviews WHERE restaurant_id = NEW.restaurant_id AND status = 'published'
+    ) WHERE id = NEW.restaurant_id;
+    RETURN NULL;
+END;
+$$ language 'plpgsql';
+
+/* Deletes of reviews (purges of their dish) are counted since 20240621090000_review_ratings_deletes */
+CREATE TRIGGER update_review_ratings AFTER
+INSERT OR UPDATE OF rating, status ON reviews FOR EACH ROW EXECUTE PROCEDURE update_review_ratings ();
+
+COMMIT;
@@ -0,0 +1,32 @@
+BEGIN;
+
+ALTER TABLE dishes ADD COLUMN IF NOT EXISTS category VARCHAR(50);
+ALTER TABLE dishes ADD COLUMN IF NOT EXISTS tags VARCHAR(50);
+
+/* Categories and tags are moved back as free text, tags are truncated to the size of the former column */
+ALTER TABLE dishes DISABLE TRIGGER update_dish_updated_at;
+
+UPDATE dishes SET category = menu_categories.name
+FROM menu_categories
+WHERE menu_categories.id = dishes.category_id;
+
+UPDATE dishes SET tags = left(dish_tags.names, 50)
+FROM (SELECT dish_id, string_agg(name, ',' ORDER BY name) AS names FROM dish_tag_names GROUP BY dish_id) AS dish_tags
+WHERE dish_tags.dish_id = dishes.id;
+
+ALTER TABLE dishes ENABLE TRIGGER update_dish_updated_at;
+
+ALTER TABLE dishes DROP CONSTRAINT IF EXISTS fk_menu_category;
+ALTER TABLE dishes DROP CONSTRAINT IF EXISTS dishes_allergens_check;
+ALTER TABLE dishes DROP CONSTRAINT IF EXISTS dishes_dietary_check;
+ALTER TABLE dishes DROP COLUMN IF EXISTS category_id;
+ALTER TABLE dishes DROP COLUMN IF EXISTS allergens;
+ALTER TABLE dishes DROP COLUMN IF EXISTS dietary;
+ALTER TABLE dishes DROP COLUMN IF EXISTS modifier_groups;
+
+DROP VIEW IF EXISTS dish_tag_names;
+DROP TABLE IF EXISTS dish_tags;
+DROP TABLE IF EXISTS tags;
+DROP TABLE IF EXISTS menu_categories;
+
+COMMIT;
@@ -0,0 +1,91 @@
+BEGIN;
+
+/* Sections of the menu of a restaurant, in the order they are listed */
+CREATE TABLE
+    IF NOT EXISTS menu_categories (
+        id serial PRIMARY KEY,
+        restaurant_id int NOT NULL,
+        name VARCHAR(50) NOT NULL,
+        description VARCHAR(500) NOT NULL DEFAULT '',
+        position INT NOT NULL DEFAULT 1,
+        created_at timestamp NOT NULL DEFAULT now (),
+        updated_at timestamp NOT NULL DEFAULT now (),
+        CONSTRAINT fk_restaurant FOREIGN KEY (restaurant_id) REFERENCES restaurants (id),
+        CONSTRAINT menu_categories_restaurant_name_key UNIQUE (restaurant_id, name)
+    );
+
+CREATE TRIGGER update_menu_category_updated_at BEFORE
+UPDATE ON menu_categories FOR EACH ROW EXECUTE PROCEDURE update_modified_column ();
+
+CREATE INDEX IF NOT EXISTS menu_categories_restaurant_position_idx ON menu_categories (restaurant_id, position);
+
+/* Tags are shared by the dishes of a restaurant */
+CREATE TABLE
+    IF NOT EXISTS tags (
+        id serial PRIMARY KEY,
+        restaurant_id int NOT NULL,
+        name VARCHAR(50) NOT NULL,
+        created_at timestamp NOT NULL DEFAULT now (),
+        CONSTRAINT fk_restaurant FOREIGN KEY (restaurant_id) REFERENCES restaurants (id),
+        CONSTRAINT tags_restaurant_name_key UNIQUE (restaurant_id, name)
+    );
+
+CREATE TABLE
+    IF NOT EXISTS dish_tags (
+        dish_id int NOT NULL,
+        tag_id int NOT NULL,
+        PRIMARY KEY (dish_id, tag_id),
+        CONSTRAINT fk_dish FOREIGN KEY (dish_id) REFERENCES dishes (id) ON DELETE CASCADE,
+        CONSTRAINT fk_tag FOREIGN KEY (tag_id) REFERENCES tags (id) ON DELETE CASCADE
+    );
+
+CREATE INDEX IF NOT EXISTS dish_tags_tag_id_idx ON dish_tags (tag_id);
+
+/* Tags of the dishes along with their names, which dishes are listed and filtered by */
+CREATE OR REPLACE VIEW dish_tag_names AS
+SELECT dish_tags.dish_id, dish_tags.tag_id, tags.restaurant_id, tags.name
+FROM dish_tags JOIN tags ON tags.id = dish_tags.tag_id;
+
+/*
+Allergens and dietary labels come from a fixed vocabulary (see dto.Allergens and dto.DietaryLabels).
+Modifier groups (sizes, extras...) hold their options along with the price delta of each, in cents
+*/
+ALTER TABLE dishes ADD COLUMN IF NOT EXISTS category_id int;
+ALTER TABLE dishes ADD CONSTRAINT fk_menu_category FOREIGN KEY (category_id) REFERENCES menu_categories (id) ON DELETE SET NULL;
+ALTER TABLE dishes ADD COLUMN IF NOT EXISTS allergens TEXT[] NOT NULL DEFAULT '{}';
+ALTER TABLE dishes ADD COLUMN IF NOT EXISTS dietary TEXT[] NOT NULL DEFAULT '{}';
+ALTER TABLE dishes ADD COLUMN IF NOT EXISTS modifier_groups JSONB NOT NULL DEFAULT '[]';
+ALTER TABLE dishes ADD CONSTRAINT dishes_allergens_check CHECK (allergens <@ ARRAY['gluten', 'crustaceans', 'eggs', 'fish', 'peanuts', 'soybeans', 'milk', 'nuts', 'celery', 'mustard', 'sesame', 'sulphites', 'lupin', 'molluscs']::text[]);
+ALTER TABLE dishes ADD CONSTRAINT dishes_dietary_check CHECK (dietary <@ ARRAY['vegetarian', 'vegan', 'halal', 'kosher', 'gluten_free', 'lactose_free']::text[]);
+
+CREATE INDEX IF NOT EXISTS dishes_category_id_idx ON dishes (category_id);
+CREATE INDEX IF NOT EXISTS dishes_allergens_idx ON dishes USING GIN (allergens);
+CREATE INDEX IF NOT EXISTS dishes_dietary_idx ON dishes USING GIN (dietary);
+
+/* Move the free text categories and comma separated tags of existing dishes over, without touching their updated_at */
+ALTER TABLE dishes DISABLE TRIGGER update_dish_updated_at;
+
+INSERT INTO menu_categories (restaurant_id, name, position)
+SELECT restaurant_id, category, ROW_NUMBER() OVER (PARTITION BY restaurant_id ORDER BY category)
+FROM (SELECT DISTINCT restaurant_id, trim(category) AS category FROM dishes WHERE trim(coalesce(category, '')) <> '') AS categories;
+
+UPDATE dishes SET category_id = menu_categories.id
+FROM menu_categories
+WHERE menu_categories.restaurant_id = dishes.restaurant_id AND menu_categories.name = trim(dishes.category);
+
+INSERT INTO tags (restaurant_id, name)
+SELECT DISTINCT restaurant_id, trim(tag)
+FROM dishes, unnest(string_to_array(tags, ',')) AS tag
+WHERE trim(tag) <> '';
+
+INSERT INTO dish_tags (dish_id, tag_id)
+SELECT DISTINCT dishes.id, tags.id
+FROM dishes, unnest(string_to_array(dishes.tags, ',')) AS tag, tags
+WHERE tags.restaurant_id = dishes.restaurant_id AND tags.name = trim(tag);
+
+ALTER TABLE dishes ENABLE TRIGGER update_dish_updated_at;
+
+ALTER TABLE dishes DROP COLUMN IF EXISTS category;
+ALTER TABLE dishes DROP COLUMN IF EXISTS tags;
+
+COMMIT;
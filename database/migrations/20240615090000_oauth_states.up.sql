@@ -0,0 +1,19 @@
+BEGIN;
+
+/*
+Logins in progress, from the redirect to the provider until its callback: the state is random and single use,
+the PKCE verifier stays on the server. States are kept here (OAUTH_STATE_STORE=postgres) so that the callback
+can be handled by any instance of the app.
+*/
+CREATE TABLE
+    IF NOT EXISTS oauth_states (
+        state VARCHAR(100) PRIMARY KEY,
+        provider VARCHAR(50) NOT NULL,
+        verifier VARCHAR(200) NOT NULL,
+        expires_at timestamp NOT NULL,
+        created_at timestamp NOT NULL DEFAULT now ()
+    );
+
+CREATE INDEX IF NOT EXISTS oauth_states_expires_at_idx ON oauth_states (expires_at);
+
+COMMIT;
@@ -0,0 +1,5 @@
+BEGIN;
+
+ALTER TABLE oauth_states DROP COLUMN IF EXISTS nonce;
+
+COMMIT;
@@ -0,0 +1,6 @@
+BEGIN;
+
+/* Nonce the ID token returned at the end of the login has to carry */
+ALTER TABLE oauth_states ADD COLUMN IF NOT EXISTS nonce VARCHAR(100) NOT NULL DEFAULT '';
+
+COMMIT;
@@ -0,0 +1,7 @@
+BEGIN;
+
+ALTER TABLE oauth_states DROP COLUMN IF EXISTS user_id;
+
+DROP TABLE IF EXISTS user_identities;
+
+COMMIT;
@@ -0,0 +1,27 @@
+BEGIN;
+
+/*
+Accounts of users at the SSO providers, identified by the subject the provider gives them. Users sign in
+through any of their identities, the email is only a fallback for the first login with a provider.
+*/
+CREATE TABLE
+    IF NOT EXISTS user_identities (
+        id serial PRIMARY KEY,
+        user_id int NOT NULL,
+        provider VARCHAR(50) NOT NULL,
+        subject VARCHAR(255) NOT NULL,
+        email VARCHAR(300) NOT NULL DEFAULT '',
+        created_at timestamp NOT NULL DEFAULT now (),
+        updated_at timestamp NOT NULL DEFAULT now (),
+        CONSTRAINT fk_user FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE,
+        CONSTRAINT user_identities_provider_subject_key UNIQUE (provider, subject),
+        CONSTRAINT user_identities_user_provider_key UNIQUE (user_id, provider)
+    );
+
+CREATE TRIGGER update_user_identity_updated_at BEFORE
+UPDATE ON user_identities FOR EACH ROW EXECUTE PROCEDURE update_modified_column ();
+
+/* Logins started by a signed in user link the provider to their account instead of signing in */
+ALTER TABLE oauth_states ADD COLUMN IF NOT EXISTS user_id int;
+
+COMMIT;
@@ -0,0 +1,10 @@
+BEGIN;
+
+DROP INDEX IF EXISTS sessions_user_id_idx;
+DROP INDEX IF EXISTS sessions_token_hash_key;
+
+ALTER TABLE sessions DROP COLUMN IF EXISTS revoked_at;
+ALTER TABLE sessions DROP COLUMN IF EXISTS provider_expires_at;
+ALTER TABLE sessions DROP COLUMN IF EXISTS token_hash;
+
+COMMIT;
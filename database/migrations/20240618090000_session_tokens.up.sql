@@ -0,0 +1,17 @@
+BEGIN;
+
+/*
+Sessions are identified by a random token minted by the app, only its SHA-256 is stored. The tokens of the
+provider stay on the server, their expiry no longer ends the session.
+*/
+ALTER TABLE sessions ADD COLUMN IF NOT EXISTS token_hash VARCHAR(64);
+ALTER TABLE sessions ADD COLUMN IF NOT EXISTS provider_expires_at timestamp;
+ALTER TABLE sessions ADD COLUMN IF NOT EXISTS revoked_at timestamp;
+
+/* Sessions identified by the access token of their provider are signed out */
+UPDATE sessions SET revoked_at = now () WHERE token_hash IS NULL AND revoked_at IS NULL;
+
+CREATE UNIQUE INDEX IF NOT EXISTS sessions_token_hash_key ON sessions (token_hash);
+CREATE INDEX IF NOT EXISTS sessions_user_id_idx ON sessions (user_id);
+
+COMMIT;
@@ -0,0 +1,5 @@
+BEGIN;
+
+/* Prefixed slugs are kept, they remain valid */
+
+COMMIT;
@@ -0,0 +1,12 @@
+BEGIN;
+
+/* Numeric slugs are taken for ids by /restaurants/:id, prefix them like new slugs (1984 => restaurant-1984) */
+UPDATE restaurants
+SET slug = CASE
+    WHEN EXISTS (SELECT 1 FROM restaurants AS others WHERE others.slug = 'restaurant-' || restaurants.slug)
+    THEN 'restaurant-' || restaurants.slug || '-' || restaurants.id
+    ELSE 'restaurant-' || restaurants.slug
+END
+WHERE slug ~ '^[0-9]+$';
+
+COMMIT;
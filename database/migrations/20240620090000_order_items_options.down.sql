@@ -0,0 +1,5 @@
+BEGIN;
+
+ALTER TABLE order_items DROP COLUMN IF EXISTS options;
+
+COMMIT;
@@ -0,0 +1,6 @@
+BEGIN;
+
+/* Modifier options chosen for the item ([{"group", "option", "priceDelta"}]), copied like the name and price of the dish */
+ALTER TABLE order_items ADD COLUMN IF NOT EXISTS options JSONB NOT NULL DEFAULT '[]';
+
+COMMIT;
@@ -0,0 +1,28 @@
+BEGIN;
+
+DROP TRIGGER IF EXISTS update_dish_restaurant_rating ON dishes;
+DROP FUNCTION IF EXISTS update_dish_restaurant_rating;
+
+CREATE OR REPLACE FUNCTION update_review_ratings()
+RETURNS TRIGGER AS $$
+BEGIN
+    PERFORM 1 FROM dishes WHERE id = NEW.dish_id FOR UPDATE;
+    PERFORM 1 FROM restaurants WHERE id = NEW.restaurant_id FOR UPDATE;
+
+    UPDATE dishes SET (rating_average, rating_count) = (
+        SELECT coalesce(round(avg(rating), 2), 0), count(*) FROM reviews WHERE dish_id = NEW.dish_id AND status = 'published'
+    ) WHERE id = NEW.dish_id;
+    UPDATE restaurants SET (rating_average, rating_count) = (
+        SELECT coalesce(round(avg(rating), 2), 0), count(*) FROM reviews WHERE restaurant_id = NEW.restaurant_id AND status = 'published'
+    ) WHERE id = NEW.restaurant_id;
+    RETURN NULL;
+END;
+$$ language 'plpgsql';
+
+DROP TRIGGER IF EXISTS update_review_ratings ON reviews;
+CREATE TRIGGER update_review_ratings AFTER
+INSERT OR UPDATE OF rating, status ON reviews FOR EACH ROW EXECUTE PROCEDURE update_review_ratings ();
+
+DROP FUNCTION IF EXISTS update_restaurant_rating;
+
+COMMIT;
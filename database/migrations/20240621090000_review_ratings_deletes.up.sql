@@ -0,0 +1,63 @@
+BEGIN;
+
+/* Recomputes the ratings of a restaurant from the published reviews of its dishes, deleted dishes aren't counted */
+CREATE OR REPLACE FUNCTION update_restaurant_rating(restaurant int)
+RETURNS VOID AS $$
+BEGIN
+    UPDATE restaurants SET (rating_average, rating_count) = (
+        SELECT coalesce(round(avg(reviews.rating), 2), 0), count(*) FROM reviews
+        JOIN dishes ON dishes.id = reviews.dish_id
+        WHERE reviews.restaurant_id = restaurant AND reviews.status = 'published' AND dishes.deleted_at IS NULL
+    ) WHERE id = restaurant;
+END;
+$$ language 'plpgsql';
+
+/*
+Recomputes the ratings of the dish and the restaurant of the review, deleted reviews (e.g. purged along with their dish)
+included. Their rows are locked first so that reviews submitted concurrently are counted by whichever transaction
+updates the ratings last
+*/
+CREATE OR REPLACE FUNCTION update_review_ratings()
+RETURNS TRIGGER AS $$
+DECLARE
+    review reviews;
+BEGIN
+    IF TG_OP = 'DELETE' THEN
+        review := OLD;
+    ELSE
+        review := NEW;
+    END IF;
+
+    PERFORM 1 FROM dishes WHERE id = review.dish_id FOR UPDATE;
+    PERFORM 1 FROM restaurants WHERE id = review.restaurant_id FOR UPDATE;
+
+    UPDATE dishes SET (rating_average, rating_count) = (
+        SELECT coalesce(round(avg(rating), 2), 0), count(*) FROM reviews WHERE dish_id = review.dish_id AND status = 'published'
+    ) WHERE id = review.dish_id;
+    PERFORM update_restaurant_rating(review.restaurant_id);
+    RETURN NULL;
+END;
+$$ language 'plpgsql';
+
+DROP TRIGGER IF EXISTS update_review_ratings ON reviews;
+CREATE TRIGGER update_review_ratings AFTER
+INSERT OR UPDATE OF rating, status OR DELETE ON reviews FOR EACH ROW EXECUTE PROCEDURE update_review_ratings ();
+
+/* Deleting or restoring a dish takes its reviews out of, or back into, the ratings of its restaurant */
+CREATE OR REPLACE FUNCTION update_dish_restaurant_rating()
+RETURNS TRIGGER AS $$
+BEGIN
+    PERFORM 1 FROM restaurants WHERE id = NEW.restaurant_id FOR UPDATE;
+    PERFORM update_restaurant_rating(NEW.restaurant_id);
+    RETURN NULL;
+END;
+$$ language 'plpgsql';
+
+CREATE TRIGGER update_dish_restaurant_rating AFTER
+UPDATE OF deleted_at ON dishes FOR EACH ROW WHEN (OLD.deleted_at IS DISTINCT FROM NEW.deleted_at)
+EXECUTE PROCEDURE update_dish_restaurant_rating ();
+
+/* Ratings counted reviews of deleted and purged dishes so far */
+SELECT update_restaurant_rating(id) FROM restaurants;
+
+COMMIT;
@@ -22,11 +22,9 @@ var (
 // ConnectDB function: Make database connection
 func init() {
 
-	// Load environmenatal variables
-	err := godotenv.Load(".env")
-
-	if err != nil {
-		log.Fatal("Error loading .env file")
+	// Load environmenatal variables, deployments may set them without .env file
+	if err := godotenv.Load(".env"); err != nil {
+		log.Println("No .env file loaded, reading the environment:", err)
 	}
 
 	DB = sqlx.NewDb(SqlDb(), "postgres")
@@ -76,6 +74,24 @@ func HasUniquenessViolation(err error) (bool, string) {
 	return false, ""
 }
 
+/* HasExclusionViolation tells whether err comes from an exclusion constraint, e.g. overlapping reservations of a table */
+func HasExclusionViolation(err error) (bool, string) {
+	if err, ok := err.(*pq.Error); ok {
+		return err.Code.Name() == "exclusion_violation", err.Constraint
+	}
+
+	return false, ""
+}
+
+/* HasForeignKeyViolation tells whether err comes from a foreign key, e.g. deleting a record others still reference */
+func HasForeignKeyViolation(err error) (bool, string) {
+	if err, ok := err.(*pq.Error); ok {
+		return err.Code.Name() == "foreign_key_violation", err.Constraint
+	}
+
+	return false, ""
+}
+
 func ErrorKey(err error) string {
 	switch err {
 	case sql.ErrNoRows:
@@ -2,9 +2,11 @@ package database
 
 import (
 	"net/url"
-	"strings"
+	"sort"
+	"time"
 
 	"github.com/doug-martin/goqu/v9"
+	_ "github.com/doug-martin/goqu/v9/dialect/postgres"
 	"github.com/doug-martin/goqu/v9/exp"
 	"golang.org/x/exp/constraints"
 	pagination "resturants-hub.com/m/v2/packages"
@@ -14,84 +16,401 @@ type DataValue interface {
 	string | constraints.Float | constraints.Integer | constraints.Complex
 }
 
+/*
+Scope narrows a query down with conditions that can't be expressed through request params,
+e.g. {"deleted_at": nil} to hide soft deleted rows. Keys follow the same "attr__operator" format as params.
+*/
+type Scope map[string]interface{}
+
 type SqlBuilder interface {
-	Filter(tableName string, params url.Values) string
-	Insert(tableName string, data interface{}) string
-	Update(tableName string, id *int64, data interface{}) string
-	Find(tableName string, params map[string]interface{}) string
-	SearchBy(tableName string, params map[string]interface{}) string
+	Filter(tableName string, params url.Values, scopes ...Scope) (string, []interface{}, error)
+	Count(tableName string, params url.Values, scopes ...Scope) (string, []interface{}, error)
+	FullText(tableName string, text string, params url.Values, scopes ...Scope) (string, []interface{}, error)
+	FullTextCount(tableName string, text string, params url.Values, scopes ...Scope) (string, []interface{}, error)
+	Insert(tableName string, data interface{}) (string, []interface{}, error)
+	Update(tableName string, id *int64, data interface{}) (string, []interface{}, error)
+	UpdateWhere(tableName string, data interface{}, params map[string]interface{}) (string, []interface{}, error)
+	Renumber(tableName string, params map[string]interface{}, gapAt int) (string, []interface{}, error)
+	Lock(tableName string, params map[string]interface{}) (string, []interface{}, error)
+	Delete(tableName string, id *int64) (string, []interface{}, error)
+	DeleteWhere(tableName string, params map[string]interface{}) (string, []interface{}, error)
+	InsertIgnore(tableName string, data interface{}) (string, []interface{}, error)
+	SoftDelete(tableName string, id *int64) (string, []interface{}, error)
+	Restore(tableName string, id *int64) (string, []interface{}, error)
+	PurgeDeleted(tableName string, before time.Time, dependents map[string]string, referrers map[string]string) (string, []interface{}, error)
+	Find(tableName string, params map[string]interface{}) (string, []interface{}, error)
+	SearchBy(tableName string, params map[string]interface{}) (string, []interface{}, error)
+	InScope(attr string, operator string, tableName string, column string, params map[string]interface{}) (Scope, error)
 }
 
 type sqlBuilder struct {
+	dialect goqu.DialectWrapper
 }
 
+/*
+NewSqlBuilder returns a builder generating postgres flavoured, prepared statements.
+Values are never inlined in the SQL: they are returned as args to be bound by the driver
+*/
 func NewSqlBuilder() SqlBuilder {
-	return &sqlBuilder{}
+	return &sqlBuilder{
+		dialect: goqu.Dialect("postgres"),
+	}
+}
+
+func (builder *sqlBuilder) Find(tableName string, params map[string]interface{}) (string, []interface{}, error) {
+	exp, err := filtersToSql(params)
+	if err != nil {
+		return "", nil, err
+	}
+
+	return builder.dialect.From(tableName).Prepared(true).Where(exp).ToSQL()
+}
+
+func (builder *sqlBuilder) SearchBy(tableName string, params map[string]interface{}) (string, []interface{}, error) {
+	exp, err := filtersToSql(params)
+	if err != nil {
+		return "", nil, err
+	}
+	return builder.dialect.From(tableName).Prepared(true).Where(exp).ToSQL()
+}
+
+/*
+InScope matches the records whose attr is (operator "in") or isn't (operator "notin") among the column of the
+records of tableName matching params, e.g. the dishes whose id is among the dish_id of the dish_tags named "spicy"
+*/
+func (builder *sqlBuilder) InScope(attr string, operator string, tableName string, column string, params map[string]interface{}) (Scope, error) {
+	exp, err := filtersToSql(params)
+	if err != nil {
+		return nil, err
+	}
+	subquery := builder.dialect.From(tableName).Prepared(true).Select(goqu.I(column)).Where(exp)
+	return Scope{attr: goqu.Op{operator: subquery}}, nil
+}
+
+func (builder *sqlBuilder) Filter(tableName string, params url.Values, scopes ...Scope) (string, []interface{}, error) {
+	meta := pagination.NewPagination(params)
+	if meta.IsKeyset() {
+		return builder.keyset(tableName, params, scopes...)
+	}
+
+	ds, err := builder.filtered(tableName, params, scopes...)
+	if err != nil {
+		return "", nil, err
+	}
+	ds = ds.Order(sortToSql(params, false)...)
+	return ds.Limit(meta.Size()).Offset(meta.Offset()).ToSQL()
+}
+
+/*
+keyset fetches the page right after (or before) the cursor: rows are compared with the cursor
+on the sort key, falling back to the next sort field on ties, so no OFFSET is needed.
+Pages before the cursor are fetched in reverse order and re-sorted in an outer query.
+*/
+func (builder *sqlBuilder) keyset(tableName string, params url.Values, scopes ...Scope) (string, []interface{}, error) {
+	meta := pagination.NewPagination(params)
+	fields := pagination.SortFields(params)
+	backward := meta.IsBackward()
+	cursor, cursorErr := meta.Cursor(fields)
+	if cursorErr != nil {
+		return "", nil, cursorErr
+	}
+
+	ds, err := builder.filtered(tableName, params, scopes...)
+	if err != nil {
+		return "", nil, err
+	}
+	ds = ds.Order(sortToSql(params, backward)...)
+	if cursor != nil {
+		ds = ds.Where(keysetToSql(fields, cursor, backward))
+	}
+	ds = ds.Limit(meta.Size())
+
+	if backward {
+		ds = builder.dialect.From(ds.As("page")).Prepared(true).Order(sortToSql(params, false)...)
+	}
+	return ds.ToSQL()
+}
+
+/*
+keysetToSql expands the row comparison (a, b, id) > (x, y, z) into
+a > x OR (a = x AND b > y) OR (a = x AND b = y AND id > z), honoring the direction of every sort field.
+NULLs can't be compared, they are matched explicitly: they come last whatever the direction (see sortToSql)
+*/
+func keysetToSql(fields []pagination.SortField, cursor []interface{}, backward bool) exp.Expression {
+	or := goqu.Or()
+	for i, field := range fields {
+		and := goqu.And()
+		for j := 0; j < i; j++ {
+			and = and.Append(tiedWith(fields[j].Attr, cursor[j]))
+		}
+		and = and.Append(beyond(field, cursor[i], backward))
+		or = or.Append(and)
+	}
+	return or
+}
+
+/* tiedWith matches the rows having the sort value of the cursor, NULL included */
+func tiedWith(attr string, value interface{}) exp.Expression {
+	if value == nil {
+		return goqu.I(attr).IsNull()
+	}
+	return goqu.I(attr).Eq(value)
+}
+
+/* beyond matches the rows coming after the sort value of the cursor, or before it when paging backward */
+func beyond(field pagination.SortField, value interface{}, backward bool) exp.Expression {
+	column := goqu.I(field.Attr)
+	if backward {
+		/* Any value comes before NULLs */
+		if value == nil {
+			return column.IsNotNull()
+		}
+		if field.Desc {
+			return column.Gt(value)
+		}
+		return column.Lt(value)
+	}
+
+	/* Nothing but other NULLs (ties) comes after NULLs */
+	if value == nil {
+		return goqu.L("FALSE")
+	}
+	if field.Desc {
+		return goqu.Or(column.Lt(value), column.IsNull())
+	}
+	return goqu.Or(column.Gt(value), column.IsNull())
+}
+
+/* Count the records matching the same filters and scopes as Filter, ignoring pagination */
+func (builder *sqlBuilder) Count(tableName string, params url.Values, scopes ...Scope) (string, []interface{}, error) {
+	ds, err := builder.filtered(tableName, params, scopes...)
+	if err != nil {
+		return "", nil, err
+	}
+	return ds.Select(goqu.COUNT(goqu.Star()).As("count")).ToSQL()
 }
 
-func (builder *sqlBuilder) Find(tableName string, params map[string]interface{}) string {
-	exp := filtersToSql(params)
+/*
+FullText matches text against the search_vector column of the table (see the search_vectors migration).
+Records are selected along with their "rank", most relevant first, and filtered like in Filter.
+Text follows the web search syntax: "quoted phrases", OR and -excluded words are supported
+*/
+func (builder *sqlBuilder) FullText(tableName string, text string, params url.Values, scopes ...Scope) (string, []interface{}, error) {
+	meta := pagination.NewPagination(params)
+	ds, err := builder.filtered(tableName, params, scopes...)
+	if err != nil {
+		return "", nil, err
+	}
+
+	query := webSearchQuery(text)
+	rank := goqu.L("ts_rank(?, ?)", goqu.I("search_vector"), query)
+	ds = ds.Select(goqu.Star(), rank.As("rank")).
+		Where(goqu.L("? @@ ?", goqu.I("search_vector"), query)).
+		Order(goqu.I("rank").Desc(), goqu.I("id").Asc())
+	return ds.Limit(meta.Size()).ToSQL()
+}
 
-	sql, _, _ := goqu.From(tableName).Where(exp).ToSQL()
-	return sql
+/* FullTextCount counts all the records matching text, filters and scopes like FullText, ignoring its limit */
+func (builder *sqlBuilder) FullTextCount(tableName string, text string, params url.Values, scopes ...Scope) (string, []interface{}, error) {
+	ds, err := builder.filtered(tableName, params, scopes...)
+	if err != nil {
+		return "", nil, err
+	}
+	return ds.Select(goqu.COUNT(goqu.Star()).As("count")).
+		Where(goqu.L("? @@ ?", goqu.I("search_vector"), webSearchQuery(text))).
+		ToSQL()
 }
 
-func (builder *sqlBuilder) SearchBy(tableName string, params map[string]interface{}) string {
-	exp := filtersToSql(params)
-	sql, _, _ := goqu.From(tableName).Where(exp).ToSQL()
-	return sql
+func webSearchQuery(text string) exp.LiteralExpression {
+	return goqu.L("websearch_to_tsquery('simple', ?)", text)
 }
 
-func (builder *sqlBuilder) Filter(tableName string, params url.Values) string {
+func (builder *sqlBuilder) filtered(tableName string, params url.Values, scopes ...Scope) (*goqu.SelectDataset, error) {
 	query := map[string]interface{}{}
 	for key, value := range params {
 		query[key] = value
 	}
-	meta := pagination.Pagination{Params: query}
 
-	exp := filtersToSql(query)
-	sql, _, _ := goqu.From(tableName).Where(exp).Limit(meta.Size()).Offset(meta.Offset()).ToSQL()
-	return sql
+	exp, err := filtersToSql(query)
+	if err != nil {
+		return nil, err
+	}
+	ds := builder.dialect.From(tableName).Prepared(true).Where(exp)
+	for _, scope := range scopes {
+		scopeExp, err := filtersToSql(scope)
+		if err != nil {
+			return nil, err
+		}
+		ds = ds.Where(scopeExp)
+	}
+	return ds, nil
+}
+
+/*
+sortToSql translates a JSON:API sort param (e.g. "-created_at,name") into order clauses, reversed
+when reverse is set. Attributes must have been whitelisted by the handler before reaching the builder
+*/
+func sortToSql(params url.Values, reverse bool) []exp.OrderedExpression {
+	order := []exp.OrderedExpression{}
+	for _, field := range pagination.SortFields(params) {
+		var ordered exp.OrderedExpression
+		if field.Desc != reverse {
+			ordered = goqu.I(field.Attr).Desc()
+		} else {
+			ordered = goqu.I(field.Attr).Asc()
+		}
+		/* NULLs come last in either direction, so that cursors can tell where they are (see keysetToSql) */
+		if reverse {
+			ordered = ordered.NullsFirst()
+		} else {
+			ordered = ordered.NullsLast()
+		}
+		order = append(order, ordered)
+	}
+	return order
+}
+
+func (builder *sqlBuilder) Insert(tableName string, data interface{}) (string, []interface{}, error) {
+	ds := builder.dialect.Insert(tableName).Prepared(true).Rows(data).Returning(goqu.T(tableName).All())
+
+	return ds.ToSQL()
 }
 
-func (builder *sqlBuilder) Insert(tableName string, data interface{}) string {
-	ds := goqu.Insert(tableName).Rows(data).Returning(goqu.T(tableName).All())
+/* InsertIgnore inserts the rows which don't conflict with existing ones, returning only the inserted rows */
+func (builder *sqlBuilder) InsertIgnore(tableName string, data interface{}) (string, []interface{}, error) {
+	ds := builder.dialect.Insert(tableName).Prepared(true).Rows(data).OnConflict(goqu.DoNothing()).Returning(goqu.T(tableName).All())
 
-	insertSQL, _, _ := ds.ToSQL()
-	return insertSQL
+	return ds.ToSQL()
 }
 
-func (builder *sqlBuilder) Update(tableName string, id *int64, data interface{}) string {
-	ds := goqu.Update(tableName).Set(data).Where(goqu.Ex{
+func (builder *sqlBuilder) Update(tableName string, id *int64, data interface{}) (string, []interface{}, error) {
+	ds := builder.dialect.Update(tableName).Prepared(true).Set(data).Where(goqu.Ex{
 		"id": id,
 	}).Returning(goqu.T(tableName).All())
 
-	insertSQL, _, _ := ds.ToSQL()
-	return insertSQL
+	return ds.ToSQL()
 }
 
-func filtersToSql(params map[string]interface{}) exp.Ex {
-	exp := goqu.Ex{}
-	optMapping := map[string]string{
-		"prefix":   "like",
-		"contains": "contains",
-		"in":       "in",
-		"gt":       "gt",
-		"gte":      "gte",
+/* UpdateWhere updates all the records matching params (same format as filters), returning them */
+func (builder *sqlBuilder) UpdateWhere(tableName string, data interface{}, params map[string]interface{}) (string, []interface{}, error) {
+	exp, err := filtersToSql(params)
+	if err != nil {
+		return "", nil, err
 	}
 
-	for key, value := range params {
-		if key == "size" || key == "page" || key == "sort" {
-			continue
-		}
-		splits := strings.Split(key, "__")
-		attr := splits[0]
-		if len(splits) > 1 {
-			opt := splits[1]
-			exp[attr] = goqu.Op{optMapping[opt]: value}
-		} else {
-			exp[attr] = value
-		}
+	ds := builder.dialect.Update(tableName).Prepared(true).Set(data).Where(exp).Returning(goqu.T(tableName).All())
+	return ds.ToSQL()
+}
+
+/*
+Renumber sets the position of the records matching params to 1, 2, 3... in their current order (position, then id),
+so that gaps and duplicates are gone. A gapAt above 0 leaves that position free, the records from there on being shifted by one
+*/
+func (builder *sqlBuilder) Renumber(tableName string, params map[string]interface{}, gapAt int) (string, []interface{}, error) {
+	exp, err := filtersToSql(params)
+	if err != nil {
+		return "", nil, err
+	}
+
+	rowNumber := goqu.L("ROW_NUMBER() OVER (ORDER BY ?, ?)", goqu.I("position"), goqu.I("id"))
+	ordered := builder.dialect.From(tableName).Select(goqu.I("id"), rowNumber.As("rank")).Where(exp)
+	rank := goqu.I("ordered.rank")
+	var position interface{} = rank
+	if gapAt > 0 {
+		position = goqu.Case().When(rank.Lt(gapAt), rank).Else(goqu.L("? + 1", rank))
+	}
+	ds := builder.dialect.Update(tableName).Prepared(true).
+		With("ordered", ordered).
+		From("ordered").
+		Set(goqu.Record{"position": position}).
+		Where(goqu.I(tableName + ".id").Eq(goqu.I("ordered.id")))
+	return ds.ToSQL()
+}
+
+/* Lock selects the ids of the records matching params FOR UPDATE, so that concurrent transactions writing them wait for the current one */
+func (builder *sqlBuilder) Lock(tableName string, params map[string]interface{}) (string, []interface{}, error) {
+	exp, err := filtersToSql(params)
+	if err != nil {
+		return "", nil, err
+	}
+	return builder.dialect.From(tableName).Prepared(true).Select(goqu.I("id")).Where(exp).ForUpdate(goqu.Wait).ToSQL()
+}
+
+func (builder *sqlBuilder) Delete(tableName string, id *int64) (string, []interface{}, error) {
+	ds := builder.dialect.Delete(tableName).Prepared(true).Where(goqu.Ex{
+		"id": id,
+	})
+
+	return ds.ToSQL()
+}
+
+/* DeleteWhere deletes all the records matching params (same format as filters), returning them */
+func (builder *sqlBuilder) DeleteWhere(tableName string, params map[string]interface{}) (string, []interface{}, error) {
+	exp, err := filtersToSql(params)
+	if err != nil {
+		return "", nil, err
+	}
+	return builder.dialect.Delete(tableName).Prepared(true).Where(exp).Returning(goqu.T(tableName).All()).ToSQL()
+}
+
+/* SoftDelete flags the record as deleted by setting its deleted_at, the row itself is kept */
+func (builder *sqlBuilder) SoftDelete(tableName string, id *int64) (string, []interface{}, error) {
+	ds := builder.dialect.Update(tableName).Prepared(true).Set(goqu.Record{
+		"deleted_at": goqu.L("NOW()"),
+	}).Where(goqu.Ex{
+		"id":         id,
+		"deleted_at": nil,
+	}).Returning(goqu.T(tableName).All())
+
+	return ds.ToSQL()
+}
+
+/* Restore clears the deleted_at of a soft deleted record */
+func (builder *sqlBuilder) Restore(tableName string, id *int64) (string, []interface{}, error) {
+	ds := builder.dialect.Update(tableName).Prepared(true).Set(goqu.Record{
+		"deleted_at": nil,
+	}).Where(goqu.Ex{
+		"id": id,
+	}).Returning(goqu.T(tableName).All())
+
+	return ds.ToSQL()
+}
+
+/*
+PurgeDeleted hard deletes the records soft deleted before the given time, in a single statement.
+dependents (table => foreign key) lists the rows which are deleted along with them, e.g. {"dishes": "restaurant_id"}.
+referrers (table => foreign key) lists the rows which keep a record from being purged while they reference it,
+e.g. {"pages": "author_id"}: such records are skipped until their referrers are gone, instead of failing the statement
+*/
+func (builder *sqlBuilder) PurgeDeleted(tableName string, before time.Time, dependents map[string]string, referrers map[string]string) (string, []interface{}, error) {
+	conditions := []exp.Expression{goqu.Ex{
+		"deleted_at": goqu.Op{"lt": before},
+	}}
+	for _, table := range sortedKeys(referrers) {
+		referencing := builder.dialect.From(goqu.T(table).As("referrer")).Select(goqu.L("1")).Where(
+			goqu.I("referrer." + referrers[table]).Eq(goqu.I(tableName + ".id")),
+		)
+		conditions = append(conditions, goqu.L("NOT EXISTS ?", referencing))
+	}
+	purged := builder.dialect.From(tableName).Prepared(true).Select("id").Where(conditions...)
+	ds := builder.dialect.Delete(tableName).Prepared(true).With("purged", purged)
+
+	for _, table := range sortedKeys(dependents) {
+		dependent := builder.dialect.Delete(table).Where(goqu.I(dependents[table]).In(
+			builder.dialect.From("purged").Select("id"),
+		))
+		ds = ds.With("purged_"+table, dependent)
+	}
+
+	return ds.Where(goqu.I("id").In(builder.dialect.From("purged").Select("id"))).ToSQL()
+}
+
+/* sortedKeys returns the keys of a table => column map sorted, so that the generated statements are stable */
+func sortedKeys(columns map[string]string) []string {
+	keys := make([]string, 0, len(columns))
+	for key := range columns {
+		keys = append(keys, key)
 	}
-	return exp
+	sort.Strings(keys)
+	return keys
 }
@@ -0,0 +1,173 @@
+package database
+
+import (
+	"net/http"
+	"net/url"
+	"reflect"
+	"testing"
+	"time"
+
+	pagination "resturants-hub.com/m/v2/packages"
+	rest_errors "resturants-hub.com/m/v2/packages/utils"
+)
+
+func TestFilterKeyset(t *testing.T) {
+	fields := []pagination.SortField{{Attr: "price", Desc: true}, {Attr: "id"}}
+	tests := []struct {
+		name     string
+		params   url.Values
+		wantSql  string
+		wantArgs []interface{}
+	}{
+		{
+			name:     "first page",
+			params:   url.Values{"sort": {"-price,id"}, "page[after]": {""}},
+			wantSql:  `SELECT * FROM "dishes" ORDER BY "price" DESC NULLS LAST, "id" ASC NULLS LAST LIMIT $1`,
+			wantArgs: []interface{}{int64(20)},
+		},
+		{
+			name:     "after a value",
+			params:   url.Values{"sort": {"-price,id"}, "page[after]": {pagination.EncodeCursor(fields, []interface{}{10, 3})}},
+			wantSql:  `SELECT * FROM "dishes" WHERE ((("price" < $1) OR ("price" IS NULL)) OR (("price" = $2) AND (("id" > $3) OR ("id" IS NULL)))) ORDER BY "price" DESC NULLS LAST, "id" ASC NULLS LAST LIMIT $4`,
+			wantArgs: []interface{}{"10", "10", "3", int64(20)},
+		},
+		{
+			name:     "after a null",
+			params:   url.Values{"sort": {"-price,id"}, "page[after]": {pagination.EncodeCursor(fields, []interface{}{nil, 3})}},
+			wantSql:  `SELECT * FROM "dishes" WHERE (FALSE OR (("price" IS NULL) AND (("id" > $1) OR ("id" IS NULL)))) ORDER BY "price" DESC NULLS LAST, "id" ASC NULLS LAST LIMIT $2`,
+			wantArgs: []interface{}{"3", int64(20)},
+		},
+		{
+			name:     "before a null",
+			params:   url.Values{"sort": {"-price,id"}, "page[before]": {pagination.EncodeCursor(fields, []interface{}{nil, 3})}},
+			wantSql:  `SELECT * FROM (SELECT * FROM "dishes" WHERE (("price" IS NOT NULL) OR (("price" IS NULL) AND ("id" < $1))) ORDER BY "price" ASC NULLS FIRST, "id" DESC NULLS FIRST LIMIT $2) AS "page" ORDER BY "price" DESC NULLS LAST, "id" ASC NULLS LAST`,
+			wantArgs: []interface{}{"3", int64(20)},
+		},
+		{
+			name:     "before a value",
+			params:   url.Values{"sort": {"-price,id"}, "page[before]": {pagination.EncodeCursor(fields, []interface{}{10, 3})}},
+			wantSql:  `SELECT * FROM (SELECT * FROM "dishes" WHERE (("price" > $1) OR (("price" = $2) AND ("id" < $3))) ORDER BY "price" ASC NULLS FIRST, "id" DESC NULLS FIRST LIMIT $4) AS "page" ORDER BY "price" DESC NULLS LAST, "id" ASC NULLS LAST`,
+			wantArgs: []interface{}{"10", "10", "3", int64(20)},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			sql, args, err := NewSqlBuilder().Filter("dishes", test.params)
+			if err != nil {
+				t.Fatalf("Filter() error = %v", err)
+			}
+			if sql != test.wantSql {
+				t.Errorf("Filter() sql =\n%s\nwant\n%s", sql, test.wantSql)
+			}
+			if !reflect.DeepEqual(args, test.wantArgs) {
+				t.Errorf("Filter() args = %#v, want %#v", args, test.wantArgs)
+			}
+		})
+	}
+}
+
+func TestFilterKeysetRejectsCursorOfAnotherSort(t *testing.T) {
+	cursor := pagination.EncodeCursor([]pagination.SortField{{Attr: "name"}, {Attr: "id"}}, []interface{}{"pasta", 3})
+	params := url.Values{"sort": {"-price,id"}, "page[after]": {cursor}}
+
+	_, _, err := NewSqlBuilder().Filter("dishes", params)
+	restErr, ok := err.(rest_errors.RestErr)
+	if !ok || restErr.Status() != http.StatusBadRequest {
+		t.Fatalf("Filter() error = %v, want a 400", err)
+	}
+}
+
+func TestFullTextCount(t *testing.T) {
+	params := url.Values{"size": {"5"}, "name__prefix": {"pa"}}
+	sql, args, err := NewSqlBuilder().FullTextCount("dishes", "pasta", params, Scope{"deleted_at": nil})
+	if err != nil {
+		t.Fatalf("FullTextCount() error = %v", err)
+	}
+	wantSql := `SELECT COUNT(*) AS "count" FROM "dishes" WHERE (("name" LIKE $1) AND ("deleted_at" IS NULL) AND "search_vector" @@ websearch_to_tsquery('simple', $2))`
+	if sql != wantSql {
+		t.Errorf("FullTextCount() sql =\n%s\nwant\n%s", sql, wantSql)
+	}
+	if wantArgs := []interface{}{"pa%", "pasta"}; !reflect.DeepEqual(args, wantArgs) {
+		t.Errorf("FullTextCount() args = %#v, want %#v", args, wantArgs)
+	}
+}
+
+func TestRenumber(t *testing.T) {
+	params := map[string]interface{}{"restaurant_id": 1, "parent_page_id": nil, "id__neq": 4}
+	tests := []struct {
+		name     string
+		gapAt    int
+		wantSql  string
+		wantArgs []interface{}
+	}{
+		{
+			name:     "closing gaps",
+			wantSql:  `WITH ordered AS (SELECT "id", ROW_NUMBER() OVER (ORDER BY "position", "id") AS "rank" FROM "pages" WHERE (("id" != $1) AND ("parent_page_id" IS NULL) AND ("restaurant_id" = $2))) UPDATE "pages" SET "position"="ordered"."rank" FROM "ordered" WHERE ("pages"."id" = "ordered"."id")`,
+			wantArgs: []interface{}{int64(4), int64(1)},
+		},
+		{
+			name:     "leaving a position free",
+			gapAt:    2,
+			wantSql:  `WITH ordered AS (SELECT "id", ROW_NUMBER() OVER (ORDER BY "position", "id") AS "rank" FROM "pages" WHERE (("id" != $1) AND ("parent_page_id" IS NULL) AND ("restaurant_id" = $2))) UPDATE "pages" SET "position"=CASE  WHEN ("ordered"."rank" < $3) THEN "ordered"."rank" ELSE "ordered"."rank" + 1 END FROM "ordered" WHERE ("pages"."id" = "ordered"."id")`,
+			wantArgs: []interface{}{int64(4), int64(1), int64(2)},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			sql, args, err := NewSqlBuilder().Renumber("pages", params, test.gapAt)
+			if err != nil {
+				t.Fatalf("Renumber() error = %v", err)
+			}
+			if sql != test.wantSql {
+				t.Errorf("Renumber() sql =\n%s\nwant\n%s", sql, test.wantSql)
+			}
+			if !reflect.DeepEqual(args, test.wantArgs) {
+				t.Errorf("Renumber() args = %#v, want %#v", args, test.wantArgs)
+			}
+		})
+	}
+}
+
+func TestPurgeDeleted(t *testing.T) {
+	before := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name       string
+		table      string
+		dependents map[string]string
+		referrers  map[string]string
+		wantSql    string
+	}{
+		{
+			name:    "expired records",
+			table:   "dishes",
+			wantSql: `WITH purged AS (SELECT "id" FROM "dishes" WHERE ("deleted_at" < $1)) DELETE FROM "dishes" WHERE ("id" IN ((SELECT "id" FROM "purged")))`,
+		},
+		{
+			name:      "skipping records referenced by their own table",
+			table:     "pages",
+			referrers: map[string]string{"pages": "parent_page_id"},
+			wantSql:   `WITH purged AS (SELECT "id" FROM "pages" WHERE (("deleted_at" < $1) AND NOT EXISTS (SELECT 1 FROM "pages" AS "referrer" WHERE ("referrer"."parent_page_id" = "pages"."id")))) DELETE FROM "pages" WHERE ("id" IN ((SELECT "id" FROM "purged")))`,
+		},
+		{
+			name:       "purging dependents and skipping referenced records",
+			table:      "users",
+			dependents: map[string]string{"sessions": "user_id"},
+			referrers:  map[string]string{"restaurants": "manager_id", "pages": "author_id"},
+			wantSql:    `WITH purged AS (SELECT "id" FROM "users" WHERE (("deleted_at" < $1) AND NOT EXISTS (SELECT 1 FROM "pages" AS "referrer" WHERE ("referrer"."author_id" = "users"."id")) AND NOT EXISTS (SELECT 1 FROM "restaurants" AS "referrer" WHERE ("referrer"."manager_id" = "users"."id")))), purged_sessions AS (DELETE FROM "sessions" WHERE ("user_id" IN ((SELECT "id" FROM "purged")))) DELETE FROM "users" WHERE ("id" IN ((SELECT "id" FROM "purged")))`,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			sql, args, err := NewSqlBuilder().PurgeDeleted(test.table, before, test.dependents, test.referrers)
+			if err != nil {
+				t.Fatalf("PurgeDeleted() error = %v", err)
+			}
+			if sql != test.wantSql {
+				t.Errorf("PurgeDeleted() sql =\n%s\nwant\n%s", sql, test.wantSql)
+			}
+			if !reflect.DeepEqual(args, []interface{}{before}) {
+				t.Errorf("PurgeDeleted() args = %#v, want %#v", args, []interface{}{before})
+			}
+		})
+	}
+}
@@ -12,21 +12,40 @@ type PermissionsMap map[consts.Role]interface{}
 var (
 	PermissionMappings PermissionsMap = PermissionsMap{
 		consts.Admin: map[consts.ResourceType][]string{
-			consts.Restaurants: {"accessCollection", "accessMember", "create"},
-			consts.Users:       {"accessCollection", "accessMember", "create"},
-			consts.Invitations: {"accessCollection", "accessMember", "create"},
-			consts.Pages:       {"accessCollection", "accessMember", "create"},
+			consts.Restaurants:    {"accessCollection", "accessMember", "create"},
+			consts.Users:          {"accessCollection", "accessMember", "create"},
+			consts.Invitations:    {"accessCollection", "accessMember", "create"},
+			consts.Pages:          {"accessCollection", "accessMember", "create"},
+			consts.Dishes:         {"accessCollection", "accessMember", "create"},
+			consts.Tables:         {"accessCollection", "accessMember", "create"},
+			consts.Reservations:   {"accessCollection", "accessMember", "create"},
+			consts.Orders:         {"accessCollection", "accessMember"},
+			consts.Reviews:        {"accessCollection", "accessMember"},
+			consts.MenuCategories: {"accessCollection", "accessMember", "create"},
 		},
 		consts.Manager: map[consts.ResourceType][]string{
-			consts.Restaurants: {"accessMember", "create"},
-			consts.Users:       {"accessMember"},
-			consts.Invitations: {},
-			consts.Pages:       {"accessCollection", "accessMember", "create"},
+			consts.Restaurants:    {"accessMember", "create"},
+			consts.Users:          {"accessMember"},
+			consts.Invitations:    {},
+			consts.Pages:          {"accessCollection", "accessMember", "create"},
+			consts.Dishes:         {"accessCollection", "accessMember", "create"},
+			consts.Tables:         {"accessCollection", "accessMember", "create"},
+			consts.Reservations:   {"accessCollection", "accessMember", "create"},
+			consts.Orders:         {"accessCollection", "accessMember"},
+			consts.Reviews:        {"accessCollection", "accessMember"},
+			consts.MenuCategories: {"accessCollection", "accessMember", "create"},
 		},
 		consts.Public: map[consts.ResourceType][]string{
-			consts.Restaurants: {},
-			consts.Users:       {},
-			consts.Invitations: {},
+			consts.Restaurants:    {"accessCollection", "accessMember"},
+			consts.Users:          {},
+			consts.Invitations:    {},
+			consts.Pages:          {"accessCollection", "accessMember"},
+			consts.Dishes:         {"accessCollection"},
+			consts.Tables:         {},
+			consts.Reservations:   {"create"},
+			consts.Orders:         {"create"},
+			consts.Reviews:        {"accessCollection", "create"},
+			consts.MenuCategories: {"accessCollection"},
 		},
 	}
 )
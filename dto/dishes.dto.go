@@ -0,0 +1,141 @@
+package dto
+
+import (
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"github.com/lib/pq"
+	consts "resturants-hub.com/m/v2/packages/const"
+	"resturants-hub.com/m/v2/packages/types"
+	"resturants-hub.com/m/v2/serializers"
+)
+
+// DB representation of the dishes table
+type Dish struct {
+	Id             int64          `json:"id" db:"id" goqu:"skipinsert,skipupdate"`
+	RestaurantId   int64          `json:"restaurantId" db:"restaurant_id" goqu:"omitempty" validate:"required"`
+	Name           string         `json:"name" db:"name" goqu:"omitempty" validate:"required,min=3,max=50"`
+	Description    string         `json:"description" db:"description" goqu:"omitempty" validate:"required,max=5000"`
+	Price          int64          `json:"price" db:"price" goqu:"omitempty" validate:"required,gt=0"`
+	CategoryId     types.NullInt  `json:"categoryId" db:"category_id" goqu:"skipinsert,skipupdate"`
+	Tags           []string       `json:"tags" db:"-"`
+	Allergens      pq.StringArray `json:"allergens" db:"allergens" goqu:"skipinsert,skipupdate"`
+	Dietary        pq.StringArray `json:"dietary" db:"dietary" goqu:"skipinsert,skipupdate"`
+	ModifierGroups ModifierGroups `json:"modifierGroups" db:"modifier_groups" goqu:"skipinsert,skipupdate"`
+	Website        string         `json:"website" db:"website" goqu:"omitempty" validate:"max=500"`
+	EnableReviews  *bool          `json:"enableReviews" db:"enable_reviews" goqu:"omitempty"`
+	RatingAverage  float64        `json:"ratingAverage" db:"rating_average" goqu:"skipinsert,skipupdate"`
+	RatingCount    int64          `json:"ratingCount" db:"rating_count" goqu:"skipinsert,skipupdate"`
+	Published      time.Time      `json:"published" db:"published" goqu:"skipinsert,skipupdate,omitempty"`
+	CreatedAt      time.Time      `json:"createdAt" db:"created_at" goqu:"skipinsert,skipupdate,omitempty"`
+	UpdatedAt      time.Time      `json:"updatedAt" db:"updated_at" goqu:"skipinsert,skipupdate,omitempty"`
+	DeletedAt      sql.NullTime   `json:"deletedAt" db:"deleted_at" goqu:"skipupdate,omitempty"`
+	SearchVector   sql.NullString `json:"-" db:"search_vector" goqu:"skipinsert,skipupdate"`
+}
+
+// Dishes represents a slice of Dish objects
+type Dishes []Dish
+
+/* Dish found by a full text search, along with its relevance */
+type RankedDish struct {
+	Dish
+	Rank float64 `db:"rank"`
+}
+
+/* Struct for creating new Dish, its place on the menu is given through DishMenuPayload */
+type CreateDishPayload struct {
+	RestaurantId  int64  `json:"restaurantId" db:"restaurant_id" validate:"required"`
+	Name          string `json:"name" db:"name" validate:"required,min=3,max=50"`
+	Description   string `json:"description" db:"description" validate:"required,max=5000"`
+	Price         int64  `json:"price" db:"price" validate:"required,gt=0"`
+	Website       string `json:"website" db:"website" goqu:"omitempty" validate:"max=500"`
+	EnableReviews bool   `json:"enableReviews" db:"enable_reviews"`
+}
+
+type PublicDishItem struct {
+	Name           string         `json:"name" db:"name"`
+	Description    string         `json:"description" db:"description"`
+	Price          int64          `json:"price" db:"price"`
+	CategoryId     types.NullInt  `json:"categoryId" db:"category_id"`
+	Tags           []string       `json:"tags"`
+	Allergens      []string       `json:"allergens" db:"allergens"`
+	Dietary        []string       `json:"dietary" db:"dietary"`
+	ModifierGroups ModifierGroups `json:"modifierGroups" db:"modifier_groups"`
+	EnableReviews  bool           `json:"enableReviews" db:"enable_reviews"`
+	RatingAverage  float64        `json:"ratingAverage" db:"rating_average"`
+	RatingCount    int64          `json:"ratingCount" db:"rating_count"`
+}
+
+type OwnerDishListItem struct {
+	PublicDishItem
+	Website   string    `json:"website" db:"website"`
+	Published time.Time `json:"published" db:"published"`
+	CreatedAt time.Time `json:"createdAt" db:"created_at"`
+}
+
+type OwnerDishDetailItem struct {
+	OwnerDishListItem
+	UpdatedAt time.Time `json:"updatedAt" db:"updated_at"`
+}
+
+type AdminDishListItem struct {
+	OwnerDishListItem
+	RestaurantId int64        `json:"restaurantId" db:"restaurant_id"`
+	DeletedAt    sql.NullTime `json:"deletedAt" db:"deleted_at"`
+}
+
+type AdminDishDetailItem struct {
+	AdminDishListItem
+	UpdatedAt time.Time `json:"updatedAt" db:"updated_at"`
+}
+
+/* Dishes can't be moved to another restaurant: their category, tags and reviews belong to their restaurant */
+func (dish *Dish) UpdableAttributes(role consts.Role) []string {
+	switch role {
+	case consts.Admin, consts.Manager:
+		return []string{"name", "description", "price", "website", "enableReviews", "categoryId", "tags", "allergens", "dietary", "modifierGroups"}
+	default:
+		return []string{}
+	}
+}
+
+func (record *Dish) MemberFor(role consts.Role) interface{} {
+	payload, _ := json.Marshal(record)
+	switch role {
+	case consts.Admin:
+		var details AdminDishDetailItem
+		json.Unmarshal(payload, &details)
+		return serializers.MemberPayload[AdminDishDetailItem]{Id: record.Id, Type: "dishes", Attributes: details}
+	case consts.Manager:
+		var details OwnerDishDetailItem
+		json.Unmarshal(payload, &details)
+		return serializers.MemberPayload[OwnerDishDetailItem]{Id: record.Id, Type: "dishes", Attributes: details}
+	default:
+		var details PublicDishItem
+		json.Unmarshal(payload, &details)
+		return serializers.MemberPayload[PublicDishItem]{Id: record.Id, Type: "dishes", Attributes: details}
+	}
+}
+
+func (dishes Dishes) CollectionFor(role consts.Role) []interface{} {
+	result := make([]interface{}, len(dishes))
+	for index, record := range dishes {
+		payload, _ := json.Marshal(record)
+		switch role {
+		case consts.Admin:
+			var item AdminDishListItem
+			json.Unmarshal(payload, &item)
+			result[index] = serializers.MemberPayload[AdminDishListItem]{Id: record.Id, Type: "dishes", Attributes: item}
+		case consts.Manager:
+			var item OwnerDishListItem
+			json.Unmarshal(payload, &item)
+			result[index] = serializers.MemberPayload[OwnerDishListItem]{Id: record.Id, Type: "dishes", Attributes: item}
+		default:
+			var item PublicDishItem
+			json.Unmarshal(payload, &item)
+			result[index] = serializers.MemberPayload[PublicDishItem]{Id: record.Id, Type: "dishes", Attributes: item}
+		}
+	}
+	return result
+}
@@ -0,0 +1,225 @@
+package dto
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+	"golang.org/x/exp/slices"
+	rest_errors "resturants-hub.com/m/v2/packages/utils"
+)
+
+/* Allergens dishes are labelled with, the 14 allergens to be declared in the EU (kept in sync with the dishes_allergens_check constraint) */
+var Allergens = []string{
+	"gluten", "crustaceans", "eggs", "fish", "peanuts", "soybeans", "milk",
+	"nuts", "celery", "mustard", "sesame", "sulphites", "lupin", "molluscs",
+}
+
+/* Diets dishes are suitable for (kept in sync with the dishes_dietary_check constraint) */
+var DietaryLabels = []string{"vegetarian", "vegan", "halal", "kosher", "gluten_free", "lactose_free"}
+
+const (
+	/* Largest number of tags of a dish */
+	MaxDishTags = 20
+	/* Largest number of modifier groups of a dish, and of options of a group */
+	MaxModifierGroups  = 10
+	MaxModifierOptions = 20
+)
+
+/* Option of a modifier group, its price delta (in cents) is added to the price of the dish and may be negative */
+type ModifierOption struct {
+	Name       string `json:"name"`
+	PriceDelta int64  `json:"priceDelta"`
+}
+
+/* Modifier group of a dish (sizes, extras...), diners pick between minChoices and maxChoices of its options */
+type ModifierGroup struct {
+	Name       string           `json:"name"`
+	MinChoices int              `json:"minChoices"`
+	MaxChoices int              `json:"maxChoices"`
+	Options    []ModifierOption `json:"options"`
+}
+
+type ModifierGroups []ModifierGroup
+
+func (groups *ModifierGroups) Scan(src interface{}) error {
+	switch source := src.(type) {
+	case string:
+		return json.Unmarshal([]byte(source), groups)
+	case []byte:
+		return json.Unmarshal(source, groups)
+	case nil:
+		*groups = ModifierGroups{}
+		return nil
+	default:
+		return fmt.Errorf("cannot convert %T to ModifierGroups", src)
+	}
+}
+
+func (groups ModifierGroups) Value() (driver.Value, error) {
+	if groups == nil {
+		groups = ModifierGroups{}
+	}
+	return json.Marshal(groups)
+}
+
+/* Validate reports the invalid groups and options by index, along with what is wrong with them */
+func (groups ModifierGroups) Validate() rest_errors.ValidationErrs {
+	causes := rest_errors.ValidationErrs{}
+	invalid := func(cause string, details map[string]interface{}) {
+		details["error"] = cause
+		causes["modifierGroups"] = append(causes["modifierGroups"], details)
+	}
+
+	if len(groups) > MaxModifierGroups {
+		invalid("too_many_groups", map[string]interface{}{"max": MaxModifierGroups})
+		return causes
+	}
+	names := []string{}
+	for index, group := range groups {
+		switch {
+		case group.Name == "" || len(group.Name) > 50:
+			invalid("invalid_name", map[string]interface{}{"index": index, "max": 50})
+		case slices.Contains(names, group.Name):
+			invalid("duplicate_name", map[string]interface{}{"index": index, "name": group.Name})
+		}
+		names = append(names, group.Name)
+
+		if len(group.Options) == 0 || len(group.Options) > MaxModifierOptions {
+			invalid("invalid_options", map[string]interface{}{"index": index, "min": 1, "max": MaxModifierOptions})
+			continue
+		}
+		if group.MinChoices < 0 || group.MaxChoices < 1 || group.MinChoices > group.MaxChoices || group.MaxChoices > len(group.Options) {
+			invalid("invalid_choices", map[string]interface{}{"index": index, "minChoices": group.MinChoices, "maxChoices": group.MaxChoices, "options": len(group.Options)})
+		}
+		options := []string{}
+		for optionIndex, option := range group.Options {
+			switch {
+			case option.Name == "" || len(option.Name) > 50:
+				invalid("invalid_option_name", map[string]interface{}{"index": index, "option": optionIndex, "max": 50})
+			case slices.Contains(options, option.Name):
+				invalid("duplicate_option_name", map[string]interface{}{"index": index, "option": optionIndex, "name": option.Name})
+			}
+			options = append(options, option.Name)
+		}
+	}
+	return causes
+}
+
+/*
+DishMenuPayload holds the attributes placing a dish on the menu, which aren't plain columns of the dishes table:
+they are read out of the payload of dishes and only the given ones are changed.
+*/
+type DishMenuPayload struct {
+	CategoryId     *int64         `json:"categoryId"`
+	Tags           []string       `json:"tags"`
+	Allergens      []string       `json:"allergens"`
+	Dietary        []string       `json:"dietary"`
+	ModifierGroups ModifierGroups `json:"modifierGroups"`
+	/* Attributes given in the payload */
+	Given []string `json:"-"`
+}
+
+/* Attributes of the dish payloads read into DishMenuPayload */
+var DishMenuAttributes = []string{"categoryId", "tags", "allergens", "dietary", "modifierGroups"}
+
+func (menu *DishMenuPayload) Has(attribute string) bool {
+	return slices.Contains(menu.Given, attribute)
+}
+
+/* Validate checks the given attributes, labels have to be part of the vocabulary */
+func (menu *DishMenuPayload) Validate() *rest_errors.ValidationErrs {
+	causes := rest_errors.ValidationErrs{}
+	vocabulary := func(attribute string, labels []string, allowed []string) {
+		for _, label := range labels {
+			if !slices.Contains(allowed, label) {
+				causes[attribute] = append(causes[attribute], map[string]interface{}{"error": "unknown_label", "label": label, "allowed": allowed})
+			}
+		}
+	}
+	vocabulary("allergens", menu.Allergens, Allergens)
+	vocabulary("dietary", menu.Dietary, DietaryLabels)
+
+	if len(menu.Tags) > MaxDishTags {
+		causes["tags"] = append(causes["tags"], map[string]interface{}{"error": "too_many_tags", "max": MaxDishTags})
+	}
+	for index, tag := range menu.Tags {
+		if strings.TrimSpace(tag) == "" || len(tag) > 50 {
+			causes["tags"] = append(causes["tags"], map[string]interface{}{"error": "invalid_tag", "index": index, "max": 50})
+		}
+	}
+
+	for attribute, groupCauses := range menu.ModifierGroups.Validate() {
+		causes[attribute] = groupCauses
+	}
+	if len(causes) > 0 {
+		return &causes
+	}
+	return nil
+}
+
+/* Columns maps the given attributes stored on the dishes table to their new value, tags are stored apart */
+func (menu *DishMenuPayload) Columns() map[string]interface{} {
+	columns := map[string]interface{}{}
+	if menu.Has("categoryId") {
+		/* A null category takes the dish out of its category */
+		columns["category_id"] = menu.CategoryId
+	}
+	if menu.Has("allergens") {
+		columns["allergens"] = uniqueLabels(menu.Allergens)
+	}
+	if menu.Has("dietary") {
+		columns["dietary"] = uniqueLabels(menu.Dietary)
+	}
+	if menu.Has("modifierGroups") {
+		columns["modifier_groups"] = menu.ModifierGroups
+	}
+	return columns
+}
+
+/* TagNames are the given tags, trimmed and without repetitions */
+func (menu *DishMenuPayload) TagNames() []string {
+	tags := make([]string, len(menu.Tags))
+	for index, tag := range menu.Tags {
+		tags[index] = strings.TrimSpace(tag)
+	}
+	return uniqueLabels(tags)
+}
+
+/* uniqueLabels drops repeated labels, keeping the order they were given in */
+func uniqueLabels(labels []string) pq.StringArray {
+	unique := pq.StringArray{}
+	for _, label := range labels {
+		if !slices.Contains(unique, label) {
+			unique = append(unique, label)
+		}
+	}
+	return unique
+}
+
+// DB representation of the tags table, tags are shared by the dishes of a restaurant
+type Tag struct {
+	Id           int64     `json:"id" db:"id" goqu:"skipinsert,skipupdate"`
+	RestaurantId int64     `json:"restaurantId" db:"restaurant_id"`
+	Name         string    `json:"name" db:"name"`
+	CreatedAt    time.Time `json:"createdAt" db:"created_at" goqu:"skipinsert,skipupdate,omitempty"`
+}
+
+type Tags []Tag
+
+// DB representation of the dish_tags table
+type DishTag struct {
+	DishId int64 `db:"dish_id"`
+	TagId  int64 `db:"tag_id"`
+}
+
+// Row of the dish_tag_names view: a tag of a dish along with its name
+type DishTagName struct {
+	DishId       int64  `db:"dish_id"`
+	TagId        int64  `db:"tag_id"`
+	RestaurantId int64  `db:"restaurant_id"`
+	Name         string `db:"name"`
+}
@@ -0,0 +1,75 @@
+package dto
+
+import (
+	"encoding/json"
+	"time"
+
+	consts "resturants-hub.com/m/v2/packages/const"
+	"resturants-hub.com/m/v2/serializers"
+)
+
+// DB representation of the menu_categories table: a section of the menu, listed by position
+type MenuCategory struct {
+	Id           int64     `json:"id" db:"id" goqu:"skipinsert,skipupdate"`
+	RestaurantId int64     `json:"restaurantId" db:"restaurant_id" goqu:"omitempty" validate:"required"`
+	Name         string    `json:"name" db:"name" goqu:"omitempty" validate:"required,max=50"`
+	Description  string    `json:"description" db:"description" goqu:"omitempty" validate:"max=500"`
+	Position     int       `json:"position" db:"position" goqu:"omitempty"`
+	CreatedAt    time.Time `json:"createdAt" db:"created_at" goqu:"skipinsert,skipupdate,omitempty"`
+	UpdatedAt    time.Time `json:"updatedAt" db:"updated_at" goqu:"skipinsert,skipupdate,omitempty"`
+}
+
+// MenuCategories represents a slice of MenuCategory objects
+type MenuCategories []MenuCategory
+
+/* Struct for creating new MenuCategory, it comes after the other categories unless a position is given */
+type CreateMenuCategoryPayload struct {
+	RestaurantId int64  `json:"restaurantId" db:"restaurant_id" validate:"required"`
+	Name         string `json:"name" db:"name" validate:"required,max=50"`
+	Description  string `json:"description" db:"description" validate:"max=500"`
+	Position     int    `json:"position" db:"position" validate:"gte=0"`
+}
+
+type PublicMenuCategoryItem struct {
+	Name        string `json:"name" db:"name"`
+	Description string `json:"description" db:"description"`
+	Position    int    `json:"position" db:"position"`
+}
+
+type MenuCategoryItem struct {
+	PublicMenuCategoryItem
+	RestaurantId int64     `json:"restaurantId" db:"restaurant_id"`
+	CreatedAt    time.Time `json:"createdAt" db:"created_at"`
+	UpdatedAt    time.Time `json:"updatedAt" db:"updated_at"`
+}
+
+func (category *MenuCategory) UpdableAttributes(role consts.Role) []string {
+	switch role {
+	case consts.Admin, consts.Manager:
+		return []string{"name", "description", "position"}
+	default:
+		return []string{}
+	}
+}
+
+func (record *MenuCategory) MemberFor(role consts.Role) interface{} {
+	payload, _ := json.Marshal(record)
+	switch role {
+	case consts.Admin, consts.Manager:
+		var details MenuCategoryItem
+		json.Unmarshal(payload, &details)
+		return serializers.MemberPayload[MenuCategoryItem]{Id: record.Id, Type: "menuCategories", Attributes: details}
+	default:
+		var details PublicMenuCategoryItem
+		json.Unmarshal(payload, &details)
+		return serializers.MemberPayload[PublicMenuCategoryItem]{Id: record.Id, Type: "menuCategories", Attributes: details}
+	}
+}
+
+func (categories MenuCategories) CollectionFor(role consts.Role) []interface{} {
+	result := make([]interface{}, len(categories))
+	for index, record := range categories {
+		result[index] = record.MemberFor(role)
+	}
+	return result
+}
@@ -0,0 +1,131 @@
+package dto
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestModifierGroupsValidate(t *testing.T) {
+	options := func(names ...string) []ModifierOption {
+		result := []ModifierOption{}
+		for _, name := range names {
+			result = append(result, ModifierOption{Name: name})
+		}
+		return result
+	}
+	tooManyOptions := make([]string, MaxModifierOptions+1)
+	for index := range tooManyOptions {
+		tooManyOptions[index] = strings.Repeat("o", index+1)
+	}
+	tooManyGroups := make(ModifierGroups, MaxModifierGroups+1)
+	for index := range tooManyGroups {
+		tooManyGroups[index] = ModifierGroup{Name: strings.Repeat("g", index+1), MaxChoices: 1, Options: options("a")}
+	}
+
+	tests := []struct {
+		name       string
+		groups     ModifierGroups
+		wantErrors []string
+	}{
+		{name: "none", groups: ModifierGroups{}},
+		{
+			name: "valid",
+			groups: ModifierGroups{
+				{Name: "Size", MinChoices: 1, MaxChoices: 1, Options: []ModifierOption{{"Small", -200}, {"Large", 300}}},
+				{Name: "Extras", MinChoices: 0, MaxChoices: 2, Options: options("Cheese", "Olives")},
+			},
+		},
+		{name: "too many groups", groups: tooManyGroups, wantErrors: []string{"too_many_groups"}},
+		{name: "missing name", groups: ModifierGroups{{MaxChoices: 1, Options: options("a")}}, wantErrors: []string{"invalid_name"}},
+		{name: "name too long", groups: ModifierGroups{{Name: strings.Repeat("x", 51), MaxChoices: 1, Options: options("a")}}, wantErrors: []string{"invalid_name"}},
+		{
+			name:       "duplicate name",
+			groups:     ModifierGroups{{Name: "Size", MaxChoices: 1, Options: options("a")}, {Name: "Size", MaxChoices: 1, Options: options("b")}},
+			wantErrors: []string{"duplicate_name"},
+		},
+		{name: "no options", groups: ModifierGroups{{Name: "Size", MaxChoices: 1}}, wantErrors: []string{"invalid_options"}},
+		{name: "too many options", groups: ModifierGroups{{Name: "Size", MaxChoices: 1, Options: options(tooManyOptions...)}}, wantErrors: []string{"invalid_options"}},
+		{name: "no choice", groups: ModifierGroups{{Name: "Size", MaxChoices: 0, Options: options("a")}}, wantErrors: []string{"invalid_choices"}},
+		{name: "min above max", groups: ModifierGroups{{Name: "Size", MinChoices: 2, MaxChoices: 1, Options: options("a", "b")}}, wantErrors: []string{"invalid_choices"}},
+		{name: "max above options", groups: ModifierGroups{{Name: "Size", MaxChoices: 3, Options: options("a", "b")}}, wantErrors: []string{"invalid_choices"}},
+		{name: "negative min", groups: ModifierGroups{{Name: "Size", MinChoices: -1, MaxChoices: 1, Options: options("a")}}, wantErrors: []string{"invalid_choices"}},
+		{name: "missing option name", groups: ModifierGroups{{Name: "Size", MaxChoices: 1, Options: options("")}}, wantErrors: []string{"invalid_option_name"}},
+		{
+			name:       "duplicate option name",
+			groups:     ModifierGroups{{Name: "Size", MaxChoices: 1, Options: options("a", "a")}},
+			wantErrors: []string{"duplicate_option_name"},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			causes := test.groups.Validate()
+			errors := []string{}
+			for _, cause := range causes["modifierGroups"] {
+				errors = append(errors, cause.(map[string]interface{})["error"].(string))
+			}
+			if strings.Join(errors, ",") != strings.Join(test.wantErrors, ",") {
+				t.Errorf("Validate() errors = %v, want %v", errors, test.wantErrors)
+			}
+		})
+	}
+}
+
+func TestDishMenuPayloadValidate(t *testing.T) {
+	tooManyTags := make([]string, MaxDishTags+1)
+	for index := range tooManyTags {
+		tooManyTags[index] = strings.Repeat("t", index+1)
+	}
+
+	tests := []struct {
+		name       string
+		menu       DishMenuPayload
+		wantErrors map[string][]string
+	}{
+		{name: "empty", menu: DishMenuPayload{}},
+		{
+			name: "valid",
+			menu: DishMenuPayload{
+				Tags:           []string{"spicy", "house special"},
+				Allergens:      []string{"gluten", "milk"},
+				Dietary:        []string{"vegetarian"},
+				ModifierGroups: ModifierGroups{{Name: "Size", MinChoices: 1, MaxChoices: 1, Options: []ModifierOption{{"Small", 0}}}},
+			},
+		},
+		{
+			name:       "unknown labels",
+			menu:       DishMenuPayload{Allergens: []string{"gluten", "chocolate"}, Dietary: []string{"paleo"}},
+			wantErrors: map[string][]string{"allergens": {"unknown_label"}, "dietary": {"unknown_label"}},
+		},
+		{name: "too many tags", menu: DishMenuPayload{Tags: tooManyTags}, wantErrors: map[string][]string{"tags": {"too_many_tags"}}},
+		{name: "blank tag", menu: DishMenuPayload{Tags: []string{"spicy", " "}}, wantErrors: map[string][]string{"tags": {"invalid_tag"}}},
+		{name: "tag too long", menu: DishMenuPayload{Tags: []string{strings.Repeat("t", 51)}}, wantErrors: map[string][]string{"tags": {"invalid_tag"}}},
+		{
+			name:       "invalid modifier groups",
+			menu:       DishMenuPayload{ModifierGroups: ModifierGroups{{Name: "Size", MaxChoices: 1}}},
+			wantErrors: map[string][]string{"modifierGroups": {"invalid_options"}},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			causes := test.menu.Validate()
+			if test.wantErrors == nil {
+				if causes != nil {
+					t.Fatalf("Validate() = %v, want no causes", *causes)
+				}
+				return
+			}
+			if causes == nil || len(*causes) != len(test.wantErrors) {
+				t.Fatalf("Validate() = %v, want causes for %v", causes, test.wantErrors)
+			}
+			for attribute, wantErrors := range test.wantErrors {
+				errors := []string{}
+				for _, cause := range (*causes)[attribute] {
+					errors = append(errors, cause.(map[string]interface{})["error"].(string))
+				}
+				if strings.Join(errors, ",") != strings.Join(wantErrors, ",") {
+					t.Errorf("Validate() %s errors = %v, want %v", attribute, errors, wantErrors)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,23 @@
+package dto
+
+import (
+	"time"
+
+	"resturants-hub.com/m/v2/packages/types"
+)
+
+// DB representation of the oauth_states table: a login in progress, from the redirect to the provider until its callback
+type OAuthState struct {
+	State    string `json:"state" db:"state"`
+	Provider string `json:"provider" db:"provider"`
+	Verifier string `json:"verifier" db:"verifier"`
+	Nonce    string `json:"nonce" db:"nonce"`
+	/* User linking the provider to their account, null for logins */
+	UserId    types.NullInt `json:"userId" db:"user_id"`
+	ExpiresAt time.Time     `json:"expiresAt" db:"expires_at"`
+	CreatedAt time.Time     `json:"createdAt" db:"created_at" goqu:"skipinsert,omitempty"`
+}
+
+func (state *OAuthState) IsExpired() bool {
+	return !state.ExpiresAt.After(time.Now())
+}
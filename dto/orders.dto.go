@@ -0,0 +1,300 @@
+package dto
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"golang.org/x/exp/slices"
+	consts "resturants-hub.com/m/v2/packages/const"
+	"resturants-hub.com/m/v2/packages/types"
+	rest_errors "resturants-hub.com/m/v2/packages/utils"
+	"resturants-hub.com/m/v2/serializers"
+)
+
+/* States of a takeaway order */
+const (
+	OrderPlaced    = "placed"
+	OrderAccepted  = "accepted"
+	OrderPreparing = "preparing"
+	OrderReady     = "ready"
+	OrderCompleted = "completed"
+	OrderCancelled = "cancelled"
+)
+
+/* Largest number of items of an order */
+const MaxOrderItems = 50
+
+/* Event of the order lifecycle, allowed from some states only */
+type OrderTransition struct {
+	From []string
+	To   string
+}
+
+/*
+OrderTransitions are the events of an order: the restaurant accepts placed orders, prepares them,
+tells when they are ready and completes them once picked up. Orders can be cancelled until they are ready.
+*/
+var OrderTransitions = map[string]OrderTransition{
+	"accept":   {From: []string{OrderPlaced}, To: OrderAccepted},
+	"prepare":  {From: []string{OrderAccepted}, To: OrderPreparing},
+	"ready":    {From: []string{OrderPreparing}, To: OrderReady},
+	"complete": {From: []string{OrderReady}, To: OrderCompleted},
+	"cancel":   {From: []string{OrderPlaced, OrderAccepted, OrderPreparing}, To: OrderCancelled},
+}
+
+// DB representation of the orders table, amounts are in cents
+type Order struct {
+	Id            int64          `json:"id" db:"id" goqu:"skipinsert,skipupdate"`
+	RestaurantId  int64          `json:"restaurantId" db:"restaurant_id" goqu:"omitempty"`
+	Status        string         `json:"status" db:"status" goqu:"omitempty"`
+	CustomerName  string         `json:"customerName" db:"customer_name" goqu:"omitempty"`
+	CustomerEmail string         `json:"customerEmail" db:"customer_email" goqu:"omitempty"`
+	CustomerPhone string         `json:"customerPhone" db:"customer_phone" goqu:"omitempty"`
+	Notes         string         `json:"notes" db:"notes" goqu:"omitempty"`
+	PickupAt      types.NullTime `json:"pickupAt" db:"pickup_at" goqu:"skipupdate"`
+	Total         int64          `json:"total" db:"total" goqu:"skipupdate"`
+	CreatedAt     time.Time      `json:"createdAt" db:"created_at" goqu:"skipinsert,skipupdate,omitempty"`
+	UpdatedAt     time.Time      `json:"updatedAt" db:"updated_at" goqu:"skipinsert,skipupdate,omitempty"`
+	Items         OrderItems     `json:"items" db:"-"`
+}
+
+// Orders represents a slice of Order objects
+type Orders []Order
+
+// DB representation of the order_items table: a dish of an order, with its name, options and price when it was ordered
+type OrderItem struct {
+	Id        int64            `json:"id" db:"id" goqu:"skipinsert"`
+	OrderId   int64            `json:"orderId" db:"order_id"`
+	DishId    types.NullInt    `json:"dishId" db:"dish_id"`
+	Name      string           `json:"name" db:"name"`
+	Options   OrderItemOptions `json:"options" db:"options"`
+	UnitPrice int64            `json:"unitPrice" db:"unit_price"`
+	Quantity  int              `json:"quantity" db:"quantity"`
+	Total     int64            `json:"total" db:"total" goqu:"skipinsert"`
+	Notes     string           `json:"notes" db:"notes"`
+	CreatedAt time.Time        `json:"createdAt" db:"created_at" goqu:"skipinsert"`
+}
+
+// OrderItems represents a slice of OrderItem objects
+type OrderItems []OrderItem
+
+/* Modifier option chosen for an item, its price delta is copied like the price of the dish */
+type OrderItemOption struct {
+	Group      string `json:"group"`
+	Option     string `json:"option"`
+	PriceDelta int64  `json:"priceDelta"`
+}
+
+type OrderItemOptions []OrderItemOption
+
+func (options *OrderItemOptions) Scan(src interface{}) error {
+	switch source := src.(type) {
+	case string:
+		return json.Unmarshal([]byte(source), options)
+	case []byte:
+		return json.Unmarshal(source, options)
+	case nil:
+		*options = OrderItemOptions{}
+		return nil
+	default:
+		return fmt.Errorf("cannot convert %T to OrderItemOptions", src)
+	}
+}
+
+func (options OrderItemOptions) Value() (driver.Value, error) {
+	if options == nil {
+		options = OrderItemOptions{}
+	}
+	return json.Marshal(options)
+}
+
+/* Struct for placing new Order, its total and items are computed from the menu */
+type CreateOrderPayload struct {
+	RestaurantId  int64                    `json:"-" db:"restaurant_id" validate:"required"`
+	Status        string                   `json:"-" db:"status"`
+	CustomerName  string                   `json:"customerName" db:"customer_name" validate:"required,max=100"`
+	CustomerEmail string                   `json:"customerEmail" db:"customer_email" validate:"required,email,max=255"`
+	CustomerPhone string                   `json:"customerPhone" db:"customer_phone" validate:"max=50"`
+	Notes         string                   `json:"notes" db:"notes" validate:"max=1000"`
+	PickupAt      types.NullTime           `json:"pickupAt" db:"pickup_at"`
+	Total         int64                    `json:"-" db:"total"`
+	Items         []CreateOrderItemPayload `json:"items" db:"items" goqu:"skipinsert" validate:"dive"`
+}
+
+/* A dish of the cart along with the options chosen in its modifier groups, its price is always read from the menu */
+type CreateOrderItemPayload struct {
+	DishId   int64                   `json:"dishId" validate:"required"`
+	Quantity int                     `json:"quantity" validate:"required,gt=0,lte=100"`
+	Options  []SelectedOptionPayload `json:"options" validate:"dive"`
+	Notes    string                  `json:"notes" validate:"max=500"`
+}
+
+/* Option chosen in a modifier group of the dish, both are referred to by name */
+type SelectedOptionPayload struct {
+	Group  string `json:"group" validate:"required"`
+	Option string `json:"option" validate:"required"`
+}
+
+/* Struct for pricing a cart before placing the order */
+type CartPayload struct {
+	Items []CreateOrderItemPayload `json:"items" validate:"dive"`
+}
+
+/* Cart priced from the current menu, before the order is placed */
+type Cart struct {
+	Items OrderItems `json:"items"`
+	Total int64      `json:"total"`
+}
+
+type OrderListItem struct {
+	RestaurantId  int64          `json:"restaurantId" db:"restaurant_id"`
+	Status        string         `json:"status" db:"status"`
+	CustomerName  string         `json:"customerName" db:"customer_name"`
+	CustomerEmail string         `json:"customerEmail" db:"customer_email"`
+	CustomerPhone string         `json:"customerPhone" db:"customer_phone"`
+	PickupAt      types.NullTime `json:"pickupAt" db:"pickup_at"`
+	Total         int64          `json:"total" db:"total"`
+	CreatedAt     time.Time      `json:"createdAt" db:"created_at"`
+}
+
+type OrderDetailItem struct {
+	OrderListItem
+	Notes     string     `json:"notes" db:"notes"`
+	Items     OrderItems `json:"items"`
+	UpdatedAt time.Time  `json:"updatedAt" db:"updated_at"`
+}
+
+/* Order as confirmed to the diner who placed it */
+type PublicOrderItem struct {
+	Status   string         `json:"status" db:"status"`
+	PickupAt types.NullTime `json:"pickupAt" db:"pickup_at"`
+	Total    int64          `json:"total" db:"total"`
+	Items    OrderItems     `json:"items"`
+}
+
+/*
+PriceItems prices the items of a cart from the dishes of the menu they refer to, adding the price deltas of the chosen options.
+Items referring to dishes which aren't on the menu, or whose options don't fit the modifier groups of the dish, are reported by index.
+*/
+func PriceItems(dishes Dishes, items []CreateOrderItemPayload) (OrderItems, int64, *rest_errors.ValidationErrs) {
+	menu := make(map[int64]Dish, len(dishes))
+	for _, dish := range dishes {
+		menu[dish.Id] = dish
+	}
+
+	causes := rest_errors.ValidationErrs{}
+	priced := make(OrderItems, 0, len(items))
+	var total int64
+	for index, item := range items {
+		dish, exists := menu[item.DishId]
+		if !exists {
+			causes["items"] = append(causes["items"], map[string]interface{}{"error": "dish_not_found", "index": index, "dishId": item.DishId})
+			continue
+		}
+		options, optionCauses := selectOptions(dish.ModifierGroups, item.Options)
+		if len(optionCauses) > 0 {
+			for _, cause := range optionCauses {
+				cause["index"] = index
+				causes["items"] = append(causes["items"], cause)
+			}
+			continue
+		}
+		unitPrice := dish.Price
+		for _, option := range options {
+			unitPrice += option.PriceDelta
+		}
+		if unitPrice < 0 {
+			causes["items"] = append(causes["items"], map[string]interface{}{"error": "negative_price", "index": index, "unitPrice": unitPrice})
+			continue
+		}
+		orderItem := OrderItem{
+			DishId:    types.NullInt{NullInt64: sql.NullInt64{Int64: dish.Id, Valid: true}},
+			Name:      dish.Name,
+			Options:   options,
+			UnitPrice: unitPrice,
+			Quantity:  item.Quantity,
+			Total:     unitPrice * int64(item.Quantity),
+			Notes:     item.Notes,
+		}
+		priced = append(priced, orderItem)
+		total += orderItem.Total
+	}
+	if len(causes) > 0 {
+		return nil, 0, &causes
+	}
+	return priced, total, nil
+}
+
+/*
+selectOptions resolves the options chosen for an item among the modifier groups of its dish, in the order of the groups.
+Every group must get between minChoices and maxChoices options, groups with a minChoices above 0 being required.
+*/
+func selectOptions(groups ModifierGroups, selected []SelectedOptionPayload) (OrderItemOptions, []map[string]interface{}) {
+	causes := []map[string]interface{}{}
+	chosen := map[string][]string{}
+	for _, selection := range selected {
+		group := slices.IndexFunc(groups, func(group ModifierGroup) bool { return group.Name == selection.Group })
+		switch {
+		case group < 0:
+			causes = append(causes, map[string]interface{}{"error": "modifier_group_not_found", "group": selection.Group})
+		case !slices.ContainsFunc(groups[group].Options, func(option ModifierOption) bool { return option.Name == selection.Option }):
+			causes = append(causes, map[string]interface{}{"error": "modifier_option_not_found", "group": selection.Group, "option": selection.Option})
+		case slices.Contains(chosen[selection.Group], selection.Option):
+			causes = append(causes, map[string]interface{}{"error": "duplicate_option", "group": selection.Group, "option": selection.Option})
+		default:
+			chosen[selection.Group] = append(chosen[selection.Group], selection.Option)
+		}
+	}
+
+	options := OrderItemOptions{}
+	for _, group := range groups {
+		count := len(chosen[group.Name])
+		if count < group.MinChoices || count > group.MaxChoices {
+			causes = append(causes, map[string]interface{}{"error": "invalid_choices", "group": group.Name, "minChoices": group.MinChoices, "maxChoices": group.MaxChoices, "chosen": count})
+			continue
+		}
+		for _, option := range group.Options {
+			if slices.Contains(chosen[group.Name], option.Name) {
+				options = append(options, OrderItemOption{Group: group.Name, Option: option.Name, PriceDelta: option.PriceDelta})
+			}
+		}
+	}
+	return options, causes
+}
+
+/* Transition returns the transition of event when it applies to the current state of the order */
+func (order *Order) Transition(event string) (OrderTransition, bool) {
+	transition, exists := OrderTransitions[event]
+	if !exists || !slices.Contains(transition.From, order.Status) {
+		return OrderTransition{}, false
+	}
+	return transition, true
+}
+
+func (record *Order) MemberFor(role consts.Role) interface{} {
+	payload, _ := json.Marshal(record)
+	switch role {
+	case consts.Admin, consts.Manager:
+		var details OrderDetailItem
+		json.Unmarshal(payload, &details)
+		return serializers.MemberPayload[OrderDetailItem]{Id: record.Id, Type: "orders", Attributes: details}
+	default:
+		var details PublicOrderItem
+		json.Unmarshal(payload, &details)
+		return serializers.MemberPayload[PublicOrderItem]{Id: record.Id, Type: "orders", Attributes: details}
+	}
+}
+
+func (orders Orders) CollectionFor(role consts.Role) []interface{} {
+	result := make([]interface{}, len(orders))
+	for index, record := range orders {
+		payload, _ := json.Marshal(record)
+		var item OrderListItem
+		json.Unmarshal(payload, &item)
+		result[index] = serializers.MemberPayload[OrderListItem]{Id: record.Id, Type: "orders", Attributes: item}
+	}
+	return result
+}
@@ -0,0 +1,169 @@
+package dto
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestPriceItems(t *testing.T) {
+	menu := Dishes{
+		{Id: 1, Name: "Margherita", Price: 900},
+		{Id: 2, Name: "Tiramisu", Price: 550},
+	}
+	tests := []struct {
+		name       string
+		items      []CreateOrderItemPayload
+		wantItems  [][3]int64
+		wantTotal  int64
+		wantCauses []interface{}
+	}{
+		{
+			name:      "single item",
+			items:     []CreateOrderItemPayload{{DishId: 1, Quantity: 1}},
+			wantItems: [][3]int64{{900, 1, 900}},
+			wantTotal: 900,
+		},
+		{
+			name:      "quantities",
+			items:     []CreateOrderItemPayload{{DishId: 1, Quantity: 2}, {DishId: 2, Quantity: 3}},
+			wantItems: [][3]int64{{900, 2, 1800}, {550, 3, 1650}},
+			wantTotal: 3450,
+		},
+		{
+			name:      "same dish twice",
+			items:     []CreateOrderItemPayload{{DishId: 1, Quantity: 1}, {DishId: 1, Quantity: 1, Notes: "no basil"}},
+			wantItems: [][3]int64{{900, 1, 900}, {900, 1, 900}},
+			wantTotal: 1800,
+		},
+		{
+			name:      "empty cart",
+			items:     []CreateOrderItemPayload{},
+			wantItems: [][3]int64{},
+		},
+		{
+			name:  "dishes off the menu",
+			items: []CreateOrderItemPayload{{DishId: 1, Quantity: 1}, {DishId: 3, Quantity: 1}, {DishId: 4, Quantity: 2}},
+			wantCauses: []interface{}{
+				map[string]interface{}{"error": "dish_not_found", "index": 1, "dishId": int64(3)},
+				map[string]interface{}{"error": "dish_not_found", "index": 2, "dishId": int64(4)},
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			items, total, causes := PriceItems(menu, test.items)
+			if test.wantCauses != nil {
+				if causes == nil || !reflect.DeepEqual((*causes)["items"], test.wantCauses) {
+					t.Fatalf("PriceItems() causes = %v, want %v", causes, test.wantCauses)
+				}
+				return
+			}
+			if causes != nil {
+				t.Fatalf("PriceItems() causes = %v", *causes)
+			}
+			if total != test.wantTotal {
+				t.Errorf("PriceItems() total = %d, want %d", total, test.wantTotal)
+			}
+			got := [][3]int64{}
+			for index, item := range items {
+				got = append(got, [3]int64{item.UnitPrice, int64(item.Quantity), item.Total})
+				if dish := test.items[index]; item.DishId.Int64 != dish.DishId || item.Notes != dish.Notes {
+					t.Errorf("PriceItems() item %d = %+v, want dish %d", index, item, dish.DishId)
+				}
+			}
+			if !reflect.DeepEqual(got, test.wantItems) {
+				t.Errorf("PriceItems() items (unit price, quantity, total) = %v, want %v", got, test.wantItems)
+			}
+		})
+	}
+}
+
+func TestPriceItemsWithOptions(t *testing.T) {
+	pizza := Dish{Id: 1, Name: "Pizza", Price: 900, ModifierGroups: ModifierGroups{
+		{Name: "Size", MinChoices: 1, MaxChoices: 1, Options: []ModifierOption{{"Small", -200}, {"Medium", 0}, {"Large", 300}}},
+		{Name: "Extras", MinChoices: 0, MaxChoices: 2, Options: []ModifierOption{{"Cheese", 150}, {"Olives", 100}, {"Ham", 200}}},
+	}}
+	water := Dish{Id: 2, Name: "Water", Price: 100, ModifierGroups: ModifierGroups{
+		{Name: "Discount", MinChoices: 0, MaxChoices: 1, Options: []ModifierOption{{"Staff", -500}}},
+	}}
+	menu := Dishes{pizza, water}
+
+	tests := []struct {
+		name          string
+		item          CreateOrderItemPayload
+		wantOptions   OrderItemOptions
+		wantUnitPrice int64
+		wantTotal     int64
+		wantErrors    []string
+	}{
+		{
+			name:          "required option",
+			item:          CreateOrderItemPayload{DishId: 1, Quantity: 2, Options: []SelectedOptionPayload{{"Size", "Large"}}},
+			wantOptions:   OrderItemOptions{{"Size", "Large", 300}},
+			wantUnitPrice: 1200,
+			wantTotal:     2400,
+		},
+		{
+			name:          "options in the order of the menu",
+			item:          CreateOrderItemPayload{DishId: 1, Quantity: 1, Options: []SelectedOptionPayload{{"Extras", "Olives"}, {"Size", "Small"}, {"Extras", "Cheese"}}},
+			wantOptions:   OrderItemOptions{{"Size", "Small", -200}, {"Extras", "Cheese", 150}, {"Extras", "Olives", 100}},
+			wantUnitPrice: 950,
+			wantTotal:     950,
+		},
+		{
+			name:          "optional group left out",
+			item:          CreateOrderItemPayload{DishId: 2, Quantity: 3},
+			wantOptions:   OrderItemOptions{},
+			wantUnitPrice: 100,
+			wantTotal:     300,
+		},
+		{name: "required group left out", item: CreateOrderItemPayload{DishId: 1, Quantity: 1}, wantErrors: []string{"invalid_choices"}},
+		{
+			name:       "too many options",
+			item:       CreateOrderItemPayload{DishId: 1, Quantity: 1, Options: []SelectedOptionPayload{{"Size", "Small"}, {"Size", "Large"}}},
+			wantErrors: []string{"invalid_choices"},
+		},
+		{
+			name:       "same option twice",
+			item:       CreateOrderItemPayload{DishId: 1, Quantity: 1, Options: []SelectedOptionPayload{{"Size", "Small"}, {"Extras", "Ham"}, {"Extras", "Ham"}}},
+			wantErrors: []string{"duplicate_option"},
+		},
+		{
+			name:       "unknown group and option",
+			item:       CreateOrderItemPayload{DishId: 1, Quantity: 1, Options: []SelectedOptionPayload{{"Size", "Medium"}, {"Crust", "Thin"}, {"Extras", "Pineapple"}}},
+			wantErrors: []string{"modifier_group_not_found", "modifier_option_not_found"},
+		},
+		{
+			name:       "negative price",
+			item:       CreateOrderItemPayload{DishId: 2, Quantity: 1, Options: []SelectedOptionPayload{{"Discount", "Staff"}}},
+			wantErrors: []string{"negative_price"},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			items, total, causes := PriceItems(menu, []CreateOrderItemPayload{test.item})
+			if test.wantErrors != nil {
+				if causes == nil {
+					t.Fatalf("PriceItems() = %v, want errors %v", items, test.wantErrors)
+				}
+				errors := []string{}
+				for _, cause := range (*causes)["items"] {
+					errors = append(errors, cause.(map[string]interface{})["error"].(string))
+				}
+				if !reflect.DeepEqual(errors, test.wantErrors) {
+					t.Errorf("PriceItems() errors = %v, want %v", errors, test.wantErrors)
+				}
+				return
+			}
+			if causes != nil {
+				t.Fatalf("PriceItems() causes = %v", *causes)
+			}
+			if !reflect.DeepEqual(items[0].Options, test.wantOptions) {
+				t.Errorf("PriceItems() options = %v, want %v", items[0].Options, test.wantOptions)
+			}
+			if items[0].UnitPrice != test.wantUnitPrice || total != test.wantTotal {
+				t.Errorf("PriceItems() unit price = %d, total = %d, want %d, %d", items[0].UnitPrice, total, test.wantUnitPrice, test.wantTotal)
+			}
+		})
+	}
+}
@@ -0,0 +1,88 @@
+package dto
+
+import (
+	"encoding/json"
+	"time"
+
+	"resturants-hub.com/m/v2/packages/diff"
+	"resturants-hub.com/m/v2/packages/markup"
+	"resturants-hub.com/m/v2/serializers"
+)
+
+// DB representation of the page_revisions table: a saved version of the content of a page
+type PageRevision struct {
+	Id        int64     `json:"id" db:"id"`
+	PageId    int64     `json:"pageId" db:"page_id"`
+	Number    int       `json:"number" db:"number"`
+	Title     string    `json:"title" db:"title"`
+	Excerpt   string    `json:"excerpt" db:"excerpt"`
+	Body      string    `json:"body" db:"body"`
+	Format    string    `json:"format" db:"format"`
+	CreatedAt time.Time `json:"createdAt" db:"created_at"`
+}
+
+// PageRevisions represents a slice of PageRevision objects
+type PageRevisions []PageRevision
+
+type PageRevisionListItem struct {
+	PageId    int64     `json:"pageId" db:"page_id"`
+	Number    int       `json:"number" db:"number"`
+	Title     string    `json:"title" db:"title"`
+	Excerpt   string    `json:"excerpt" db:"excerpt"`
+	CreatedAt time.Time `json:"createdAt" db:"created_at"`
+}
+
+type PageRevisionDetailItem struct {
+	PageRevisionListItem
+	Body     string `json:"body" db:"body"`
+	Format   string `json:"format" db:"format"`
+	BodyHtml string `json:"bodyHtml"`
+}
+
+/* Changes of an attribute between two revisions, line by line */
+type FieldDiff struct {
+	Changed bool        `json:"changed"`
+	From    string      `json:"from"`
+	To      string      `json:"to"`
+	Lines   []diff.Line `json:"lines,omitempty"`
+}
+
+/* Diff compares the content of the revision with a later one, field by field */
+func (record *PageRevision) Diff(to *PageRevision) map[string]FieldDiff {
+	fields := map[string][2]string{
+		"title":   {record.Title, to.Title},
+		"excerpt": {record.Excerpt, to.Excerpt},
+		"body":    {record.Body, to.Body},
+		"format":  {record.Format, to.Format},
+	}
+
+	result := make(map[string]FieldDiff, len(fields))
+	for name, values := range fields {
+		field := FieldDiff{Changed: values[0] != values[1], From: values[0], To: values[1]}
+		if field.Changed {
+			field.Lines = diff.Lines(values[0], values[1])
+		}
+		result[name] = field
+	}
+	return result
+}
+
+/* Revisions are only exposed to the users allowed to access the page, who all get the same attributes */
+func (record *PageRevision) MemberFor() interface{} {
+	payload, _ := json.Marshal(record)
+	var details PageRevisionDetailItem
+	json.Unmarshal(payload, &details)
+	details.BodyHtml = markup.Render(record.Format, record.Body)
+	return serializers.MemberPayload[PageRevisionDetailItem]{Id: record.Id, Type: "pageRevisions", Attributes: details}
+}
+
+func (revisions PageRevisions) CollectionFor() []interface{} {
+	result := make([]interface{}, len(revisions))
+	for index, record := range revisions {
+		payload, _ := json.Marshal(record)
+		var item PageRevisionListItem
+		json.Unmarshal(payload, &item)
+		result[index] = serializers.MemberPayload[PageRevisionListItem]{Id: record.Id, Type: "pageRevisions", Attributes: item}
+	}
+	return result
+}
@@ -3,42 +3,105 @@ package dto
 import (
 	"database/sql"
 	"encoding/json"
+	"sort"
 	"time"
 
+	"golang.org/x/exp/slices"
 	consts "resturants-hub.com/m/v2/packages/const"
+	"resturants-hub.com/m/v2/packages/markup"
 	"resturants-hub.com/m/v2/packages/types"
 	"resturants-hub.com/m/v2/serializers"
 )
 
 // DB representation of the page table
 type Page struct {
-	Id           int64         `json:"id" db:"id" goqu:"skipinsert,skipupdate"`
-	Title        string        `json:"title" db:"title" goqu:"omitempty" validate:"required,min=3,max=50"`
-	Slug         string        `json:"slug" db:"slug" validate:"required,min=3,max=50"`
-	Excerpt      string        `json:"excerpt" db:"excerpt" goqu:"omitempty" validate:"min=10,max=2000"`
-	Body         string        `json:"body" db:"body" goqu:"omitempty" validate:"required,min=100,"`
-	Visibility   string        `json:"visibility" db:"visibility" goqu:"omitempty"`
-	AuthorId     int64         `json:"authorId" db:"author_id" goqu:"omitempty" validate:"required"`
-	RestaurantId types.NullInt `json:"restaurantId" db:"restaurant_id" goqu:"omitempty" validate:"required"`
-	ParentPageId types.NullInt `json:"parentPageId" db:"parent_page_id" goqu:"omitempty"`
-	CreatedAt    time.Time     `json:"createdAt" db:"created_at" goqu:"skipinsert,skipupdate,omitempty"`
-	UpdatedAt    time.Time     `json:"updatedAt" db:"updated_at" goqu:"skipinsert,skipupdate,omitempty"`
-	DeletedAt    sql.NullTime  `json:"deletedAt" db:"deleted_at" goqu:"skipupdate,omitempty"`
+	Id           int64          `json:"id" db:"id" goqu:"skipinsert,skipupdate"`
+	Title        string         `json:"title" db:"title" goqu:"omitempty" validate:"required,min=3,max=50"`
+	Slug         string         `json:"slug" db:"slug" goqu:"omitempty" validate:"required,min=3,max=50"`
+	Excerpt      string         `json:"excerpt" db:"excerpt" goqu:"omitempty" validate:"min=10,max=2000"`
+	Body         string         `json:"body" db:"body" goqu:"omitempty" validate:"required,min=100,"`
+	Format       string         `json:"format" db:"format" goqu:"omitempty"`
+	Visibility   string         `json:"visibility" db:"visibility" goqu:"omitempty"`
+	AuthorId     int64          `json:"authorId" db:"author_id" goqu:"omitempty" validate:"required"`
+	RestaurantId types.NullInt  `json:"restaurantId" db:"restaurant_id" goqu:"omitempty" validate:"required"`
+	ParentPageId types.NullInt  `json:"parentPageId" db:"parent_page_id" goqu:"omitempty"`
+	Position     int            `json:"position" db:"position" goqu:"omitempty"`
+	PublishAt    types.NullTime `json:"publishAt" db:"publish_at" goqu:"skipinsert,skipupdate"`
+	UnpublishAt  types.NullTime `json:"unpublishAt" db:"unpublish_at" goqu:"skipinsert,skipupdate"`
+	CreatedAt    time.Time      `json:"createdAt" db:"created_at" goqu:"skipinsert,skipupdate,omitempty"`
+	UpdatedAt    time.Time      `json:"updatedAt" db:"updated_at" goqu:"skipinsert,skipupdate,omitempty"`
+	DeletedAt    sql.NullTime   `json:"deletedAt" db:"deleted_at" goqu:"skipupdate,omitempty"`
+	SearchVector sql.NullString `json:"-" db:"search_vector" goqu:"skipinsert,skipupdate"`
+}
+
+/* Workflow states of a page, stored in its visibility column. Only published pages are shown to visitors */
+const (
+	PageDraft     = "draft"
+	PageInReview  = "in_review"
+	PageScheduled = "scheduled"
+	PagePublished = "published"
+	PageArchived  = "archived"
+)
+
+/* PageTransition moves a page from one of the From states to the To state */
+type PageTransition struct {
+	From []string
+	To   string
+}
+
+/*
+PageTransitions are the events of the page workflow: authors submit their drafts for review,
+which admins approve (publishing or scheduling them) or reject. The visibility of a page can only change through them.
+*/
+var PageTransitions = map[string]PageTransition{
+	"submit":   {From: []string{PageDraft}, To: PageInReview},
+	"approve":  {From: []string{PageInReview}, To: PagePublished},
+	"reject":   {From: []string{PageInReview}, To: PageDraft},
+	"schedule": {From: []string{PageDraft, PageInReview, PageArchived}, To: PageScheduled},
+	"publish":  {From: []string{PageDraft, PageInReview, PageScheduled, PageArchived}, To: PagePublished},
+	"archive":  {From: []string{PagePublished, PageScheduled}, To: PageArchived},
+	"reopen":   {From: []string{PageInReview, PageScheduled, PageArchived}, To: PageDraft},
+}
+
+/* Transition returns the transition of event when it applies to the current state of the page */
+func (page *Page) Transition(event string) (PageTransition, bool) {
+	transition, exists := PageTransitions[event]
+	if !exists || !slices.Contains(transition.From, page.Visibility) {
+		return PageTransition{}, false
+	}
+	return transition, true
 }
 
 // Pages represents a slice of Page objects
 type Pages []Page
 
+/* Page found by a full text search, along with its relevance */
+type RankedPage struct {
+	Page
+	Rank float64 `db:"rank"`
+}
+
+/* Former slug of a page, leading to its current slug */
+type PageSlug struct {
+	Id           int64     `json:"id" db:"id"`
+	PageId       int64     `json:"pageId" db:"page_id"`
+	RestaurantId int64     `json:"restaurantId" db:"restaurant_id"`
+	Slug         string    `json:"slug" db:"slug"`
+	CreatedAt    time.Time `json:"createdAt" db:"created_at"`
+}
+
 /* Struct for creating new Page */
 type CreatePagePayload struct {
 	Title        string        `json:"title" db:"title" goqu:"omitempty" validate:"required,min=3,max=50"`
 	Slug         string        `json:"slug" db:"slug" validate:"required,min=3,max=50"`
 	Excerpt      string        `json:"excerpt" db:"excerpt" goqu:"omitempty" validate:"min=10,max=2000"`
 	Body         string        `json:"body" db:"body" goqu:"omitempty" validate:"required,min=100"`
+	Format       string        `json:"format" db:"format" goqu:"omitempty" validate:"oneof=markdown html plain"`
 	Visibility   string        `json:"visibility" db:"visibility" goqu:"omitempty"`
 	AuthorId     int64         `json:"authorId" db:"author_id" goqu:"omitempty" validate:"required"`
 	RestaurantId types.NullInt `json:"restaurantId" db:"restaurant_id" goqu:"omitempty" validate:"required"`
 	ParentPageId types.NullInt `json:"parentPageId" db:"parent_page_id" goqu:"omitempty"`
+	Position     int           `json:"position" db:"position" goqu:"omitempty"`
 	DeletedAt    sql.NullTime  `json:"deletedAt" db:"deleted_at" goqu:"skipupdate,omitempty"`
 }
 
@@ -47,16 +110,28 @@ type PublicItem struct {
 	Slug    string `json:"slug" db:"slug"`
 	Excerpt string `json:"excerpt" db:"excerpt"`
 }
+type PublicDetailItem struct {
+	PublicItem
+	Body     string `json:"body" db:"body"`
+	Format   string `json:"format" db:"format"`
+	BodyHtml string `json:"bodyHtml"`
+}
+
 type OwnerListItem struct {
 	PublicItem
-	Visibility   string        `json:"visibility" db:"visibility"`
-	AuthorId     int64         `json:"authorId" db:"author_id"`
-	ParentPageId types.NullInt `json:"parentPageId" db:"parent_page_id"`
+	Visibility   string         `json:"visibility" db:"visibility"`
+	AuthorId     int64          `json:"authorId" db:"author_id"`
+	ParentPageId types.NullInt  `json:"parentPageId" db:"parent_page_id"`
+	Position     int            `json:"position" db:"position"`
+	PublishAt    types.NullTime `json:"publishAt" db:"publish_at"`
+	UnpublishAt  types.NullTime `json:"unpublishAt" db:"unpublish_at"`
 }
 
 type OwnerDetailItem struct {
 	OwnerListItem
-	Body string `json:"body" db:"body"`
+	Body     string `json:"body" db:"body"`
+	Format   string `json:"format" db:"format"`
+	BodyHtml string `json:"bodyHtml"`
 }
 type AdminListItem struct {
 	OwnerListItem
@@ -66,19 +141,21 @@ type AdminListItem struct {
 
 type AdminDetailItem struct {
 	AdminListItem
-	Body string `json:"body" db:"body"`
+	Body     string `json:"body" db:"body"`
+	Format   string `json:"format" db:"format"`
+	BodyHtml string `json:"bodyHtml"`
 }
 
 type PayloadTypes interface {
-	AdminListItem | OwnerListItem | AdminDetailItem | OwnerDetailItem
+	AdminListItem | OwnerListItem | AdminDetailItem | OwnerDetailItem | PublicItem | PublicDetailItem
 }
 
 func (page *Page) UpdableAttributes(role consts.Role) []string {
 	switch role {
 	case consts.Admin:
-		return []string{"title", "excerpt", "body", "visibility", "authorId", "restaurantId", "parentPageId", "deletedAt"}
+		return []string{"title", "slug", "excerpt", "body", "format", "authorId", "restaurantId", "parentPageId", "position", "deletedAt"}
 	case consts.Manager:
-		return []string{"title", "excerpt", "body", "visibility", "parentPageId", "deletedAt"}
+		return []string{"title", "slug", "excerpt", "body", "format", "parentPageId", "position", "deletedAt"}
 	default:
 		return []string{}
 	}
@@ -86,19 +163,24 @@ func (page *Page) UpdableAttributes(role consts.Role) []string {
 
 func (record *Page) MemberFor(role consts.Role) interface{} {
 	payload, _ := json.Marshal(record)
+	/* The body is returned along with its rendering, which is safe to embed as is */
+	bodyHtml := markup.Render(record.Format, record.Body)
 	switch role {
 	case consts.Admin:
 		var details AdminDetailItem
 		json.Unmarshal(payload, &details)
+		details.BodyHtml = bodyHtml
 		return serializers.MemberPayload[AdminDetailItem]{Id: record.Id, Type: "pages", Attributes: details}
 	case consts.Manager:
 		var details OwnerDetailItem
 		json.Unmarshal(payload, &details)
+		details.BodyHtml = bodyHtml
 		return serializers.MemberPayload[OwnerDetailItem]{Id: record.Id, Type: "pages", Attributes: details}
 	default:
-		var details PublicItem
+		var details PublicDetailItem
 		json.Unmarshal(payload, &details)
-		return serializers.MemberPayload[PublicItem]{Id: record.Id, Type: "pages", Attributes: details}
+		details.BodyHtml = bodyHtml
+		return serializers.MemberPayload[PublicDetailItem]{Id: record.Id, Type: "pages", Attributes: details}
 	}
 }
 
@@ -123,3 +205,61 @@ func (pages Pages) CollectionFor(role consts.Role) []interface{} {
 	}
 	return result
 }
+
+/* Page of a navigation tree, nesting its sub pages */
+type PageNode struct {
+	Id         int64       `json:"id"`
+	Type       string      `json:"type"`
+	Attributes interface{} `json:"attributes"`
+	Children   []*PageNode `json:"children"`
+}
+
+/*
+TreeFor nests the pages under their parent page, siblings ordered by position.
+Pages whose parent isn't part of the collection (e.g. it is deleted) are listed at the root.
+*/
+func (pages Pages) TreeFor(role consts.Role) []*PageNode {
+	sorted := make(Pages, len(pages))
+	copy(sorted, pages)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if sorted[i].Position != sorted[j].Position {
+			return sorted[i].Position < sorted[j].Position
+		}
+		return sorted[i].Id < sorted[j].Id
+	})
+
+	collection := sorted.CollectionFor(role)
+	nodes := make(map[int64]*PageNode, len(sorted))
+	for index, record := range sorted {
+		nodes[record.Id] = &PageNode{
+			Id:         record.Id,
+			Type:       "pages",
+			Attributes: attributesOf(collection[index]),
+			Children:   []*PageNode{},
+		}
+	}
+
+	roots := []*PageNode{}
+	for _, record := range sorted {
+		node := nodes[record.Id]
+		if parent, found := nodes[record.ParentPageId.Int64]; record.ParentPageId.Valid && found {
+			parent.Children = append(parent.Children, node)
+			continue
+		}
+		roots = append(roots, node)
+	}
+	return roots
+}
+
+func attributesOf(member interface{}) interface{} {
+	switch item := member.(type) {
+	case serializers.MemberPayload[AdminListItem]:
+		return item.Attributes
+	case serializers.MemberPayload[OwnerListItem]:
+		return item.Attributes
+	case serializers.MemberPayload[PublicItem]:
+		return item.Attributes
+	default:
+		return nil
+	}
+}
@@ -0,0 +1,206 @@
+package dto
+
+import (
+	"encoding/json"
+	"sort"
+	"time"
+
+	"golang.org/x/exp/slices"
+	consts "resturants-hub.com/m/v2/packages/const"
+	"resturants-hub.com/m/v2/serializers"
+)
+
+/* States of a reservation */
+const (
+	ReservationPending   = "pending"
+	ReservationConfirmed = "confirmed"
+	ReservationCancelled = "cancelled"
+	ReservationNoShow    = "no_show"
+)
+
+/* Reservations in these states hold their table, the others free it */
+var ActiveReservationStatuses = []string{ReservationPending, ReservationConfirmed}
+
+const (
+	/* Time a table is held for when the reservation doesn't say otherwise */
+	ReservationDuration = 2 * time.Hour
+	/* Availability is searched on slots starting every ReservationSlotStep */
+	ReservationSlotStep = 15 * time.Minute
+)
+
+/* Event of the reservation lifecycle, allowed from some states only */
+type ReservationTransition struct {
+	From []string
+	To   string
+}
+
+/*
+ReservationTransitions are the events of a reservation: the restaurant confirms pending reservations,
+either side may cancel them, and confirmed reservations the diners didn't show up for are marked as such.
+*/
+var ReservationTransitions = map[string]ReservationTransition{
+	"confirm": {From: []string{ReservationPending}, To: ReservationConfirmed},
+	"cancel":  {From: []string{ReservationPending, ReservationConfirmed}, To: ReservationCancelled},
+	"no-show": {From: []string{ReservationConfirmed}, To: ReservationNoShow},
+}
+
+// DB representation of the reservations table
+type Reservation struct {
+	Id            int64     `json:"id" db:"id" goqu:"skipinsert,skipupdate"`
+	RestaurantId  int64     `json:"restaurantId" db:"restaurant_id" goqu:"omitempty"`
+	TableId       int64     `json:"tableId" db:"table_id" goqu:"omitempty"`
+	PartySize     int       `json:"partySize" db:"party_size" goqu:"omitempty"`
+	StartsAt      time.Time `json:"startsAt" db:"starts_at" goqu:"omitempty"`
+	EndsAt        time.Time `json:"endsAt" db:"ends_at" goqu:"omitempty"`
+	Status        string    `json:"status" db:"status" goqu:"omitempty"`
+	CustomerName  string    `json:"customerName" db:"customer_name" goqu:"omitempty"`
+	CustomerEmail string    `json:"customerEmail" db:"customer_email" goqu:"omitempty"`
+	CustomerPhone string    `json:"customerPhone" db:"customer_phone" goqu:"omitempty"`
+	Notes         string    `json:"notes" db:"notes" goqu:"omitempty"`
+	CreatedAt     time.Time `json:"createdAt" db:"created_at" goqu:"skipinsert,skipupdate,omitempty"`
+	UpdatedAt     time.Time `json:"updatedAt" db:"updated_at" goqu:"skipinsert,skipupdate,omitempty"`
+}
+
+// Reservations represents a slice of Reservation objects
+type Reservations []Reservation
+
+/* Struct for creating new Reservation, the table is picked among the free ones when it isn't given */
+type CreateReservationPayload struct {
+	RestaurantId    int64     `json:"restaurantId" db:"restaurant_id" validate:"required"`
+	TableId         int64     `json:"tableId" db:"table_id"`
+	PartySize       int       `json:"partySize" db:"party_size" validate:"required,gt=0"`
+	StartsAt        time.Time `json:"startsAt" db:"starts_at" validate:"required"`
+	EndsAt          time.Time `json:"-" db:"ends_at"`
+	DurationMinutes int       `json:"durationMinutes" db:"duration_minutes" goqu:"skipinsert" validate:"omitempty,gte=30,lte=360"`
+	Status          string    `json:"-" db:"status"`
+	CustomerName    string    `json:"customerName" db:"customer_name" validate:"required,max=100"`
+	CustomerEmail   string    `json:"customerEmail" db:"customer_email" validate:"required,email,max=255"`
+	CustomerPhone   string    `json:"customerPhone" db:"customer_phone" validate:"max=50"`
+	Notes           string    `json:"notes" db:"notes" validate:"max=1000"`
+}
+
+/* Duration of the reservation, ReservationDuration unless durationMinutes is given */
+func (payload *CreateReservationPayload) Duration() time.Duration {
+	if payload.DurationMinutes == 0 {
+		return ReservationDuration
+	}
+	return time.Duration(payload.DurationMinutes) * time.Minute
+}
+
+type ReservationItem struct {
+	RestaurantId  int64     `json:"restaurantId" db:"restaurant_id"`
+	TableId       int64     `json:"tableId" db:"table_id"`
+	PartySize     int       `json:"partySize" db:"party_size"`
+	StartsAt      time.Time `json:"startsAt" db:"starts_at"`
+	EndsAt        time.Time `json:"endsAt" db:"ends_at"`
+	Status        string    `json:"status" db:"status"`
+	CustomerName  string    `json:"customerName" db:"customer_name"`
+	CustomerEmail string    `json:"customerEmail" db:"customer_email"`
+	CustomerPhone string    `json:"customerPhone" db:"customer_phone"`
+	Notes         string    `json:"notes" db:"notes"`
+	CreatedAt     time.Time `json:"createdAt" db:"created_at"`
+	UpdatedAt     time.Time `json:"updatedAt" db:"updated_at"`
+}
+
+/* Reservation as confirmed to the diner who made it */
+type PublicReservationItem struct {
+	PartySize    int       `json:"partySize" db:"party_size"`
+	StartsAt     time.Time `json:"startsAt" db:"starts_at"`
+	EndsAt       time.Time `json:"endsAt" db:"ends_at"`
+	Status       string    `json:"status" db:"status"`
+	CustomerName string    `json:"customerName" db:"customer_name"`
+}
+
+/* Slot of the availability search, with the tables free for its whole duration, smallest first */
+type AvailabilitySlot struct {
+	StartsAt time.Time        `json:"startsAt"`
+	EndsAt   time.Time        `json:"endsAt"`
+	Tables   []AvailableTable `json:"tables"`
+}
+
+func (reservation *Reservation) UpdableAttributes(role consts.Role) []string {
+	switch role {
+	case consts.Admin, consts.Manager:
+		return []string{"customerName", "customerEmail", "customerPhone", "notes"}
+	default:
+		return []string{}
+	}
+}
+
+/* Transition returns the transition of event when it applies to the current state of the reservation */
+func (reservation *Reservation) Transition(event string) (ReservationTransition, bool) {
+	transition, exists := ReservationTransitions[event]
+	if !exists || !slices.Contains(transition.From, reservation.Status) {
+		return ReservationTransition{}, false
+	}
+	return transition, true
+}
+
+func (record *Reservation) MemberFor(role consts.Role) interface{} {
+	payload, _ := json.Marshal(record)
+	switch role {
+	case consts.Admin, consts.Manager:
+		var details ReservationItem
+		json.Unmarshal(payload, &details)
+		return serializers.MemberPayload[ReservationItem]{Id: record.Id, Type: "reservations", Attributes: details}
+	default:
+		var details PublicReservationItem
+		json.Unmarshal(payload, &details)
+		return serializers.MemberPayload[PublicReservationItem]{Id: record.Id, Type: "reservations", Attributes: details}
+	}
+}
+
+func (reservations Reservations) CollectionFor(role consts.Role) []interface{} {
+	result := make([]interface{}, len(reservations))
+	for index, record := range reservations {
+		result[index] = record.MemberFor(role)
+	}
+	return result
+}
+
+/* FreeTables returns the active tables seating the party which no reservation holds during [start, end), smallest first */
+func (tables Tables) FreeTables(booked Reservations, partySize int, start time.Time, end time.Time) Tables {
+	free := Tables{}
+	for _, table := range tables {
+		if table.Seats < partySize || (table.Active != nil && !*table.Active) {
+			continue
+		}
+		available := true
+		for _, reservation := range booked {
+			if reservation.TableId == table.Id && slices.Contains(ActiveReservationStatuses, reservation.Status) &&
+				reservation.StartsAt.Before(end) && reservation.EndsAt.After(start) {
+				available = false
+				break
+			}
+		}
+		if available {
+			free = append(free, table)
+		}
+	}
+	sort.SliceStable(free, func(i, j int) bool { return free[i].Seats < free[j].Seats })
+	return free
+}
+
+/*
+AvailableSlots lists the slots starting between from and to (every ReservationSlotStep) at which the party can be seated:
+the restaurant has to be open during the whole reservation, which has to start after now, and a table has to be free.
+*/
+func (restaurant *Restaurant) AvailableSlots(tables Tables, booked Reservations, partySize int, from time.Time, to time.Time, duration time.Duration, now time.Time) []AvailabilitySlot {
+	slots := []AvailabilitySlot{}
+	for start := from; !start.After(to); start = start.Add(ReservationSlotStep) {
+		end := start.Add(duration)
+		if !start.After(now) || !restaurant.OpeningHours.Covers(restaurant.Timezone, start, end) {
+			continue
+		}
+		free := tables.FreeTables(booked, partySize, start, end)
+		if len(free) == 0 {
+			continue
+		}
+		slot := AvailabilitySlot{StartsAt: start, EndsAt: end, Tables: make([]AvailableTable, len(free))}
+		for index, table := range free {
+			slot.Tables[index] = AvailableTable{Id: table.Id, Name: table.Name, Seats: table.Seats}
+		}
+		slots = append(slots, slot)
+	}
+	return slots
+}
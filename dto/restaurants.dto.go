@@ -6,6 +6,7 @@ import (
 	"time"
 
 	consts "resturants-hub.com/m/v2/packages/const"
+	"resturants-hub.com/m/v2/packages/hours"
 	"resturants-hub.com/m/v2/packages/types"
 	"resturants-hub.com/m/v2/serializers"
 )
@@ -15,6 +16,7 @@ type Restaurant struct {
 	Id            int64                  `json:"id" db:"id" goqu:"skipinsert,skipupdate"`
 	ManagerId     int64                  `json:"managerId" db:"manager_id" goqu:"omitempty" validate:"required"`
 	Name          string                 `json:"name" db:"name" goqu:"omitempty" validate:"required,min=3,max=50"`
+	Slug          string                 `json:"slug" db:"slug" goqu:"omitempty"`
 	Description   string                 `json:"description" db:"description" goqu:"omitempty" validate:"required,min=10"`
 	Address       types.JsonMap[Address] `json:"address" db:"address" goqu:"omitempty" validate:"required"`
 	Email         string                 `json:"email" db:"email" goqu:"omitempty" validate:"required,email"`
@@ -23,9 +25,14 @@ type Restaurant struct {
 	Website       string                 `json:"website" db:"website" goqu:"omitempty"`
 	FacebookLink  string                 `json:"facebookLink" db:"facebook_link" goqu:"omitempty"`
 	InstagramLink string                 `json:"instagramLink" db:"instagram_link" goqu:"omitempty"`
+	Timezone      string                 `json:"timezone" db:"timezone" goqu:"skipinsert,skipupdate"`
+	OpeningHours  hours.Schedule         `json:"openingHours" db:"opening_hours" goqu:"skipinsert,skipupdate"`
+	RatingAverage float64                `json:"ratingAverage" db:"rating_average" goqu:"skipinsert,skipupdate"`
+	RatingCount   int64                  `json:"ratingCount" db:"rating_count" goqu:"skipinsert,skipupdate"`
 	CreatedAt     time.Time              `json:"createdAt" db:"created_at" goqu:"skipinsert,skipupdate,omitempty"`
 	UpdatedAt     time.Time              `json:"updatedAt" db:"updated_at" goqu:"skipinsert,skipupdate,omitempty"`
 	DeletedAt     sql.NullTime           `json:"deletedAt" db:"deleted_at" goqu:"skipupdate,omitempty"`
+	SearchVector  sql.NullString         `json:"-" db:"search_vector" goqu:"skipinsert,skipupdate"`
 }
 type Address struct {
 	Street     string `json:"street" db:"street" validate:"required"`
@@ -38,6 +45,7 @@ type Address struct {
 type CreateRestaurantPayload struct {
 	ManagerId     int64                  `json:"managerId" db:"manager_id" validate:"required"`
 	Name          string                 `json:"name" db:"name" validate:"required,min=3,max=50"`
+	Slug          string                 `json:"slug" db:"slug" validate:"required,max=60"`
 	Address       types.JsonMap[Address] `json:"address" db:"address" validate:"required"`
 	Description   string                 `json:"description" db:"description" validate:"required,min=10"`
 	Email         string                 `json:"email" db:"email" validate:"required,email"`
@@ -49,20 +57,26 @@ type CreateRestaurantPayload struct {
 }
 
 type AdminRestaurantListItem struct {
-	Id          int64                  `json:"id"`
-	ManagerId   int64                  `json:"managerId" db:"manager_id"`
-	Name        string                 `json:"name" db:"name"`
-	Description string                 `json:"description" db:"description"`
-	Address     types.JsonMap[Address] `json:"address" db:"address"`
-	Email       string                 `json:"email" db:"email"`
-	Phone       string                 `json:"phone" db:"phone"`
-	CreatedAt   time.Time              `json:"createdAt" db:"created_at"`
+	Id            int64                  `json:"id"`
+	ManagerId     int64                  `json:"managerId" db:"manager_id"`
+	Name          string                 `json:"name" db:"name"`
+	Slug          string                 `json:"slug" db:"slug"`
+	Description   string                 `json:"description" db:"description"`
+	Address       types.JsonMap[Address] `json:"address" db:"address"`
+	Email         string                 `json:"email" db:"email"`
+	Phone         string                 `json:"phone" db:"phone"`
+	OpenNow       bool                   `json:"openNow"`
+	NextOpening   *time.Time             `json:"nextOpening"`
+	RatingAverage float64                `json:"ratingAverage" db:"rating_average"`
+	RatingCount   int64                  `json:"ratingCount" db:"rating_count"`
+	CreatedAt     time.Time              `json:"createdAt" db:"created_at"`
 }
 
 type AdminRestaurantDetailItem struct {
 	Id            int64                  `json:"id"`
 	ManagerId     int64                  `json:"managerId" db:"manager_id"`
 	Name          string                 `json:"name" db:"name"`
+	Slug          string                 `json:"slug" db:"slug"`
 	Description   string                 `json:"description" db:"description"`
 	Address       types.JsonMap[Address] `json:"address" db:"address"`
 	Email         string                 `json:"email" db:"email"`
@@ -71,35 +85,55 @@ type AdminRestaurantDetailItem struct {
 	Website       string                 `json:"website" db:"website"`
 	FacebookLink  string                 `json:"facebookLink" db:"facebook_link"`
 	InstagramLink string                 `json:"instagramLink" db:"instagram_link"`
+	Timezone      string                 `json:"timezone" db:"timezone"`
+	OpeningHours  hours.Schedule         `json:"openingHours" db:"opening_hours"`
+	OpenNow       bool                   `json:"openNow"`
+	NextOpening   *time.Time             `json:"nextOpening"`
+	RatingAverage float64                `json:"ratingAverage" db:"rating_average"`
+	RatingCount   int64                  `json:"ratingCount" db:"rating_count"`
 	CreatedAt     time.Time              `json:"createdAt" db:"created_at"`
 	UpdatedAt     time.Time              `json:"updatedAt" db:"updated_at"`
 	DeletedAt     sql.NullTime           `json:"deletedAt" db:"deleted_at"`
 }
 
 type OwnerRestaurantListItem struct {
-	Id          int64     `json:"id"`
-	ManagerId   int64     `json:"managerId" db:"manager_id"`
-	Name        string    `json:"name" db:"name"`
-	Description string    `json:"description" db:"description"`
-	Address     string    `json:"address" db:"address"`
-	Email       string    `json:"email" db:"email"`
-	Phone       string    `json:"phone" db:"phone"`
-	CreatedAt   time.Time `json:"createdAt" db:"created_at"`
+	Id            int64                  `json:"id"`
+	ManagerId     int64                  `json:"managerId" db:"manager_id"`
+	Name          string                 `json:"name" db:"name"`
+	Slug          string                 `json:"slug" db:"slug"`
+	Description   string                 `json:"description" db:"description"`
+	Address       types.JsonMap[Address] `json:"address" db:"address"`
+	Email         string                 `json:"email" db:"email"`
+	Phone         string                 `json:"phone" db:"phone"`
+	Timezone      string                 `json:"timezone" db:"timezone"`
+	OpeningHours  hours.Schedule         `json:"openingHours" db:"opening_hours"`
+	OpenNow       bool                   `json:"openNow"`
+	NextOpening   *time.Time             `json:"nextOpening"`
+	RatingAverage float64                `json:"ratingAverage" db:"rating_average"`
+	RatingCount   int64                  `json:"ratingCount" db:"rating_count"`
+	CreatedAt     time.Time              `json:"createdAt" db:"created_at"`
 }
 
 type OwnerRestaurantDetailItem struct {
-	Id            int64     `json:"id"`
-	ManagerId     int64     `json:"managerId" db:"manager_id"`
-	Name          string    `json:"name" db:"name"`
-	Description   string    `json:"description" db:"description"`
-	Address       string    `json:"address" db:"address"`
-	Email         string    `json:"email" db:"email"`
-	Phone         string    `json:"phone" db:"phone"`
-	Mobile        string    `json:"mobile" db:"mobile"`
-	Website       string    `json:"website" db:"website"`
-	FacebookLink  string    `json:"facebookLink" db:"facebook_link"`
-	InstagramLink string    `json:"instagramLink" db:"instagram_link"`
-	UpdatedAt     time.Time `json:"updatedAt" db:"updated_at"`
+	Id            int64                  `json:"id"`
+	ManagerId     int64                  `json:"managerId" db:"manager_id"`
+	Name          string                 `json:"name" db:"name"`
+	Slug          string                 `json:"slug" db:"slug"`
+	Description   string                 `json:"description" db:"description"`
+	Address       types.JsonMap[Address] `json:"address" db:"address"`
+	Email         string                 `json:"email" db:"email"`
+	Phone         string                 `json:"phone" db:"phone"`
+	Mobile        string                 `json:"mobile" db:"mobile"`
+	Website       string                 `json:"website" db:"website"`
+	FacebookLink  string                 `json:"facebookLink" db:"facebook_link"`
+	InstagramLink string                 `json:"instagramLink" db:"instagram_link"`
+	Timezone      string                 `json:"timezone" db:"timezone"`
+	OpeningHours  hours.Schedule         `json:"openingHours" db:"opening_hours"`
+	OpenNow       bool                   `json:"openNow"`
+	NextOpening   *time.Time             `json:"nextOpening"`
+	RatingAverage float64                `json:"ratingAverage" db:"rating_average"`
+	RatingCount   int64                  `json:"ratingCount" db:"rating_count"`
+	UpdatedAt     time.Time              `json:"updatedAt" db:"updated_at"`
 }
 
 type RestaurantPayloadTypes interface {
@@ -112,8 +146,51 @@ func (restaurant *Restaurant) AdminUpdableAttributes() []string {
 
 type Restaurants []Restaurant
 
+/* Restaurant found by a full text search, along with its relevance */
+type RankedRestaurant struct {
+	Restaurant
+	Rank float64 `db:"rank"`
+}
+
+/* Opening hours of a restaurant, edited on their own by its manager */
+type OpeningHoursPayload struct {
+	Timezone string `json:"timezone"`
+	hours.Schedule
+}
+
+type OpeningHoursItem struct {
+	Timezone    string                      `json:"timezone"`
+	Weekly      map[string][]hours.Interval `json:"weekly"`
+	Exceptions  []hours.Exception           `json:"exceptions"`
+	OpenNow     bool                        `json:"openNow"`
+	NextOpening *time.Time                  `json:"nextOpening"`
+}
+
+/* payload serializes the restaurant along with its opening status, which is computed at the time of the request */
+func (restaurant *Restaurant) payload() []byte {
+	openNow, nextOpening := restaurant.OpeningHours.Status(restaurant.Timezone, time.Now())
+	payload, _ := json.Marshal(struct {
+		*Restaurant
+		OpenNow     bool       `json:"openNow"`
+		NextOpening *time.Time `json:"nextOpening"`
+	}{restaurant, openNow, nextOpening})
+	return payload
+}
+
+func (restaurant *Restaurant) OpeningHoursMember() interface{} {
+	openNow, nextOpening := restaurant.OpeningHours.Status(restaurant.Timezone, time.Now())
+	item := OpeningHoursItem{
+		Timezone:    restaurant.Timezone,
+		Weekly:      restaurant.OpeningHours.Weekly,
+		Exceptions:  restaurant.OpeningHours.Exceptions,
+		OpenNow:     openNow,
+		NextOpening: nextOpening,
+	}
+	return serializers.MemberPayload[OpeningHoursItem]{Id: restaurant.Id, Type: "openingHours", Attributes: item}
+}
+
 func (restaurant *Restaurant) MemberFor(role consts.Role) interface{} {
-	payload, _ := json.Marshal(restaurant)
+	payload := restaurant.payload()
 	switch role {
 	case consts.Admin:
 		var details AdminRestaurantDetailItem
@@ -133,7 +210,7 @@ func (restaurant *Restaurant) MemberFor(role consts.Role) interface{} {
 func (restaurants Restaurants) CollectionFor(role consts.Role) []interface{} {
 	result := make([]interface{}, len(restaurants))
 	for index, record := range restaurants {
-		payload, _ := json.Marshal(record)
+		payload := record.payload()
 		switch role {
 		case consts.Admin:
 			var adminListItem AdminRestaurantListItem
@@ -143,6 +220,10 @@ func (restaurants Restaurants) CollectionFor(role consts.Role) []interface{} {
 			var details OwnerRestaurantDetailItem
 			json.Unmarshal(payload, &details)
 			result[index] = serializers.MemberPayload[OwnerRestaurantDetailItem]{Id: record.Id, Type: "restaurants", Attributes: details}
+		default:
+			var listItem OwnerRestaurantListItem
+			json.Unmarshal(payload, &listItem)
+			result[index] = serializers.MemberPayload[OwnerRestaurantListItem]{Id: record.Id, Type: "restaurants", Attributes: listItem}
 		}
 	}
 	return result
@@ -0,0 +1,121 @@
+package dto
+
+import (
+	"encoding/json"
+	"time"
+
+	"golang.org/x/exp/slices"
+	consts "resturants-hub.com/m/v2/packages/const"
+	"resturants-hub.com/m/v2/packages/types"
+	"resturants-hub.com/m/v2/serializers"
+)
+
+/* States of a review, only published reviews are shown and counted in the ratings */
+const (
+	ReviewPublished = "published"
+	ReviewHidden    = "hidden"
+	ReviewRemoved   = "removed"
+)
+
+/* Event of the review moderation, allowed from some states only */
+type ReviewTransition struct {
+	From []string
+	To   string
+}
+
+/*
+ReviewTransitions are the events of the moderation of a review: restaurants hide abusive reviews,
+which admins then restore or remove for good.
+*/
+var ReviewTransitions = map[string]ReviewTransition{
+	"hide":    {From: []string{ReviewPublished}, To: ReviewHidden},
+	"restore": {From: []string{ReviewHidden}, To: ReviewPublished},
+	"remove":  {From: []string{ReviewHidden}, To: ReviewRemoved},
+}
+
+// DB representation of the reviews table
+type Review struct {
+	Id           int64          `json:"id" db:"id" goqu:"skipinsert,skipupdate"`
+	RestaurantId int64          `json:"restaurantId" db:"restaurant_id" goqu:"skipupdate"`
+	DishId       int64          `json:"dishId" db:"dish_id" goqu:"skipupdate"`
+	Rating       int            `json:"rating" db:"rating" goqu:"omitempty"`
+	Title        string         `json:"title" db:"title" goqu:"omitempty"`
+	Body         string         `json:"body" db:"body" goqu:"omitempty"`
+	AuthorName   string         `json:"authorName" db:"author_name" goqu:"omitempty"`
+	AuthorEmail  string         `json:"authorEmail" db:"author_email" goqu:"omitempty"`
+	Status       string         `json:"status" db:"status" goqu:"omitempty"`
+	Reply        string         `json:"reply" db:"reply"`
+	RepliedAt    types.NullTime `json:"repliedAt" db:"replied_at"`
+	CreatedAt    time.Time      `json:"createdAt" db:"created_at" goqu:"skipinsert,skipupdate,omitempty"`
+	UpdatedAt    time.Time      `json:"updatedAt" db:"updated_at" goqu:"skipinsert,skipupdate,omitempty"`
+}
+
+// Reviews represents a slice of Review objects
+type Reviews []Review
+
+/* Struct for submitting new Review, the dish and restaurant are the ones of the URL */
+type CreateReviewPayload struct {
+	RestaurantId int64  `json:"-" db:"restaurant_id" validate:"required"`
+	DishId       int64  `json:"-" db:"dish_id" validate:"required"`
+	Rating       int    `json:"rating" db:"rating" validate:"required,gte=1,lte=5"`
+	Title        string `json:"title" db:"title" validate:"max=100"`
+	Body         string `json:"body" db:"body" validate:"required,max=2000"`
+	AuthorName   string `json:"authorName" db:"author_name" validate:"required,max=100"`
+	AuthorEmail  string `json:"authorEmail" db:"author_email" validate:"required,email,max=255"`
+}
+
+/* Reply of the restaurant to a review, an empty reply withdraws it */
+type ReviewReplyPayload struct {
+	Reply string `json:"reply" validate:"max=2000"`
+}
+
+/* Review as shown on the menu, without the email of its author */
+type PublicReviewItem struct {
+	DishId     int64          `json:"dishId" db:"dish_id"`
+	Rating     int            `json:"rating" db:"rating"`
+	Title      string         `json:"title" db:"title"`
+	Body       string         `json:"body" db:"body"`
+	AuthorName string         `json:"authorName" db:"author_name"`
+	Reply      string         `json:"reply" db:"reply"`
+	RepliedAt  types.NullTime `json:"repliedAt" db:"replied_at"`
+	CreatedAt  time.Time      `json:"createdAt" db:"created_at"`
+}
+
+type ReviewItem struct {
+	PublicReviewItem
+	RestaurantId int64     `json:"restaurantId" db:"restaurant_id"`
+	AuthorEmail  string    `json:"authorEmail" db:"author_email"`
+	Status       string    `json:"status" db:"status"`
+	UpdatedAt    time.Time `json:"updatedAt" db:"updated_at"`
+}
+
+/* Transition returns the transition of event when it applies to the current state of the review */
+func (review *Review) Transition(event string) (ReviewTransition, bool) {
+	transition, exists := ReviewTransitions[event]
+	if !exists || !slices.Contains(transition.From, review.Status) {
+		return ReviewTransition{}, false
+	}
+	return transition, true
+}
+
+func (record *Review) MemberFor(role consts.Role) interface{} {
+	payload, _ := json.Marshal(record)
+	switch role {
+	case consts.Admin, consts.Manager:
+		var details ReviewItem
+		json.Unmarshal(payload, &details)
+		return serializers.MemberPayload[ReviewItem]{Id: record.Id, Type: "reviews", Attributes: details}
+	default:
+		var details PublicReviewItem
+		json.Unmarshal(payload, &details)
+		return serializers.MemberPayload[PublicReviewItem]{Id: record.Id, Type: "reviews", Attributes: details}
+	}
+}
+
+func (reviews Reviews) CollectionFor(role consts.Role) []interface{} {
+	result := make([]interface{}, len(reviews))
+	for index, record := range reviews {
+		result[index] = record.MemberFor(role)
+	}
+	return result
+}
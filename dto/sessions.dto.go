@@ -1,26 +1,71 @@
 package dto
 
-import "time"
+import (
+	"encoding/json"
+	"time"
 
+	consts "resturants-hub.com/m/v2/packages/const"
+	"resturants-hub.com/m/v2/packages/types"
+	"resturants-hub.com/m/v2/serializers"
+)
+
+/*
+Session of a signed in user, identified by the token of its cookie of which only the hash is stored.
+The tokens of the provider never leave the server.
+*/
 type Session struct {
-	Id                int64     `json:"id" db:"id" goqu:"skipinsert"`
-	UserId            int64     `json:"userId" db:"user_id" goqu:"omitempty"`
-	Provider          string    `json:"provider" db:"provider" goqu:"omitempty"`
-	Email             string    `json:"email" db:"email" goqu:"omitempty"`
-	AccessToken       string    `json:"accessToken" db:"access_token" goqu:"omitempty"`
-	AccessTokenSecret string    `json:"accessTokenSecret" db:"access_token_secret" goqu:"omitempty"`
-	RefreshToken      string    `json:"refreshToken" db:"refresh_token" goqu:"omitempty"`
-	ExpiresAt         time.Time `json:"expiresAt" db:"expires_at"`
-	CreatedAt         time.Time `json:"createdAt" db:"created_at" goqu:"skipinsert omitempty"`
-	UpdatedAt         time.Time `json:"updatedAt" db:"updated_at" goqu:"skipinsert"`
-	IDToken           string    `json:"idToken" db:"id_token"`
+	Id                int64          `json:"id" db:"id" goqu:"skipinsert,skipupdate"`
+	UserId            int64          `json:"userId" db:"user_id" goqu:"omitempty"`
+	Provider          string         `json:"provider" db:"provider" goqu:"omitempty"`
+	Email             string         `json:"email" db:"email" goqu:"omitempty"`
+	Token             string         `json:"-" db:"-"`
+	TokenHash         string         `json:"-" db:"token_hash" goqu:"omitempty"`
+	AccessToken       string         `json:"-" db:"access_token" goqu:"omitempty"`
+	AccessTokenSecret string         `json:"-" db:"access_token_secret" goqu:"omitempty"`
+	RefreshToken      string         `json:"-" db:"refresh_token" goqu:"omitempty"`
+	IDToken           string         `json:"-" db:"id_token" goqu:"omitempty"`
+	ProviderExpiresAt types.NullTime `json:"-" db:"provider_expires_at"`
+	ExpiresAt         time.Time      `json:"expiresAt" db:"expires_at"`
+	RevokedAt         types.NullTime `json:"revokedAt" db:"revoked_at" goqu:"skipinsert,skipupdate"`
+	CreatedAt         time.Time      `json:"createdAt" db:"created_at" goqu:"skipinsert,skipupdate,omitempty"`
+	UpdatedAt         time.Time      `json:"updatedAt" db:"updated_at" goqu:"skipinsert,skipupdate"`
+}
+
+type Sessions []Session
+
+type SessionItem struct {
+	Provider  string         `json:"provider"`
+	ExpiresAt time.Time      `json:"expiresAt"`
+	RevokedAt types.NullTime `json:"revokedAt"`
+	CreatedAt time.Time      `json:"createdAt"`
+	UpdatedAt time.Time      `json:"updatedAt"`
+}
+
+func (session *Session) IsActive() bool {
+	return !session.RevokedAt.Valid && session.ExpiresAt.After(time.Now())
+}
+
+func (session *Session) MemberFor(role consts.Role) interface{} {
+	payload, _ := json.Marshal(session)
+	var details SessionItem
+	json.Unmarshal(payload, &details)
+	return serializers.MemberPayload[SessionItem]{Id: session.Id, Type: "sessions", Attributes: details}
+}
+
+func (sessions Sessions) CollectionFor(role consts.Role) []interface{} {
+	result := make([]interface{}, len(sessions))
+	for index, record := range sessions {
+		result[index] = record.MemberFor(role)
+	}
+	return result
 }
 
 type SsoUserInfo struct {
-	Sub        string `json:"sub"`
-	Name       string `json:"name"`
-	GivenName  string `json:"given_name"`
-	FamilyName string `json:"family_name"`
-	Picture    string `json:"picture"`
-	Email      string `json:"email"`
+	Sub           string `json:"sub"`
+	Name          string `json:"name"`
+	GivenName     string `json:"given_name"`
+	FamilyName    string `json:"family_name"`
+	Picture       string `json:"picture"`
+	Email         string `json:"email"`
+	EmailVerified bool   `json:"email_verified"`
 }
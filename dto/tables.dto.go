@@ -0,0 +1,72 @@
+package dto
+
+import (
+	"encoding/json"
+	"time"
+
+	consts "resturants-hub.com/m/v2/packages/const"
+	"resturants-hub.com/m/v2/serializers"
+)
+
+// DB representation of the tables table: a table of a restaurant which can be booked
+type Table struct {
+	Id           int64     `json:"id" db:"id" goqu:"skipinsert,skipupdate"`
+	RestaurantId int64     `json:"restaurantId" db:"restaurant_id" goqu:"omitempty" validate:"required"`
+	Name         string    `json:"name" db:"name" goqu:"omitempty" validate:"required,max=50"`
+	Seats        int       `json:"seats" db:"seats" goqu:"omitempty" validate:"required,gt=0"`
+	Active       *bool     `json:"active" db:"active" goqu:"omitempty"`
+	CreatedAt    time.Time `json:"createdAt" db:"created_at" goqu:"skipinsert,skipupdate,omitempty"`
+	UpdatedAt    time.Time `json:"updatedAt" db:"updated_at" goqu:"skipinsert,skipupdate,omitempty"`
+}
+
+// Tables represents a slice of Table objects
+type Tables []Table
+
+/* Struct for creating new Table */
+type CreateTablePayload struct {
+	RestaurantId int64  `json:"restaurantId" db:"restaurant_id" validate:"required"`
+	Name         string `json:"name" db:"name" validate:"required,max=50"`
+	Seats        int    `json:"seats" db:"seats" validate:"required,gt=0"`
+	Active       *bool  `json:"active" db:"active" goqu:"omitempty"`
+}
+
+type TableItem struct {
+	RestaurantId int64     `json:"restaurantId" db:"restaurant_id"`
+	Name         string    `json:"name" db:"name"`
+	Seats        int       `json:"seats" db:"seats"`
+	Active       bool      `json:"active" db:"active"`
+	CreatedAt    time.Time `json:"createdAt" db:"created_at"`
+	UpdatedAt    time.Time `json:"updatedAt" db:"updated_at"`
+}
+
+/* Table as shown to diners looking for availability */
+type AvailableTable struct {
+	Id    int64  `json:"id"`
+	Name  string `json:"name"`
+	Seats int    `json:"seats"`
+}
+
+func (table *Table) UpdableAttributes(role consts.Role) []string {
+	switch role {
+	case consts.Admin, consts.Manager:
+		return []string{"name", "seats", "active"}
+	default:
+		return []string{}
+	}
+}
+
+/* Tables are only exposed to the users managing the restaurant, who all get the same attributes */
+func (record *Table) MemberFor(role consts.Role) interface{} {
+	payload, _ := json.Marshal(record)
+	var details TableItem
+	json.Unmarshal(payload, &details)
+	return serializers.MemberPayload[TableItem]{Id: record.Id, Type: "tables", Attributes: details}
+}
+
+func (tables Tables) CollectionFor(role consts.Role) []interface{} {
+	result := make([]interface{}, len(tables))
+	for index, record := range tables {
+		result[index] = record.MemberFor(role)
+	}
+	return result
+}
@@ -0,0 +1,53 @@
+package dto
+
+import (
+	"encoding/json"
+	"time"
+
+	consts "resturants-hub.com/m/v2/packages/const"
+	"resturants-hub.com/m/v2/serializers"
+)
+
+// DB representation of the user_identities table: the account of a user at an SSO provider
+type UserIdentity struct {
+	Id        int64     `json:"id" db:"id" goqu:"skipinsert,skipupdate"`
+	UserId    int64     `json:"userId" db:"user_id" goqu:"omitempty"`
+	Provider  string    `json:"provider" db:"provider" goqu:"omitempty"`
+	Subject   string    `json:"subject" db:"subject" goqu:"omitempty"`
+	Email     string    `json:"email" db:"email" goqu:"omitempty"`
+	CreatedAt time.Time `json:"createdAt" db:"created_at" goqu:"skipinsert,skipupdate,omitempty"`
+	UpdatedAt time.Time `json:"updatedAt" db:"updated_at" goqu:"skipinsert,skipupdate,omitempty"`
+}
+
+// UserIdentities represents a slice of UserIdentity objects
+type UserIdentities []UserIdentity
+
+/* Struct for linking a provider to a user, the subject is the id of the user at the provider */
+type CreateUserIdentityPayload struct {
+	UserId   int64  `json:"userId" db:"user_id" validate:"required"`
+	Provider string `json:"provider" db:"provider" validate:"required"`
+	Subject  string `json:"subject" db:"subject" validate:"required"`
+	Email    string `json:"email" db:"email"`
+}
+
+type UserIdentityItem struct {
+	Provider  string    `json:"provider"`
+	Email     string    `json:"email"`
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+func (record *UserIdentity) MemberFor(role consts.Role) interface{} {
+	payload, _ := json.Marshal(record)
+	var details UserIdentityItem
+	json.Unmarshal(payload, &details)
+	return serializers.MemberPayload[UserIdentityItem]{Id: record.Id, Type: "userIdentities", Attributes: details}
+}
+
+func (identities UserIdentities) CollectionFor(role consts.Role) []interface{} {
+	result := make([]interface{}, len(identities))
+	for index, record := range identities {
+		result[index] = record.MemberFor(role)
+	}
+	return result
+}
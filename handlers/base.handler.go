@@ -1,7 +1,10 @@
 package handlers
 
 import (
+	"encoding/json"
 	"fmt"
+	"io"
+	"net/http"
 	"net/url"
 	"strconv"
 	"strings"
@@ -9,7 +12,9 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/go-playground/validator/v10"
 	"golang.org/x/exp/slices"
+	"resturants-hub.com/m/v2/database"
 	"resturants-hub.com/m/v2/dto"
+	pagination "resturants-hub.com/m/v2/packages"
 	rest_errors "resturants-hub.com/m/v2/packages/utils"
 )
 
@@ -29,17 +34,94 @@ func NewBaseHandler() BaseHandler {
 	return &baseHandler{}
 }
 
-func WhitelistQueryParams(c *gin.Context, allowedKeys []string) url.Values {
-	allowedKeys = append(allowedKeys, []string{"page", "sort", "size"}...)
+/*
+WhitelistQueryParams makes sure query params only filter and sort on allowedKeys (or the id).
+Filters on other attributes are rejected with a 400 listing them, instead of being silently ignored
+*/
+func WhitelistQueryParams(c *gin.Context, allowedKeys []string) (url.Values, rest_errors.RestErr) {
 	params := c.Request.URL.Query()
-	for key, _ := range params {
+	causes := validateSortParam(params.Get("sort"), allowedKeys)
+
+	for key := range params {
+		if slices.Contains(pagination.ReservedParams, key) {
+			continue
+		}
+		/* Keys of JSONB columns (address.city) are allowed along with their column, other columns have no keys */
 		attr := strings.Split(key, "__")[0]
-		if !slices.Contains(allowedKeys, attr) {
-			delete(params, key)
+		column, _, dotted := strings.Cut(attr, ".")
+		if (column == "id" || slices.Contains(allowedKeys, column)) && (!dotted || slices.Contains(database.JsonColumns, column)) {
+			continue
 		}
+		causes[key] = append(causes[key], map[string]interface{}{
+			"error":     "unsupported_attribute",
+			"attribute": attr,
+		})
+	}
+
+	if len(causes) > 0 {
+		return nil, rest_errors.NewRestError("Invalid query parameters", http.StatusBadRequest, "bad_request", causes)
+	}
+	return params, nil
+}
+
+func validateSortParam(sort string, allowedKeys []string) rest_errors.ValidationErrs {
+	causes := rest_errors.ValidationErrs{}
+	for _, field := range strings.Split(sort, ",") {
+		attr := strings.TrimPrefix(strings.TrimSpace(field), "-")
+		if attr == "" || attr == "id" || slices.Contains(allowedKeys, attr) {
+			continue
+		}
+		causes["sort"] = append(causes["sort"], map[string]interface{}{
+			"error":     "unsupported_attribute",
+			"attribute": attr,
+		})
+	}
+	return causes
+}
+
+/*
+PaginationMeta returns the meta (total, page, size, totalPages) and links of a paginated collection.
+When the request uses cursors (page[after]/page[before]), the meta holds the next/prev cursors of records instead
+*/
+func PaginationMeta(c *gin.Context, params url.Values, total uint64, records interface{}) (map[string]interface{}, map[string]interface{}) {
+	meta := pagination.NewPagination(params)
+	if meta.IsKeyset() {
+		return meta.KeysetMeta(c.Request.URL, total, records, pagination.SortFields(params))
+	}
+	return meta.Meta(total), meta.Links(c.Request.URL, total)
+}
+
+/*
+DecodeAttributes reads the permitted attributes of the JSON:API request body into target, other attributes are dropped.
+Unlike SetData it keeps nothing on the handler, which all requests share, so concurrent requests can't mix their bodies
+*/
+func DecodeAttributes(c *gin.Context, permitted []string, target interface{}) rest_errors.RestErr {
+	var body struct {
+		Data struct {
+			Attributes map[string]json.RawMessage `json:"attributes"`
+		} `json:"data"`
+	}
+	data, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		return rest_errors.NewBadRequestError("invalid json body")
+	}
+	if err := json.Unmarshal(data, &body); err != nil {
+		return rest_errors.NewBadRequestError("invalid json body")
+	}
+	if body.Data.Attributes == nil {
+		return rest_errors.NewBadRequestError("Attributes not found")
 	}
 
-	return params
+	for key := range body.Data.Attributes {
+		if !slices.Contains(permitted, key) {
+			delete(body.Data.Attributes, key)
+		}
+	}
+	attributes, _ := json.Marshal(body.Data.Attributes)
+	if err := json.Unmarshal(attributes, target); err != nil {
+		return rest_errors.NewBadRequestError("invalid attributes: " + err.Error())
+	}
+	return nil
 }
 
 func GetIdFromUrl(c *gin.Context, fromQuery bool) (int64, rest_errors.RestErr) {
@@ -54,6 +136,14 @@ func GetIdFromUrl(c *gin.Context, fromQuery bool) (int64, rest_errors.RestErr) {
 	return id, nil
 }
 
+func GetNumericParamFromUrl(c *gin.Context, keyName string) (int64, rest_errors.RestErr) {
+	id, err := strconv.ParseInt(c.Param(keyName), 10, 64)
+	if err != nil {
+		return 0, rest_errors.NewBadRequestError(fmt.Sprintf("%s should be a number", keyName))
+	}
+	return id, nil
+}
+
 func GetIdentifierFromUrl(c *gin.Context, keyName string, fromQuery bool) string {
 	identifier := c.Param(keyName)
 	if fromQuery {
@@ -0,0 +1,341 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mitchellh/mapstructure"
+	"resturants-hub.com/m/v2/authorizer"
+	"resturants-hub.com/m/v2/dao"
+	"resturants-hub.com/m/v2/dto"
+	rest_errors "resturants-hub.com/m/v2/packages/utils"
+	"resturants-hub.com/m/v2/serializers"
+)
+
+type DishesHandler interface {
+	Create(c *gin.Context)
+	Get(c *gin.Context)
+	List(c *gin.Context)
+	Update(c *gin.Context)
+	Delete(c *gin.Context)
+}
+
+type dishesHandler struct {
+	dao           dao.DishesDao
+	categoriesDao dao.MenuCategoriesDao
+	base          BaseHandler
+}
+
+func NewDishesHandler() DishesHandler {
+	return &dishesHandler{
+		dao:           dao.NewDishesDao(),
+		categoriesDao: dao.NewMenuCategoriesDao(),
+		base:          NewBaseHandler(),
+	}
+}
+
+/* Query params dishes are filtered and sorted on, tags are filtered through the dish_tags (see DishesDao) */
+var dishFilters = []string{"name", "category_id", "tags", "allergens", "dietary", "price", "rating_average", "rating_count"}
+
+/*
+restaurantId resolves the restaurant the dishes belong to. Admin routes carry the restaurant id
+in the URL (/restaurants/:id/dishes) while manager routes (/my-restaurant/dishes) use the
+restaurant of the current user.
+*/
+func (ctr *dishesHandler) restaurantId(c *gin.Context) (int64, rest_errors.RestErr) {
+	if c.Param("id") != "" {
+		return GetIdFromUrl(c, false)
+	}
+
+	currentUser := ctr.base.CurrentUser(c)
+	if !currentUser.RestaurantId.Valid {
+		return 0, rest_errors.NewNotFoundError("Sorry, you are not managing any restaurant yet")
+	}
+	return currentUser.RestaurantId.Int64, nil
+}
+
+/*
+dishMenu takes the menu attributes (category, tags, allergens...) out of the payload data: they are validated
+here and stored apart from the other attributes of the dish. The category has to be one of the restaurant.
+*/
+func (ctr *dishesHandler) dishMenu(restaurantId int64, data map[string]interface{}) (*dto.DishMenuPayload, rest_errors.RestErr) {
+	given := map[string]interface{}{}
+	for _, attribute := range dto.DishMenuAttributes {
+		if value, exists := data[attribute]; exists {
+			given[attribute] = value
+			delete(data, attribute)
+		}
+	}
+
+	menu := &dto.DishMenuPayload{}
+	encoded, _ := json.Marshal(given)
+	if err := json.Unmarshal(encoded, menu); err != nil {
+		return nil, rest_errors.NewBadRequestError(fmt.Sprintf("invalid menu attributes: %s", err.Error()))
+	}
+	for attribute := range given {
+		menu.Given = append(menu.Given, attribute)
+	}
+	if causes := menu.Validate(); causes != nil {
+		return nil, rest_errors.NewValidationError(causes)
+	}
+
+	if menu.CategoryId != nil {
+		if _, getErr := ctr.categoriesDao.GetMenuCategory(&restaurantId, menu.CategoryId); getErr != nil {
+			causes := rest_errors.ValidationErrs{"categoryId": {map[string]interface{}{"error": "not_found", "categoryId": *menu.CategoryId}}}
+			return nil, rest_errors.NewValidationError(&causes)
+		}
+	}
+	return menu, nil
+}
+
+func (ctr *dishesHandler) Create(c *gin.Context) {
+	restaurantId, idErr := ctr.restaurantId(c)
+	if idErr != nil {
+		c.JSON(idErr.Status(), idErr)
+		return
+	}
+
+	/* Authorize request for current user, dishes are always created for the restaurant in the URL (or the manager's restaurant) */
+	currentUser := ctr.base.CurrentUser(c)
+	authorizer := authorizer.NewDishAuthorizer(currentUser, restaurantId)
+	permissions, restErr := authorizer.Authorize("create")
+	if restErr != nil {
+		c.JSON(restErr.Status(), restErr)
+		return
+	}
+
+	meta := map[string]interface{}{
+		"permissions": permissions,
+	}
+
+	/* Extract request body as map */
+	var mapBody map[string]interface{}
+	data, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		restErr := rest_errors.NewBadRequestError("invalid json body")
+		c.JSON(restErr.Status(), restErr)
+		return
+	}
+
+	/* extract data as json/map  */
+	json.Unmarshal(data, &mapBody)
+
+	/* Parse jsonapi payload and set attributes to data*/
+	payload := ctr.base.SetData(mapBody)
+	menu, menuErr := ctr.dishMenu(restaurantId, payload.Data)
+	if menuErr != nil {
+		c.JSON(menuErr.Status(), menuErr)
+		return
+	}
+	newRecord := &dto.CreateDishPayload{}
+	mapstructure.Decode(payload.Data, &newRecord)
+
+	newRecord.RestaurantId = restaurantId
+
+	/* Validate payload data */
+	if err := Validate.Struct(newRecord); err != nil {
+		restErr := rest_errors.NewValidationError(rest_errors.StructValidationErrors(err))
+		c.JSON(restErr.Status(), restErr)
+		return
+	}
+
+	dish, createErr := ctr.dao.CreateDish(newRecord, menu)
+	if createErr != nil {
+		c.JSON(createErr.Status(), createErr)
+		return
+	}
+
+	resource := dish.MemberFor(currentUser.Role)
+	jsonPayload := serializers.NewMemberSerializer(resource, nil, nil, meta)
+	c.JSON(http.StatusOK, jsonPayload)
+}
+
+func (ctr *dishesHandler) Get(c *gin.Context) {
+	restaurantId, idErr := ctr.restaurantId(c)
+	if idErr != nil {
+		c.JSON(idErr.Status(), idErr)
+		return
+	}
+
+	dishId, idErr := GetNumericParamFromUrl(c, "dishId")
+	if idErr != nil {
+		c.JSON(idErr.Status(), idErr)
+		return
+	}
+
+	dish, getErr := ctr.dao.GetDish(&restaurantId, &dishId)
+	if getErr != nil {
+		c.JSON(getErr.Status(), getErr)
+		return
+	}
+
+	/* Authorize access to resource */
+	currentUser := ctr.base.CurrentUser(c)
+	authorizer := authorizer.NewDishAuthorizer(currentUser, dish.RestaurantId)
+	permissions, restErr := authorizer.Authorize("access")
+	if restErr != nil {
+		c.JSON(restErr.Status(), restErr)
+		return
+	}
+
+	meta := map[string]interface{}{
+		"permissions": permissions,
+	}
+
+	resource := dish.MemberFor(currentUser.Role)
+	jsonapi := serializers.NewMemberSerializer(resource, nil, nil, meta)
+	c.JSON(http.StatusOK, jsonapi)
+}
+
+func (ctr *dishesHandler) Update(c *gin.Context) {
+	restaurantId, idErr := ctr.restaurantId(c)
+	if idErr != nil {
+		c.JSON(idErr.Status(), idErr)
+		return
+	}
+
+	dishId, idErr := GetNumericParamFromUrl(c, "dishId")
+	if idErr != nil {
+		c.JSON(idErr.Status(), idErr)
+		return
+	}
+
+	/* Check if dish exists with given Id */
+	record, getErr := ctr.dao.GetDish(&restaurantId, &dishId)
+	if getErr != nil {
+		c.JSON(getErr.Status(), getErr)
+		return
+	}
+
+	currentUser := ctr.base.CurrentUser(c)
+	/* Authorize request for current user */
+	authorizer := authorizer.NewDishAuthorizer(currentUser, record.RestaurantId)
+	permissions, restErr := authorizer.Authorize("update")
+	if restErr != nil {
+		c.JSON(restErr.Status(), restErr)
+		return
+	}
+
+	meta := map[string]interface{}{
+		"permissions": permissions,
+	}
+
+	/* Extract request body as map */
+	var mapBody map[string]interface{}
+	jsonData, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		restErr := rest_errors.NewBadRequestError("invalid json body")
+		c.JSON(restErr.Status(), restErr)
+		return
+	}
+
+	/* Validate required params and whitelisted payload data */
+	json.Unmarshal(jsonData, &mapBody)
+	payload := ctr.base.SetData(mapBody)
+	payload.Permit(record.UpdableAttributes(currentUser.Role))
+
+	/* Menu attributes are read first, so that a null category can take the dish out of its category */
+	menu, menuErr := ctr.dishMenu(record.RestaurantId, payload.Data)
+	if menuErr != nil {
+		c.JSON(menuErr.Status(), menuErr)
+		return
+	}
+
+	/* Skip empty data and patch with only new data if the update is partial(PATCH) */
+	isPartial := c.Request.Method == http.MethodPatch
+	if isPartial {
+		payload.ClearEmpty()
+	}
+
+	/* Return error if payload has eroor for require/permit */
+	if len(payload.Errors) > 0 {
+		c.JSON(payload.Errors[0].Status(), payload.Errors)
+		return
+	}
+
+	result, updateErr := ctr.dao.UpdateDish(record, payload.Data, menu)
+	if updateErr != nil {
+		c.JSON(updateErr.Status(), updateErr)
+		return
+	}
+
+	resource := result.MemberFor(currentUser.Role)
+	jsonPayload := serializers.NewMemberSerializer(resource, nil, nil, meta)
+	c.JSON(http.StatusOK, jsonPayload)
+}
+
+func (ctr *dishesHandler) Delete(c *gin.Context) {
+	restaurantId, idErr := ctr.restaurantId(c)
+	if idErr != nil {
+		c.JSON(idErr.Status(), idErr)
+		return
+	}
+
+	dishId, idErr := GetNumericParamFromUrl(c, "dishId")
+	if idErr != nil {
+		c.JSON(idErr.Status(), idErr)
+		return
+	}
+
+	/* Check if dish exists with given Id */
+	record, getErr := ctr.dao.GetDish(&restaurantId, &dishId)
+	if getErr != nil {
+		c.JSON(getErr.Status(), getErr)
+		return
+	}
+
+	/* Authorize request for current user */
+	currentUser := ctr.base.CurrentUser(c)
+	authorizer := authorizer.NewDishAuthorizer(currentUser, record.RestaurantId)
+	_, restErr := authorizer.Authorize("delete")
+	if restErr != nil {
+		c.JSON(restErr.Status(), restErr)
+		return
+	}
+
+	if deleteErr := ctr.dao.DeleteDish(record); deleteErr != nil {
+		c.JSON(deleteErr.Status(), deleteErr)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+func (ctr *dishesHandler) List(c *gin.Context) {
+	restaurantId, idErr := ctr.restaurantId(c)
+	if idErr != nil {
+		c.JSON(idErr.Status(), idErr)
+		return
+	}
+
+	/* Authorize request for current user */
+	currentUser := ctr.base.CurrentUser(c)
+	authorizer := authorizer.NewDishAuthorizer(currentUser, restaurantId)
+	_, restErr := authorizer.Authorize("accessCollection")
+	if restErr != nil {
+		c.JSON(restErr.Status(), restErr)
+		return
+	}
+
+	params, paramsErr := WhitelistQueryParams(c, append([]string{"enable_reviews", "created_at"}, dishFilters...))
+	if paramsErr != nil {
+		c.JSON(paramsErr.Status(), paramsErr)
+		return
+	}
+	params.Set("restaurant_id", fmt.Sprint(restaurantId))
+
+	// Get authorized collection of dishes
+	result, total, err := ctr.dao.AuthorizedDishesCollection(params, currentUser)
+	if err != nil {
+		c.JSON(err.Status(), err)
+		return
+	}
+	meta, links := PaginationMeta(c, params, total, result)
+
+	collection := result.CollectionFor(currentUser.Role)
+	jsonapi := serializers.NewCollectionSerializer(collection, meta, links)
+	c.JSON(http.StatusOK, jsonapi)
+}
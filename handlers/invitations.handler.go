@@ -187,18 +187,20 @@ func (ctr *invitationsHandler) List(c *gin.Context) {
 		return
 	}
 
-	params := WhitelistQueryParams(c, []string{"email", "token", "expires_at"})
-	result, err := ctr.dao.AuthorizedInvitationsCollection(params, ctr.base.CurrentUser(c))
+	params, paramsErr := WhitelistQueryParams(c, []string{"email", "token", "role", "expires_at", "created_at"})
+	if paramsErr != nil {
+		c.JSON(paramsErr.Status(), paramsErr)
+		return
+	}
+	result, total, err := ctr.dao.AuthorizedInvitationsCollection(params, ctr.base.CurrentUser(c))
 	if err != nil {
 		c.JSON(err.Status(), err)
 		return
 	}
 
-	meta := map[string]interface{}{
-		"total": len(result),
-	}
+	meta, links := PaginationMeta(c, params, total, result)
 
 	collection := result.CollectionFor()
-	jsonapi := serializers.NewCollectionSerializer(collection, meta)
+	jsonapi := serializers.NewCollectionSerializer(collection, meta, links)
 	c.JSON(http.StatusOK, jsonapi)
 }
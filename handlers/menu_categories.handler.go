@@ -0,0 +1,234 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mitchellh/mapstructure"
+	"resturants-hub.com/m/v2/authorizer"
+	"resturants-hub.com/m/v2/dao"
+	"resturants-hub.com/m/v2/dto"
+	rest_errors "resturants-hub.com/m/v2/packages/utils"
+	"resturants-hub.com/m/v2/serializers"
+)
+
+type MenuCategoriesHandler interface {
+	Create(c *gin.Context)
+	Get(c *gin.Context)
+	List(c *gin.Context)
+	Update(c *gin.Context)
+	Delete(c *gin.Context)
+}
+
+type menuCategoriesHandler struct {
+	dao  dao.MenuCategoriesDao
+	base BaseHandler
+}
+
+func NewMenuCategoriesHandler() MenuCategoriesHandler {
+	return &menuCategoriesHandler{
+		dao:  dao.NewMenuCategoriesDao(),
+		base: NewBaseHandler(),
+	}
+}
+
+func (ctr *menuCategoriesHandler) Create(c *gin.Context) {
+	currentUser := ctr.base.CurrentUser(c)
+	restaurantId, idErr := managedRestaurantId(c, currentUser)
+	if idErr != nil {
+		c.JSON(idErr.Status(), idErr)
+		return
+	}
+
+	/* Extract request body as map */
+	var mapBody map[string]interface{}
+	data, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		restErr := rest_errors.NewBadRequestError("invalid json body")
+		c.JSON(restErr.Status(), restErr)
+		return
+	}
+	json.Unmarshal(data, &mapBody)
+
+	/* Parse jsonapi payload and set attributes to data*/
+	payload := ctr.base.SetData(mapBody)
+	newRecord := &dto.CreateMenuCategoryPayload{}
+	mapstructure.Decode(payload.Data, &newRecord)
+
+	/* Categories are always created for the restaurant in the URL (or the manager's restaurant) */
+	newRecord.RestaurantId = restaurantId
+
+	authorizer := authorizer.NewMenuCategoryAuthorizer(currentUser, newRecord.RestaurantId)
+	permissions, restErr := authorizer.Authorize("create")
+	if restErr != nil {
+		c.JSON(restErr.Status(), restErr)
+		return
+	}
+
+	meta := map[string]interface{}{
+		"permissions": permissions,
+	}
+
+	if err := Validate.Struct(newRecord); err != nil {
+		restErr := rest_errors.NewValidationError(rest_errors.StructValidationErrors(err))
+		c.JSON(restErr.Status(), restErr)
+		return
+	}
+
+	category, createErr := ctr.dao.CreateMenuCategory(newRecord)
+	if createErr != nil {
+		c.JSON(createErr.Status(), createErr)
+		return
+	}
+
+	resource := category.MemberFor(currentUser.Role)
+	jsonPayload := serializers.NewMemberSerializer(resource, nil, nil, meta)
+	c.JSON(http.StatusOK, jsonPayload)
+}
+
+/* category finds the menu category of the :categoryId param and authorizes the action on it for the current user */
+func (ctr *menuCategoriesHandler) category(c *gin.Context, action string) (*dto.MenuCategory, interface{}, rest_errors.RestErr) {
+	currentUser := ctr.base.CurrentUser(c)
+	restaurantId, idErr := managedRestaurantId(c, currentUser)
+	if idErr != nil {
+		return nil, nil, idErr
+	}
+
+	categoryId, idErr := GetNumericParamFromUrl(c, "categoryId")
+	if idErr != nil {
+		return nil, nil, idErr
+	}
+
+	category, getErr := ctr.dao.GetMenuCategory(&restaurantId, &categoryId)
+	if getErr != nil {
+		return nil, nil, getErr
+	}
+
+	authorizer := authorizer.NewMenuCategoryAuthorizer(currentUser, category.RestaurantId)
+	permissions, restErr := authorizer.Authorize(action)
+	if restErr != nil {
+		return nil, nil, restErr
+	}
+	return category, permissions, nil
+}
+
+func (ctr *menuCategoriesHandler) Get(c *gin.Context) {
+	category, permissions, restErr := ctr.category(c, "access")
+	if restErr != nil {
+		c.JSON(restErr.Status(), restErr)
+		return
+	}
+
+	meta := map[string]interface{}{
+		"permissions": permissions,
+	}
+
+	resource := category.MemberFor(ctr.base.CurrentUser(c).Role)
+	jsonapi := serializers.NewMemberSerializer(resource, nil, nil, meta)
+	c.JSON(http.StatusOK, jsonapi)
+}
+
+func (ctr *menuCategoriesHandler) Update(c *gin.Context) {
+	record, permissions, restErr := ctr.category(c, "update")
+	if restErr != nil {
+		c.JSON(restErr.Status(), restErr)
+		return
+	}
+
+	meta := map[string]interface{}{
+		"permissions": permissions,
+	}
+
+	/* Extract request body as map */
+	var mapBody map[string]interface{}
+	jsonData, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		restErr := rest_errors.NewBadRequestError("invalid json body")
+		c.JSON(restErr.Status(), restErr)
+		return
+	}
+
+	/* Validate required params and whitelisted payload data */
+	currentUser := ctr.base.CurrentUser(c)
+	json.Unmarshal(jsonData, &mapBody)
+	payload := ctr.base.SetData(mapBody)
+	payload.Permit(record.UpdableAttributes(currentUser.Role))
+
+	/* Skip empty data and patch with only new data if the update is partial(PATCH) */
+	isPartial := c.Request.Method == http.MethodPatch
+	if isPartial {
+		payload.ClearEmpty()
+	}
+
+	/* Return error if payload has eroor for require/permit */
+	if len(payload.Errors) > 0 {
+		c.JSON(payload.Errors[0].Status(), payload.Errors)
+		return
+	}
+
+	result, updateErr := ctr.dao.UpdateMenuCategory(record, payload.Data)
+	if updateErr != nil {
+		c.JSON(updateErr.Status(), updateErr)
+		return
+	}
+
+	resource := result.MemberFor(currentUser.Role)
+	jsonPayload := serializers.NewMemberSerializer(resource, nil, nil, meta)
+	c.JSON(http.StatusOK, jsonPayload)
+}
+
+func (ctr *menuCategoriesHandler) Delete(c *gin.Context) {
+	record, _, restErr := ctr.category(c, "delete")
+	if restErr != nil {
+		c.JSON(restErr.Status(), restErr)
+		return
+	}
+
+	if deleteErr := ctr.dao.DeleteMenuCategory(record); deleteErr != nil {
+		c.JSON(deleteErr.Status(), deleteErr)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+func (ctr *menuCategoriesHandler) List(c *gin.Context) {
+	currentUser := ctr.base.CurrentUser(c)
+	restaurantId, idErr := managedRestaurantId(c, currentUser)
+	if idErr != nil {
+		c.JSON(idErr.Status(), idErr)
+		return
+	}
+
+	authorizer := authorizer.NewMenuCategoryAuthorizer(currentUser, restaurantId)
+	_, restErr := authorizer.Authorize("accessCollection")
+	if restErr != nil {
+		c.JSON(restErr.Status(), restErr)
+		return
+	}
+
+	params, paramsErr := WhitelistQueryParams(c, []string{"name", "position", "created_at"})
+	if paramsErr != nil {
+		c.JSON(paramsErr.Status(), paramsErr)
+		return
+	}
+	/* Categories are listed in the order of the menu unless asked otherwise */
+	if params.Get("sort") == "" {
+		params.Set("sort", "position")
+	}
+	params.Set("restaurant_id", fmt.Sprint(restaurantId))
+
+	result, total, err := ctr.dao.SearchMenuCategories(params)
+	if err != nil {
+		c.JSON(err.Status(), err)
+		return
+	}
+	meta, links := PaginationMeta(c, params, total, result)
+
+	collection := result.CollectionFor(currentUser.Role)
+	jsonapi := serializers.NewCollectionSerializer(collection, meta, links)
+	c.JSON(http.StatusOK, jsonapi)
+}
@@ -0,0 +1,207 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"resturants-hub.com/m/v2/authorizer"
+	"resturants-hub.com/m/v2/dao"
+	"resturants-hub.com/m/v2/dto"
+	rest_errors "resturants-hub.com/m/v2/packages/utils"
+	"resturants-hub.com/m/v2/serializers"
+)
+
+type OrdersHandler interface {
+	Get(c *gin.Context)
+	List(c *gin.Context)
+	Transition(c *gin.Context)
+}
+
+type ordersHandler struct {
+	dao  dao.OrdersDao
+	base BaseHandler
+}
+
+func NewOrdersHandler() OrdersHandler {
+	return &ordersHandler{
+		dao:  dao.NewOrdersDao(),
+		base: NewBaseHandler(),
+	}
+}
+
+/* Attributes an order is placed with by a diner, the cart only has the items */
+var orderAttributes = []string{"customerName", "customerEmail", "customerPhone", "notes", "pickupAt", "items"}
+
+/* decodeOrderBody reads the attributes of the request body into target, pickupAt is an RFC3339 timestamp */
+func decodeOrderBody(c *gin.Context, target interface{}) rest_errors.RestErr {
+	return DecodeAttributes(c, orderAttributes, target)
+}
+
+/* validateCartItems checks an order has between one and dto.MaxOrderItems items */
+func validateCartItems(items []dto.CreateOrderItemPayload) rest_errors.RestErr {
+	var cause string
+	switch {
+	case len(items) == 0:
+		cause = "required"
+	case len(items) > dto.MaxOrderItems:
+		cause = "too_many_items"
+	default:
+		return nil
+	}
+	causes := map[string]interface{}{"error": cause, "max": dto.MaxOrderItems}
+	return rest_errors.NewValidationError(&rest_errors.ValidationErrs{"items": []interface{}{causes}})
+}
+
+/* cartPayload reads the cart of the request body */
+func cartPayload(c *gin.Context) (*dto.CartPayload, rest_errors.RestErr) {
+	cart := &dto.CartPayload{}
+	if restErr := decodeOrderBody(c, cart); restErr != nil {
+		return nil, restErr
+	}
+	if restErr := validateCartItems(cart.Items); restErr != nil {
+		return nil, restErr
+	}
+	if err := Validate.Struct(cart); err != nil {
+		return nil, rest_errors.NewValidationError(rest_errors.StructValidationErrors(err))
+	}
+	return cart, nil
+}
+
+/* orderPayload reads the order of the request body, placed at the restaurant for pickup in the future (or as soon as possible) */
+func orderPayload(c *gin.Context, restaurant *dto.Restaurant) (*dto.CreateOrderPayload, rest_errors.RestErr) {
+	order := &dto.CreateOrderPayload{}
+	if restErr := decodeOrderBody(c, order); restErr != nil {
+		return nil, restErr
+	}
+	order.RestaurantId = restaurant.Id
+
+	if restErr := validateCartItems(order.Items); restErr != nil {
+		return nil, restErr
+	}
+	if err := Validate.Struct(order); err != nil {
+		return nil, rest_errors.NewValidationError(rest_errors.StructValidationErrors(err))
+	}
+	if order.PickupAt.Valid {
+		if !order.PickupAt.Time.After(time.Now()) {
+			causes := map[string]interface{}{"error": "must_be_in_the_future"}
+			return nil, rest_errors.NewValidationError(&rest_errors.ValidationErrs{"pickupAt": []interface{}{causes}})
+		}
+		order.PickupAt.Time = order.PickupAt.Time.UTC()
+	}
+	return order, nil
+}
+
+/* order finds the order of the :orderId param and authorizes the action on it for the current user */
+func (ctr *ordersHandler) order(c *gin.Context, action string) (*dto.Order, interface{}, rest_errors.RestErr) {
+	restaurantId, idErr := managedRestaurantId(c, ctr.base.CurrentUser(c))
+	if idErr != nil {
+		return nil, nil, idErr
+	}
+	orderId, idErr := GetNumericParamFromUrl(c, "orderId")
+	if idErr != nil {
+		return nil, nil, idErr
+	}
+
+	order, getErr := ctr.dao.GetOrder(&restaurantId, &orderId)
+	if getErr != nil {
+		return nil, nil, getErr
+	}
+
+	authorizer := authorizer.NewOrderAuthorizer(ctr.base.CurrentUser(c), order.RestaurantId)
+	permissions, restErr := authorizer.Authorize(action)
+	if restErr != nil {
+		return nil, nil, restErr
+	}
+	return order, permissions, nil
+}
+
+func (ctr *ordersHandler) Get(c *gin.Context) {
+	order, permissions, restErr := ctr.order(c, "access")
+	if restErr != nil {
+		c.JSON(restErr.Status(), restErr)
+		return
+	}
+
+	meta := map[string]interface{}{
+		"permissions": permissions,
+	}
+	resource := order.MemberFor(ctr.base.CurrentUser(c).Role)
+	jsonapi := serializers.NewMemberSerializer(resource, nil, nil, meta)
+	c.JSON(http.StatusOK, jsonapi)
+}
+
+func (ctr *ordersHandler) List(c *gin.Context) {
+	currentUser := ctr.base.CurrentUser(c)
+	restaurantId, idErr := managedRestaurantId(c, currentUser)
+	if idErr != nil {
+		c.JSON(idErr.Status(), idErr)
+		return
+	}
+
+	authorizer := authorizer.NewOrderAuthorizer(currentUser, restaurantId)
+	_, restErr := authorizer.Authorize("accessCollection")
+	if restErr != nil {
+		c.JSON(restErr.Status(), restErr)
+		return
+	}
+
+	params, paramsErr := WhitelistQueryParams(c, []string{"status", "pickup_at", "customer_name", "customer_email", "total", "created_at"})
+	if paramsErr != nil {
+		c.JSON(paramsErr.Status(), paramsErr)
+		return
+	}
+	/* Latest orders first unless asked otherwise */
+	if params.Get("sort") == "" {
+		params.Set("sort", "-created_at")
+	}
+	params.Set("restaurant_id", fmt.Sprint(restaurantId))
+
+	result, total, err := ctr.dao.SearchOrders(params)
+	if err != nil {
+		c.JSON(err.Status(), err)
+		return
+	}
+	meta, links := PaginationMeta(c, params, total, result)
+
+	collection := result.CollectionFor(currentUser.Role)
+	jsonapi := serializers.NewCollectionSerializer(collection, meta, links)
+	c.JSON(http.StatusOK, jsonapi)
+}
+
+/* Transition applies an event of the order lifecycle: POST /orders/:orderId/transitions/:event */
+func (ctr *ordersHandler) Transition(c *gin.Context) {
+	event := c.Param("event")
+	if _, exists := dto.OrderTransitions[event]; !exists {
+		restErr := rest_errors.NewNotFoundError(fmt.Sprintf("Sorry, %s is not an order transition", event))
+		c.JSON(restErr.Status(), restErr)
+		return
+	}
+
+	record, permissions, restErr := ctr.order(c, event)
+	if restErr != nil {
+		c.JSON(restErr.Status(), restErr)
+		return
+	}
+
+	transition, allowed := record.Transition(event)
+	if !allowed {
+		restErr := rest_errors.NewRestError(fmt.Sprintf("A %s order can't be %s", record.Status, event), http.StatusConflict, "conflict", nil)
+		c.JSON(restErr.Status(), restErr)
+		return
+	}
+
+	result, transitionErr := ctr.dao.TransitionOrder(record, transition.To)
+	if transitionErr != nil {
+		c.JSON(transitionErr.Status(), transitionErr)
+		return
+	}
+
+	meta := map[string]interface{}{
+		"permissions": permissions,
+	}
+	resource := result.MemberFor(ctr.base.CurrentUser(c).Role)
+	jsonPayload := serializers.NewMemberSerializer(resource, nil, nil, meta)
+	c.JSON(http.StatusOK, jsonPayload)
+}
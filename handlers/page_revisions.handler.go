@@ -0,0 +1,198 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"resturants-hub.com/m/v2/authorizer"
+	"resturants-hub.com/m/v2/dao"
+	"resturants-hub.com/m/v2/dto"
+	rest_errors "resturants-hub.com/m/v2/packages/utils"
+	"resturants-hub.com/m/v2/serializers"
+)
+
+type PageRevisionsHandler interface {
+	List(c *gin.Context)
+	Get(c *gin.Context)
+	Diff(c *gin.Context)
+	Restore(c *gin.Context)
+}
+
+type pageRevisionsHandler struct {
+	dao      dao.PageRevisionsDao
+	pagesDao dao.PagesDao
+	base     BaseHandler
+}
+
+func NewPageRevisionsHandler() PageRevisionsHandler {
+	return &pageRevisionsHandler{
+		dao:      dao.NewPageRevisionsDao(),
+		pagesDao: dao.NewPageDao(),
+		base:     NewBaseHandler(),
+	}
+}
+
+/* page finds the page of the :slug param and authorizes the action on it for the current user */
+func (ctr *pageRevisionsHandler) page(c *gin.Context, action string) (*dto.Page, interface{}, rest_errors.RestErr) {
+	slug := GetIdentifierFromUrl(c, "slug", false)
+	if slug == "" {
+		return nil, nil, rest_errors.NewBadRequestError("slug is required")
+	}
+
+	restaurantId, restaurantErr := pageRestaurantId(c, ctr.base.CurrentUser(c))
+	if restaurantErr != nil {
+		return nil, nil, restaurantErr
+	}
+
+	page, getErr := ctr.pagesDao.Get(restaurantId, &slug)
+	if getErr != nil {
+		return nil, nil, getErr
+	}
+
+	authorizer := authorizer.NewPageAuthorizer(ctr.base.CurrentUser(c), page.AuthorId)
+	permissions, restErr := authorizer.Authorize(action)
+	if restErr != nil {
+		return nil, nil, restErr
+	}
+	return page, permissions, nil
+}
+
+func (ctr *pageRevisionsHandler) List(c *gin.Context) {
+	page, _, restErr := ctr.page(c, "access")
+	if restErr != nil {
+		c.JSON(restErr.Status(), restErr)
+		return
+	}
+
+	params, paramsErr := WhitelistQueryParams(c, []string{"number", "created_at"})
+	if paramsErr != nil {
+		c.JSON(paramsErr.Status(), paramsErr)
+		return
+	}
+	/* Latest revisions first unless asked otherwise */
+	if params.Get("sort") == "" {
+		params.Set("sort", "-number")
+	}
+
+	result, total, err := ctr.dao.Revisions(page.Id, params)
+	if err != nil {
+		c.JSON(err.Status(), err)
+		return
+	}
+	meta, links := PaginationMeta(c, params, total, result)
+
+	jsonapi := serializers.NewCollectionSerializer(result.CollectionFor(), meta, links)
+	c.JSON(http.StatusOK, jsonapi)
+}
+
+func (ctr *pageRevisionsHandler) Get(c *gin.Context) {
+	page, permissions, restErr := ctr.page(c, "access")
+	if restErr != nil {
+		c.JSON(restErr.Status(), restErr)
+		return
+	}
+
+	id, idErr := GetNumericParamFromUrl(c, "revisionId")
+	if idErr != nil {
+		c.JSON(idErr.Status(), idErr)
+		return
+	}
+
+	revision, getErr := ctr.dao.GetRevision(page.Id, id)
+	if getErr != nil {
+		c.JSON(getErr.Status(), getErr)
+		return
+	}
+
+	meta := map[string]interface{}{
+		"permissions": permissions,
+	}
+	jsonapi := serializers.NewMemberSerializer(revision.MemberFor(), nil, nil, meta)
+	c.JSON(http.StatusOK, jsonapi)
+}
+
+/* Diff compares two revisions (?from=&to= revision ids) field by field, to defaults to the current content */
+func (ctr *pageRevisionsHandler) Diff(c *gin.Context) {
+	page, _, restErr := ctr.page(c, "access")
+	if restErr != nil {
+		c.JSON(restErr.Status(), restErr)
+		return
+	}
+
+	fromId, err := strconv.ParseInt(c.Query("from"), 10, 64)
+	if err != nil {
+		paramErr := rest_errors.NewBadRequestError("from should be a revision id")
+		c.JSON(paramErr.Status(), paramErr)
+		return
+	}
+	from, getErr := ctr.dao.GetRevision(page.Id, fromId)
+	if getErr != nil {
+		c.JSON(getErr.Status(), getErr)
+		return
+	}
+
+	var to *dto.PageRevision
+	if c.Query("to") == "" {
+		to, getErr = ctr.dao.LatestRevision(page.Id)
+	} else {
+		toId, err := strconv.ParseInt(c.Query("to"), 10, 64)
+		if err != nil {
+			paramErr := rest_errors.NewBadRequestError("to should be a revision id")
+			c.JSON(paramErr.Status(), paramErr)
+			return
+		}
+		to, getErr = ctr.dao.GetRevision(page.Id, toId)
+	}
+	if getErr != nil {
+		c.JSON(getErr.Status(), getErr)
+		return
+	}
+
+	c.JSON(http.StatusOK, map[string]interface{}{
+		"data": map[string]interface{}{
+			"from":   from.MemberFor(),
+			"to":     to.MemberFor(),
+			"fields": from.Diff(to),
+		},
+	})
+}
+
+/* Restore puts the content of a revision back on the page, which is saved as a new revision */
+func (ctr *pageRevisionsHandler) Restore(c *gin.Context) {
+	page, permissions, restErr := ctr.page(c, "update")
+	if restErr != nil {
+		c.JSON(restErr.Status(), restErr)
+		return
+	}
+
+	id, idErr := GetNumericParamFromUrl(c, "revisionId")
+	if idErr != nil {
+		c.JSON(idErr.Status(), idErr)
+		return
+	}
+
+	revision, getErr := ctr.dao.GetRevision(page.Id, id)
+	if getErr != nil {
+		c.JSON(getErr.Status(), getErr)
+		return
+	}
+
+	result, updateErr := ctr.pagesDao.Update(page, map[string]interface{}{
+		"title":   revision.Title,
+		"excerpt": revision.Excerpt,
+		"body":    revision.Body,
+		"format":  revision.Format,
+	})
+	if updateErr != nil {
+		c.JSON(updateErr.Status(), updateErr)
+		return
+	}
+
+	meta := map[string]interface{}{
+		"permissions": permissions,
+	}
+	resource := result.MemberFor(ctr.base.CurrentUser(c).Role)
+	jsonapi := serializers.NewMemberSerializer(resource, nil, nil, meta)
+	c.JSON(http.StatusOK, jsonapi)
+}
@@ -1,15 +1,25 @@
 package handlers
 
 import (
+	"database/sql"
 	"encoding/json"
+	"fmt"
 	"io"
+	"math"
 	"net/http"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/gosimple/slug"
 	"github.com/mitchellh/mapstructure"
+	"golang.org/x/exp/slices"
 	"resturants-hub.com/m/v2/authorizer"
 	"resturants-hub.com/m/v2/dao"
 	"resturants-hub.com/m/v2/dto"
+	"resturants-hub.com/m/v2/packages/markup"
+	"resturants-hub.com/m/v2/packages/types"
 	rest_errors "resturants-hub.com/m/v2/packages/utils"
 	"resturants-hub.com/m/v2/serializers"
 )
@@ -19,6 +29,10 @@ type PagesHandler interface {
 	GetPage(c *gin.Context)
 	ListPages(c *gin.Context)
 	UpdatePage(c *gin.Context)
+	DeletePage(c *gin.Context)
+	RestorePage(c *gin.Context)
+	PageTree(c *gin.Context)
+	TransitionPage(c *gin.Context)
 }
 
 type pagesHandler struct {
@@ -70,17 +84,65 @@ func (ctr *pagesHandler) CreatePage(c *gin.Context) {
 		"permissions": permissions,
 	}
 
-	/* Generate slug for new record */
-	newRecord.Slug = ctr.dao.GenerateSlug(newRecord.Title)
-
 	/* Set authorId to current user */
 	newRecord.AuthorId = currentUser.Id
 
+	/* Pages start as drafts, their visibility then changes through the workflow transitions */
+	newRecord.Visibility = dto.PageDraft
+
+	/* Bodies are HTML unless told otherwise, like the pages created before formats existed */
+	if newRecord.Format == "" {
+		newRecord.Format = markup.Html
+	}
+
+	/* Admins create pages in the restaurant of the url (/restaurants/:id/pages) or of the payload */
+	restaurantId, restaurantErr := nullIntValue("restaurantId", payload.Data["restaurantId"])
+	if restaurantErr != nil {
+		c.JSON(restaurantErr.Status(), restaurantErr)
+		return
+	}
+	if c.Param("id") != "" {
+		id, idErr := GetIdFromUrl(c, false)
+		if idErr != nil {
+			c.JSON(idErr.Status(), idErr)
+			return
+		}
+		restaurantId = types.NullInt{NullInt64: sql.NullInt64{Int64: id, Valid: true}}
+	}
+	newRecord.RestaurantId = restaurantId
+
 	/* Set restaurantId to current user if current user is manager */
 	if currentUser.IsManager() {
+		if c.Param("id") != "" && restaurantId != currentUser.RestaurantId {
+			forbiddenErr := rest_errors.NewForbiddenError("You are not allowed to perform this action")
+			c.JSON(forbiddenErr.Status(), forbiddenErr)
+			return
+		}
 		newRecord.RestaurantId = currentUser.RestaurantId
 	}
 
+	/* Generate slug for new record, unique among the pages of the restaurant */
+	if newRecord.RestaurantId.Valid {
+		pageSlug, slugErr := ctr.dao.GenerateSlug(newRecord.RestaurantId.Int64, newRecord.Title)
+		if slugErr != nil {
+			c.JSON(slugErr.Status(), slugErr)
+			return
+		}
+		newRecord.Slug = pageSlug
+	}
+
+	/* The parent page must belong to the same restaurant */
+	if parentPageId, parentErr := nullIntValue("parentPageId", payload.Data["parentPageId"]); parentErr != nil {
+		c.JSON(parentErr.Status(), parentErr)
+		return
+	} else if parentPageId.Valid {
+		if parentErr := ctr.dao.ValidateParent(0, newRecord.RestaurantId.Int64, parentPageId.Int64); parentErr != nil {
+			c.JSON(parentErr.Status(), parentErr)
+			return
+		}
+		newRecord.ParentPageId = parentPageId
+	}
+
 	/* Validate payload data */
 	if err := Validate.Struct(newRecord); err != nil {
 		restErr := rest_errors.NewValidationError(rest_errors.StructValidationErrors(err))
@@ -107,8 +169,19 @@ func (ctr *pagesHandler) GetPage(c *gin.Context) {
 		return
 	}
 
-	restaurant, getErr := ctr.dao.Get(&slug)
+	restaurantId, restaurantErr := pageRestaurantId(c, ctr.base.CurrentUser(c))
+	if restaurantErr != nil {
+		c.JSON(restaurantErr.Status(), restaurantErr)
+		return
+	}
+
+	restaurant, getErr := ctr.dao.Get(restaurantId, &slug)
 	if getErr != nil {
+		/* Links to the former slug of a page lead to its current slug */
+		if page, formerErr := ctr.dao.FormerSlug(restaurantId, &slug); formerErr == nil {
+			redirectToSlug(c, slug, page.Slug)
+			return
+		}
 		c.JSON(getErr.Status(), getErr)
 		return
 	}
@@ -132,15 +205,21 @@ func (ctr *pagesHandler) GetPage(c *gin.Context) {
 }
 
 func (ctr *pagesHandler) UpdatePage(c *gin.Context) {
-	slug := GetIdentifierFromUrl(c, "slug", true)
+	slug := GetIdentifierFromUrl(c, "slug", false)
 	if slug == "" {
 		slugErr := rest_errors.NewBadRequestError("slug is required")
 		c.JSON(slugErr.Status(), slugErr)
 		return
 	}
 
-	/* Check if restaurant exists with given Id */
-	record, getErr := ctr.dao.Get(&slug)
+	/* Check if page exists with given slug */
+	restaurantId, restaurantErr := pageRestaurantId(c, ctr.base.CurrentUser(c))
+	if restaurantErr != nil {
+		c.JSON(restaurantErr.Status(), restaurantErr)
+		return
+	}
+
+	record, getErr := ctr.dao.Get(restaurantId, &slug)
 	if getErr != nil {
 		c.JSON(getErr.Status(), getErr)
 		return
@@ -149,7 +228,7 @@ func (ctr *pagesHandler) UpdatePage(c *gin.Context) {
 	currentUser := ctr.base.CurrentUser(c)
 	/* Authorize request for current user */
 	authorizer := authorizer.NewPageAuthorizer(currentUser, record.AuthorId)
-	permissions, restErr := authorizer.Authorize("access")
+	permissions, restErr := authorizer.Authorize("update")
 	if restErr != nil {
 		c.JSON(restErr.Status(), restErr)
 		return
@@ -173,6 +252,13 @@ func (ctr *pagesHandler) UpdatePage(c *gin.Context) {
 	payload := ctr.base.SetData(mapBody)
 	payload.Permit(record.UpdableAttributes(currentUser.Role))
 
+	/* Re-parenting is checked for cycles before empty data is skipped, as a null parentPageId moves the page to the root */
+	parentPageId, position, moved, placementErr := ctr.pagePlacement(record, payload.Data)
+	if placementErr != nil {
+		c.JSON(placementErr.Status(), placementErr)
+		return
+	}
+
 	/* Skip empty data and patch with only new data if the update is partial(PATCH) */
 	isPartial := c.Request.Method == http.MethodPatch
 	if isPartial {
@@ -185,12 +271,42 @@ func (ctr *pagesHandler) UpdatePage(c *gin.Context) {
 		return
 	}
 
-	result, updateErr := ctr.dao.Update(record, payload.Data)
-	if updateErr != nil {
-		c.JSON(updateErr.Status(), updateErr)
+	/* Renamed pages keep being found through their former slug */
+	if value, exists := payload.Data["slug"]; exists {
+		pageSlug, valid := normalizeSlug(value)
+		if !valid {
+			causes := rest_errors.ValidationErrs{"slug": []interface{}{map[string]interface{}{"error": "invalid_slug", "provided": value}}}
+			c.JSON(http.StatusUnprocessableEntity, rest_errors.NewValidationError(&causes))
+			return
+		}
+		payload.Data["slug"] = pageSlug
+	}
+
+	if format, exists := payload.Data["format"]; exists && !slices.Contains(markup.Formats, fmt.Sprint(format)) {
+		causes := rest_errors.ValidationErrs{"format": []interface{}{map[string]interface{}{"error": "unsupported_format", "supported": markup.Formats}}}
+		c.JSON(http.StatusUnprocessableEntity, rest_errors.NewValidationError(&causes))
 		return
 	}
 
+	result := record
+	if len(payload.Data) > 0 {
+		updated, updateErr := ctr.dao.Update(record, payload.Data)
+		if updateErr != nil {
+			c.JSON(updateErr.Status(), updateErr)
+			return
+		}
+		result = updated
+	}
+
+	if moved {
+		movedPage, moveErr := ctr.dao.Move(result, parentPageId, position)
+		if moveErr != nil {
+			c.JSON(moveErr.Status(), moveErr)
+			return
+		}
+		result = movedPage
+	}
+
 	resource := result.MemberFor(currentUser.Role)
 	jsonPayload := serializers.NewMemberSerializer(resource, nil, nil, meta)
 	c.JSON(http.StatusOK, jsonPayload)
@@ -206,19 +322,380 @@ func (ctr *pagesHandler) ListPages(c *gin.Context) {
 		return
 	}
 
-	params := WhitelistQueryParams(c, []string{"author_id", "title", "restaurant_id", "visibility"})
+	params, paramsErr := WhitelistQueryParams(c, []string{"author_id", "title", "restaurant_id", "parent_page_id", "position", "visibility", "publish_at", "unpublish_at", "created_at", "updated_at", dao.DeletedFilterParam})
+	if paramsErr != nil {
+		c.JSON(paramsErr.Status(), paramsErr)
+		return
+	}
+
+	/* Pages of the restaurant of the url (/restaurants/:id/pages) */
+	if c.Param("id") != "" {
+		params.Set("restaurant_id", c.Param("id"))
+	}
 
 	// Get authorized collection of restaurants
-	result, err := ctr.dao.AuthorizedCollection(params, ctr.base.CurrentUser(c))
+	result, total, err := ctr.dao.AuthorizedCollection(params, ctr.base.CurrentUser(c))
 	if err != nil {
 		c.JSON(err.Status(), err)
 		return
 	}
+	meta, links := PaginationMeta(c, params, total, result)
+
+	collection := result.CollectionFor(currentUser.Role)
+	jsonapi := serializers.NewCollectionSerializer(collection, meta, links)
+	c.JSON(http.StatusOK, jsonapi)
+}
+
+func (ctr *pagesHandler) DeletePage(c *gin.Context) {
+	slug := GetIdentifierFromUrl(c, "slug", false)
+	if slug == "" {
+		slugErr := rest_errors.NewBadRequestError("slug is required")
+		c.JSON(slugErr.Status(), slugErr)
+		return
+	}
+
+	/* Check if page exists with given slug */
+	restaurantId, restaurantErr := pageRestaurantId(c, ctr.base.CurrentUser(c))
+	if restaurantErr != nil {
+		c.JSON(restaurantErr.Status(), restaurantErr)
+		return
+	}
+
+	record, getErr := ctr.dao.Get(restaurantId, &slug)
+	if getErr != nil {
+		c.JSON(getErr.Status(), getErr)
+		return
+	}
+
+	/* Authorize request for current user */
+	currentUser := ctr.base.CurrentUser(c)
+	authorizer := authorizer.NewPageAuthorizer(currentUser, record.AuthorId)
+	_, restErr := authorizer.Authorize("delete")
+	if restErr != nil {
+		c.JSON(restErr.Status(), restErr)
+		return
+	}
+
+	if deleteErr := ctr.dao.Delete(record); deleteErr != nil {
+		c.JSON(deleteErr.Status(), deleteErr)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+func (ctr *pagesHandler) RestorePage(c *gin.Context) {
+	slug := GetIdentifierFromUrl(c, "slug", false)
+	if slug == "" {
+		slugErr := rest_errors.NewBadRequestError("slug is required")
+		c.JSON(slugErr.Status(), slugErr)
+		return
+	}
+
+	/* Only deleted pages can be restored */
+	restaurantId, restaurantErr := pageRestaurantId(c, ctr.base.CurrentUser(c))
+	if restaurantErr != nil {
+		c.JSON(restaurantErr.Status(), restaurantErr)
+		return
+	}
+
+	record, getErr := ctr.dao.GetDeleted(restaurantId, &slug)
+	if getErr != nil {
+		c.JSON(getErr.Status(), getErr)
+		return
+	}
+
+	/* Authorize request for current user */
+	currentUser := ctr.base.CurrentUser(c)
+	authorizer := authorizer.NewPageAuthorizer(currentUser, record.AuthorId)
+	permissions, restErr := authorizer.Authorize("restore")
+	if restErr != nil {
+		c.JSON(restErr.Status(), restErr)
+		return
+	}
+
 	meta := map[string]interface{}{
-		"total": len(result),
+		"permissions": permissions,
 	}
 
-	collection := result.CollectionFor(currentUser.Role)
-	jsonapi := serializers.NewCollectionSerializer(collection, meta)
+	result, restoreErr := ctr.dao.Restore(record)
+	if restoreErr != nil {
+		c.JSON(restoreErr.Status(), restoreErr)
+		return
+	}
+
+	resource := result.MemberFor(currentUser.Role)
+	jsonPayload := serializers.NewMemberSerializer(resource, nil, nil, meta)
+	c.JSON(http.StatusOK, jsonPayload)
+}
+
+/*
+PageTree returns the pages of a restaurant (?restaurant_id=) nested under their parent page, as used to build
+the navigation of its site. Managers get the tree of their own restaurant.
+*/
+func (ctr *pagesHandler) PageTree(c *gin.Context) {
+	currentUser := ctr.base.CurrentUser(c)
+	/* Authorize request for current user */
+	authorizer := authorizer.NewPageAuthorizer(currentUser)
+	_, restErr := authorizer.Authorize("accessCollection")
+	if restErr != nil {
+		c.JSON(restErr.Status(), restErr)
+		return
+	}
+
+	param := c.Param("id")
+	if param == "" {
+		param = c.Query("restaurant_id")
+	}
+	if param == "" && currentUser.IsManager() && currentUser.RestaurantId.Valid {
+		param = fmt.Sprint(currentUser.RestaurantId.Int64)
+	}
+	if param == "" {
+		paramErr := rest_errors.NewBadRequestError("restaurant_id is required")
+		c.JSON(paramErr.Status(), paramErr)
+		return
+	}
+	restaurantId, err := strconv.ParseInt(param, 10, 64)
+	if err != nil {
+		paramErr := rest_errors.NewBadRequestError("restaurant_id should be a number")
+		c.JSON(paramErr.Status(), paramErr)
+		return
+	}
+
+	/* Managers can only browse the pages of their own restaurant */
+	if !currentUser.IsAdmin() && (!currentUser.RestaurantId.Valid || currentUser.RestaurantId.Int64 != restaurantId) {
+		forbiddenErr := rest_errors.NewForbiddenError("You are not allowed to perform this action")
+		c.JSON(forbiddenErr.Status(), forbiddenErr)
+		return
+	}
+
+	pages, getErr := ctr.dao.RestaurantPages(restaurantId)
+	if getErr != nil {
+		c.JSON(getErr.Status(), getErr)
+		return
+	}
+
+	tree := pages.TreeFor(currentUser.Role)
+	data := make([]interface{}, len(tree))
+	for index, node := range tree {
+		data[index] = node
+	}
+
+	meta := map[string]interface{}{
+		"total":        len(pages),
+		"restaurantId": restaurantId,
+	}
+	jsonapi := serializers.NewCollectionSerializer(data, meta, nil)
 	c.JSON(http.StatusOK, jsonapi)
 }
+
+/*
+pagePlacement takes parentPageId and position out of the update payload. moved tells if the page has to be moved:
+a new parent is validated first, and without a position the page is moved after its new siblings.
+*/
+func (ctr *pagesHandler) pagePlacement(record *dto.Page, data map[string]interface{}) (types.NullInt, int, bool, rest_errors.RestErr) {
+	parentValue, hasParent := data["parentPageId"]
+	positionValue, hasPosition := data["position"]
+	delete(data, "parentPageId")
+	delete(data, "position")
+
+	parentPageId := record.ParentPageId
+	if hasParent {
+		value, err := nullIntValue("parentPageId", parentValue)
+		if err != nil {
+			return parentPageId, 0, false, err
+		}
+		parentPageId = value
+	}
+	reparented := parentPageId != record.ParentPageId
+
+	position := 0
+	if !reparented {
+		position = record.Position
+	}
+	if hasPosition && positionValue != nil {
+		value, err := nullIntValue("position", positionValue)
+		if err != nil {
+			return parentPageId, 0, false, err
+		}
+		if value.Int64 < 1 {
+			causes := rest_errors.ValidationErrs{"position": []interface{}{map[string]interface{}{"error": "must_be_positive"}}}
+			return parentPageId, 0, false, rest_errors.NewValidationError(&causes)
+		}
+		position = int(value.Int64)
+	}
+
+	if !reparented && position == record.Position {
+		return parentPageId, position, false, nil
+	}
+	if reparented && parentPageId.Valid {
+		if err := ctr.dao.ValidateParent(record.Id, record.RestaurantId.Int64, parentPageId.Int64); err != nil {
+			return parentPageId, 0, false, err
+		}
+	}
+	return parentPageId, position, true, nil
+}
+
+/* nullIntValue reads an optional integer of a json payload, where numbers are decoded as float64 */
+func nullIntValue(attr string, value interface{}) (types.NullInt, rest_errors.RestErr) {
+	switch number := value.(type) {
+	case nil:
+		return types.NullInt{}, nil
+	case float64:
+		if number == math.Trunc(number) {
+			return types.NullInt{NullInt64: sql.NullInt64{Int64: int64(number), Valid: true}}, nil
+		}
+	}
+	causes := rest_errors.ValidationErrs{attr: []interface{}{map[string]interface{}{"error": "must_be_an_integer"}}}
+	return types.NullInt{}, rest_errors.NewValidationError(&causes)
+}
+
+/* Optional publication window sent along with a transition */
+type transitionPayload struct {
+	Data struct {
+		Attributes struct {
+			PublishAt   *time.Time `json:"publishAt"`
+			UnpublishAt *time.Time `json:"unpublishAt"`
+		} `json:"attributes"`
+	} `json:"data"`
+}
+
+/*
+TransitionPage applies a workflow event (:event, see dto.PageTransitions) to the page.
+Pages are scheduled until their publishAt, approving a page whose publishAt is ahead schedules it as well.
+Published pages are archived once their unpublishAt has passed.
+*/
+func (ctr *pagesHandler) TransitionPage(c *gin.Context) {
+	slug := GetIdentifierFromUrl(c, "slug", false)
+	if slug == "" {
+		slugErr := rest_errors.NewBadRequestError("slug is required")
+		c.JSON(slugErr.Status(), slugErr)
+		return
+	}
+
+	event := c.Param("event")
+	if _, exists := dto.PageTransitions[event]; !exists {
+		eventErr := rest_errors.NewNotFoundError(fmt.Sprintf("Sorry, there is no %v transition", event))
+		c.JSON(eventErr.Status(), eventErr)
+		return
+	}
+
+	/* Check if page exists with given slug */
+	restaurantId, restaurantErr := pageRestaurantId(c, ctr.base.CurrentUser(c))
+	if restaurantErr != nil {
+		c.JSON(restaurantErr.Status(), restaurantErr)
+		return
+	}
+
+	record, getErr := ctr.dao.Get(restaurantId, &slug)
+	if getErr != nil {
+		c.JSON(getErr.Status(), getErr)
+		return
+	}
+
+	/* Authorize request for current user */
+	currentUser := ctr.base.CurrentUser(c)
+	authorizer := authorizer.NewPageAuthorizer(currentUser, record.AuthorId)
+	permissions, restErr := authorizer.Authorize(event)
+	if restErr != nil {
+		c.JSON(restErr.Status(), restErr)
+		return
+	}
+
+	meta := map[string]interface{}{
+		"permissions": permissions,
+	}
+
+	var payload transitionPayload
+	if body, err := io.ReadAll(c.Request.Body); err != nil || (len(body) > 0 && json.Unmarshal(body, &payload) != nil) {
+		bodyErr := rest_errors.NewBadRequestError("invalid json body")
+		c.JSON(bodyErr.Status(), bodyErr)
+		return
+	}
+
+	transition, allowed := record.Transition(event)
+	if !allowed {
+		causes := rest_errors.ValidationErrs{"visibility": []interface{}{map[string]interface{}{
+			"error":   "invalid_transition",
+			"event":   event,
+			"current": record.Visibility,
+			"allowed": dto.PageTransitions[event].From,
+		}}}
+		c.JSON(http.StatusUnprocessableEntity, rest_errors.NewValidationError(&causes))
+		return
+	}
+
+	/* Timestamps are stored in UTC */
+	now := time.Now().UTC()
+	publishAt, unpublishAt := record.PublishAt, record.UnpublishAt
+	if at := payload.Data.Attributes.PublishAt; at != nil {
+		publishAt = types.NullTime{NullTime: sql.NullTime{Time: at.UTC(), Valid: true}}
+	}
+	if at := payload.Data.Attributes.UnpublishAt; at != nil {
+		unpublishAt = types.NullTime{NullTime: sql.NullTime{Time: at.UTC(), Valid: true}}
+	} else if unpublishAt.Valid && !unpublishAt.Time.After(now) {
+		/* The end of a past publication doesn't apply to the next one */
+		unpublishAt = types.NullTime{}
+	}
+
+	visibility := transition.To
+	switch {
+	case visibility == dto.PagePublished && event == "approve" && publishAt.Valid && publishAt.Time.After(now):
+		visibility = dto.PageScheduled
+	case visibility == dto.PagePublished:
+		publishAt = types.NullTime{NullTime: sql.NullTime{Time: now, Valid: true}}
+	case visibility == dto.PageScheduled && (!publishAt.Valid || !publishAt.Time.After(now)):
+		causes := rest_errors.ValidationErrs{"publishAt": []interface{}{map[string]interface{}{"error": "must_be_in_the_future"}}}
+		c.JSON(http.StatusUnprocessableEntity, rest_errors.NewValidationError(&causes))
+		return
+	}
+	if unpublishAt.Valid && publishAt.Valid && !unpublishAt.Time.After(publishAt.Time) && visibility != dto.PageArchived {
+		causes := rest_errors.ValidationErrs{"unpublishAt": []interface{}{map[string]interface{}{"error": "must_be_after_publish_at"}}}
+		c.JSON(http.StatusUnprocessableEntity, rest_errors.NewValidationError(&causes))
+		return
+	}
+
+	result, transitionErr := ctr.dao.Transition(record, visibility, publishAt, unpublishAt)
+	if transitionErr != nil {
+		c.JSON(transitionErr.Status(), transitionErr)
+		return
+	}
+
+	resource := result.MemberFor(currentUser.Role)
+	jsonPayload := serializers.NewMemberSerializer(resource, nil, nil, meta)
+	c.JSON(http.StatusOK, jsonPayload)
+}
+
+/*
+pageRestaurantId is the restaurant whose pages are addressed by slug: the :id of /restaurants/:id/pages routes,
+or the restaurant of managers. It is nil for admins on /api/pages routes, the slug is then looked up across restaurants.
+*/
+func pageRestaurantId(c *gin.Context, currentUser *dto.BaseUser) (*int64, rest_errors.RestErr) {
+	if c.Param("id") != "" {
+		id, idErr := GetIdFromUrl(c, false)
+		if idErr != nil {
+			return nil, idErr
+		}
+		return &id, nil
+	}
+
+	if currentUser.IsManager() && currentUser.RestaurantId.Valid {
+		return &currentUser.RestaurantId.Int64, nil
+	}
+	return nil, nil
+}
+
+/* redirectToSlug redirects a request made with the former slug of a page to the same url with its current slug */
+func redirectToSlug(c *gin.Context, formerSlug string, currentSlug string) {
+	location := *c.Request.URL
+	location.Path = strings.TrimSuffix(location.Path, formerSlug) + currentSlug
+	location.RawPath = ""
+	c.Redirect(http.StatusMovedPermanently, location.String())
+}
+
+/* normalizeSlug turns a slug chosen by a user into a valid one, e.g. "Our Menu" into "our-menu" */
+func normalizeSlug(value interface{}) (string, bool) {
+	text, isString := value.(string)
+	pageSlug := slug.Make(text)
+	return pageSlug, isString && len(pageSlug) >= 3 && len(pageSlug) <= 50
+}
@@ -0,0 +1,441 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"resturants-hub.com/m/v2/dao"
+	"resturants-hub.com/m/v2/database"
+	"resturants-hub.com/m/v2/dto"
+	consts "resturants-hub.com/m/v2/packages/const"
+	rest_errors "resturants-hub.com/m/v2/packages/utils"
+	"resturants-hub.com/m/v2/serializers"
+)
+
+type PublicHandler interface {
+	ListRestaurants(c *gin.Context)
+	GetRestaurant(c *gin.Context)
+	ListPages(c *gin.Context)
+	GetPage(c *gin.Context)
+	ListDishes(c *gin.Context)
+	ListMenuCategories(c *gin.Context)
+	MenuLabels(c *gin.Context)
+	Availability(c *gin.Context)
+	CreateReservation(c *gin.Context)
+	PriceCart(c *gin.Context)
+	CreateOrder(c *gin.Context)
+	ListReviews(c *gin.Context)
+	CreateReview(c *gin.Context)
+}
+
+type publicHandler struct {
+	restaurantsDao  dao.RestaurantDao
+	pagesDao        dao.PagesDao
+	dishesDao       dao.DishesDao
+	categoriesDao   dao.MenuCategoriesDao
+	tablesDao       dao.TablesDao
+	reservationsDao dao.ReservationsDao
+	ordersDao       dao.OrdersDao
+	reviewsDao      dao.ReviewsDao
+	visitor         *dto.BaseUser
+}
+
+/* Handler for the unauthenticated, read only API used by the customer facing site */
+func NewPublicHandler() PublicHandler {
+	return &publicHandler{
+		restaurantsDao:  dao.NewRestaurantDao(),
+		pagesDao:        dao.NewPageDao(),
+		dishesDao:       dao.NewDishesDao(),
+		categoriesDao:   dao.NewMenuCategoriesDao(),
+		tablesDao:       dao.NewTablesDao(),
+		reservationsDao: dao.NewReservationsDao(),
+		ordersDao:       dao.NewOrdersDao(),
+		reviewsDao:      dao.NewReviewsDao(),
+		visitor:         &dto.BaseUser{Role: consts.Public},
+	}
+}
+
+func (ctr *publicHandler) authorize(action string, resource consts.ResourceType) rest_errors.RestErr {
+	if !ctr.visitor.Can(action, resource) {
+		return rest_errors.NewForbiddenError("You are not allowed to perform this action")
+	}
+	return nil
+}
+
+func (ctr *publicHandler) ListRestaurants(c *gin.Context) {
+	if restErr := ctr.authorize("accessCollection", consts.Restaurants); restErr != nil {
+		c.JSON(restErr.Status(), restErr)
+		return
+	}
+
+	params, paramsErr := WhitelistQueryParams(c, []string{"name", "slug", "address", "rating_average", "rating_count"})
+	if paramsErr != nil {
+		c.JSON(paramsErr.Status(), paramsErr)
+		return
+	}
+	result, total, err := ctr.restaurantsDao.PublicRestaurantCollection(params)
+	if err != nil {
+		c.JSON(err.Status(), err)
+		return
+	}
+	meta, links := PaginationMeta(c, params, total, result)
+
+	collection := result.CollectionFor(ctr.visitor.Role)
+	jsonapi := serializers.NewCollectionSerializer(collection, meta, links)
+	c.JSON(http.StatusOK, jsonapi)
+}
+
+func (ctr *publicHandler) GetRestaurant(c *gin.Context) {
+	if restErr := ctr.authorize("accessMember", consts.Restaurants); restErr != nil {
+		c.JSON(restErr.Status(), restErr)
+		return
+	}
+
+	restaurant, getErr := ctr.restaurantsDao.GetPublicRestaurant(GetIdentifierFromUrl(c, "id", false))
+	if getErr != nil {
+		c.JSON(getErr.Status(), getErr)
+		return
+	}
+
+	resource := restaurant.MemberFor(ctr.visitor.Role)
+	jsonapi := serializers.NewMemberSerializer(resource, nil, nil, nil)
+	c.JSON(http.StatusOK, jsonapi)
+}
+
+func (ctr *publicHandler) ListPages(c *gin.Context) {
+	if restErr := ctr.authorize("accessCollection", consts.Pages); restErr != nil {
+		c.JSON(restErr.Status(), restErr)
+		return
+	}
+
+	restaurant, getErr := ctr.restaurantsDao.GetPublicRestaurant(GetIdentifierFromUrl(c, "id", false))
+	if getErr != nil {
+		c.JSON(getErr.Status(), getErr)
+		return
+	}
+
+	params, paramsErr := WhitelistQueryParams(c, []string{"title", "parent_page_id"})
+	if paramsErr != nil {
+		c.JSON(paramsErr.Status(), paramsErr)
+		return
+	}
+	result, total, err := ctr.pagesDao.PublishedCollection(&restaurant.Id, params)
+	if err != nil {
+		c.JSON(err.Status(), err)
+		return
+	}
+	meta, links := PaginationMeta(c, params, total, result)
+
+	collection := result.CollectionFor(ctr.visitor.Role)
+	jsonapi := serializers.NewCollectionSerializer(collection, meta, links)
+	c.JSON(http.StatusOK, jsonapi)
+}
+
+func (ctr *publicHandler) GetPage(c *gin.Context) {
+	if restErr := ctr.authorize("accessMember", consts.Pages); restErr != nil {
+		c.JSON(restErr.Status(), restErr)
+		return
+	}
+
+	restaurant, getErr := ctr.restaurantsDao.GetPublicRestaurant(GetIdentifierFromUrl(c, "id", false))
+	if getErr != nil {
+		c.JSON(getErr.Status(), getErr)
+		return
+	}
+
+	slug := GetIdentifierFromUrl(c, "slug", false)
+	page, getErr := ctr.pagesDao.GetPublished(&restaurant.Id, &slug)
+	if getErr != nil {
+		/* Links to the former slug of a published page lead to its current slug */
+		if renamed, formerErr := ctr.pagesDao.FormerSlug(&restaurant.Id, &slug); formerErr == nil && renamed.Visibility == dto.PagePublished {
+			redirectToSlug(c, slug, renamed.Slug)
+			return
+		}
+		c.JSON(getErr.Status(), getErr)
+		return
+	}
+
+	resource := page.MemberFor(ctr.visitor.Role)
+	jsonapi := serializers.NewMemberSerializer(resource, nil, nil, nil)
+	c.JSON(http.StatusOK, jsonapi)
+}
+
+func (ctr *publicHandler) ListDishes(c *gin.Context) {
+	if restErr := ctr.authorize("accessCollection", consts.Dishes); restErr != nil {
+		c.JSON(restErr.Status(), restErr)
+		return
+	}
+
+	restaurant, getErr := ctr.restaurantsDao.GetPublicRestaurant(GetIdentifierFromUrl(c, "id", false))
+	if getErr != nil {
+		c.JSON(getErr.Status(), getErr)
+		return
+	}
+
+	params, paramsErr := WhitelistQueryParams(c, dishFilters)
+	if paramsErr != nil {
+		c.JSON(paramsErr.Status(), paramsErr)
+		return
+	}
+	result, total, err := ctr.dishesDao.PublicDishesCollection(&restaurant.Id, params)
+	if err != nil {
+		c.JSON(err.Status(), err)
+		return
+	}
+	meta, links := PaginationMeta(c, params, total, result)
+
+	collection := result.CollectionFor(ctr.visitor.Role)
+	jsonapi := serializers.NewCollectionSerializer(collection, meta, links)
+	c.JSON(http.StatusOK, jsonapi)
+}
+
+/* ListMenuCategories lists the sections of the menu of a restaurant, in the order they are listed */
+func (ctr *publicHandler) ListMenuCategories(c *gin.Context) {
+	if restErr := ctr.authorize("accessCollection", consts.MenuCategories); restErr != nil {
+		c.JSON(restErr.Status(), restErr)
+		return
+	}
+
+	restaurant, getErr := ctr.restaurantsDao.GetPublicRestaurant(GetIdentifierFromUrl(c, "id", false))
+	if getErr != nil {
+		c.JSON(getErr.Status(), getErr)
+		return
+	}
+
+	params, paramsErr := WhitelistQueryParams(c, []string{"name", "position"})
+	if paramsErr != nil {
+		c.JSON(paramsErr.Status(), paramsErr)
+		return
+	}
+	if params.Get("sort") == "" {
+		params.Set("sort", "position")
+	}
+	result, total, err := ctr.categoriesDao.SearchMenuCategories(params, database.Scope{"restaurant_id": restaurant.Id})
+	if err != nil {
+		c.JSON(err.Status(), err)
+		return
+	}
+	meta, links := PaginationMeta(c, params, total, result)
+
+	collection := result.CollectionFor(ctr.visitor.Role)
+	jsonapi := serializers.NewCollectionSerializer(collection, meta, links)
+	c.JSON(http.StatusOK, jsonapi)
+}
+
+/* MenuLabels returns the vocabulary dishes are labelled with, which their allergens and dietary filters accept */
+func (ctr *publicHandler) MenuLabels(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"meta": map[string]interface{}{
+			"allergens": dto.Allergens,
+			"dietary":   dto.DietaryLabels,
+		},
+	})
+}
+
+/* Availability lists the slots diners can book a table at, see searchAvailability */
+func (ctr *publicHandler) Availability(c *gin.Context) {
+	if restErr := ctr.authorize("create", consts.Reservations); restErr != nil {
+		c.JSON(restErr.Status(), restErr)
+		return
+	}
+
+	restaurant, getErr := ctr.restaurantsDao.GetPublicRestaurant(GetIdentifierFromUrl(c, "id", false))
+	if getErr != nil {
+		c.JSON(getErr.Status(), getErr)
+		return
+	}
+
+	searchAvailability(c, restaurant, ctr.tablesDao, ctr.reservationsDao)
+}
+
+/* CreateReservation books a table for a diner, the reservation is pending until the restaurant confirms it */
+func (ctr *publicHandler) CreateReservation(c *gin.Context) {
+	if restErr := ctr.authorize("create", consts.Reservations); restErr != nil {
+		c.JSON(restErr.Status(), restErr)
+		return
+	}
+
+	restaurant, getErr := ctr.restaurantsDao.GetPublicRestaurant(GetIdentifierFromUrl(c, "id", false))
+	if getErr != nil {
+		c.JSON(getErr.Status(), getErr)
+		return
+	}
+
+	payload, payloadErr := reservationPayload(c)
+	if payloadErr != nil {
+		c.JSON(payloadErr.Status(), payloadErr)
+		return
+	}
+
+	reservation, bookErr := bookReservation(restaurant, payload, ctr.tablesDao, ctr.reservationsDao)
+	if bookErr != nil {
+		c.JSON(bookErr.Status(), bookErr)
+		return
+	}
+
+	resource := reservation.MemberFor(ctr.visitor.Role)
+	jsonapi := serializers.NewMemberSerializer(resource, nil, nil, nil)
+	c.JSON(http.StatusCreated, jsonapi)
+}
+
+/* PriceCart prices the items of a cart from the current menu, so diners see the total before ordering */
+func (ctr *publicHandler) PriceCart(c *gin.Context) {
+	if restErr := ctr.authorize("create", consts.Orders); restErr != nil {
+		c.JSON(restErr.Status(), restErr)
+		return
+	}
+
+	restaurant, getErr := ctr.restaurantsDao.GetPublicRestaurant(GetIdentifierFromUrl(c, "id", false))
+	if getErr != nil {
+		c.JSON(getErr.Status(), getErr)
+		return
+	}
+
+	payload, payloadErr := cartPayload(c)
+	if payloadErr != nil {
+		c.JSON(payloadErr.Status(), payloadErr)
+		return
+	}
+
+	cart, priceErr := ctr.ordersDao.PriceCart(restaurant.Id, payload.Items)
+	if priceErr != nil {
+		c.JSON(priceErr.Status(), priceErr)
+		return
+	}
+
+	c.JSON(http.StatusOK, map[string]interface{}{
+		"data": cart,
+		"meta": map[string]interface{}{
+			"total": len(cart.Items),
+		},
+	})
+}
+
+/* CreateOrder places a takeaway order for a diner, its total is computed from the menu whatever the diner was shown */
+func (ctr *publicHandler) CreateOrder(c *gin.Context) {
+	if restErr := ctr.authorize("create", consts.Orders); restErr != nil {
+		c.JSON(restErr.Status(), restErr)
+		return
+	}
+
+	restaurant, getErr := ctr.restaurantsDao.GetPublicRestaurant(GetIdentifierFromUrl(c, "id", false))
+	if getErr != nil {
+		c.JSON(getErr.Status(), getErr)
+		return
+	}
+
+	payload, payloadErr := orderPayload(c, restaurant)
+	if payloadErr != nil {
+		c.JSON(payloadErr.Status(), payloadErr)
+		return
+	}
+
+	order, createErr := ctr.ordersDao.CreateOrder(payload)
+	if createErr != nil {
+		c.JSON(createErr.Status(), createErr)
+		return
+	}
+
+	resource := order.MemberFor(ctr.visitor.Role)
+	jsonapi := serializers.NewMemberSerializer(resource, nil, nil, nil)
+	c.JSON(http.StatusCreated, jsonapi)
+}
+
+/* reviewedDish finds the dish of the :dishId param on the menu of the public restaurant, as long as it accepts reviews */
+func (ctr *publicHandler) reviewedDish(c *gin.Context) (*dto.Dish, rest_errors.RestErr) {
+	restaurant, getErr := ctr.restaurantsDao.GetPublicRestaurant(GetIdentifierFromUrl(c, "id", false))
+	if getErr != nil {
+		return nil, getErr
+	}
+	dishId, idErr := GetNumericParamFromUrl(c, "dishId")
+	if idErr != nil {
+		return nil, idErr
+	}
+
+	dish, getErr := ctr.dishesDao.GetDish(&restaurant.Id, &dishId)
+	if getErr != nil {
+		return nil, getErr
+	}
+	if dish.DeletedAt.Valid {
+		return nil, rest_errors.NewNotFoundError(fmt.Sprintf("Sorry, the dish with id %v doesn't exist", dishId))
+	}
+	if dish.EnableReviews == nil || !*dish.EnableReviews {
+		return nil, rest_errors.NewForbiddenError("Sorry, this dish doesn't accept reviews")
+	}
+	return dish, nil
+}
+
+/* ListReviews lists the published reviews of a dish, latest first unless asked otherwise */
+func (ctr *publicHandler) ListReviews(c *gin.Context) {
+	if restErr := ctr.authorize("accessCollection", consts.Reviews); restErr != nil {
+		c.JSON(restErr.Status(), restErr)
+		return
+	}
+
+	dish, dishErr := ctr.reviewedDish(c)
+	if dishErr != nil {
+		c.JSON(dishErr.Status(), dishErr)
+		return
+	}
+
+	params, paramsErr := WhitelistQueryParams(c, []string{"rating", "created_at"})
+	if paramsErr != nil {
+		c.JSON(paramsErr.Status(), paramsErr)
+		return
+	}
+	if params.Get("sort") == "" {
+		params.Set("sort", "-created_at")
+	}
+
+	scope := database.Scope{"dish_id": dish.Id, "status": dto.ReviewPublished}
+	result, total, err := ctr.reviewsDao.SearchReviews(params, scope)
+	if err != nil {
+		c.JSON(err.Status(), err)
+		return
+	}
+	meta, links := PaginationMeta(c, params, total, result)
+
+	collection := result.CollectionFor(ctr.visitor.Role)
+	jsonapi := serializers.NewCollectionSerializer(collection, meta, links)
+	c.JSON(http.StatusOK, jsonapi)
+}
+
+/* CreateReview publishes the review of a dish by a diner, who can review each dish once */
+func (ctr *publicHandler) CreateReview(c *gin.Context) {
+	if restErr := ctr.authorize("create", consts.Reviews); restErr != nil {
+		c.JSON(restErr.Status(), restErr)
+		return
+	}
+
+	dish, dishErr := ctr.reviewedDish(c)
+	if dishErr != nil {
+		c.JSON(dishErr.Status(), dishErr)
+		return
+	}
+
+	/* A rating which isn't a whole number is rejected, instead of being rounded down */
+	newRecord := &dto.CreateReviewPayload{}
+	if restErr := DecodeAttributes(c, []string{"rating", "title", "body", "authorName", "authorEmail"}, newRecord); restErr != nil {
+		c.JSON(restErr.Status(), restErr)
+		return
+	}
+	newRecord.RestaurantId = dish.RestaurantId
+	newRecord.DishId = dish.Id
+
+	if err := Validate.Struct(newRecord); err != nil {
+		restErr := rest_errors.NewValidationError(rest_errors.StructValidationErrors(err))
+		c.JSON(restErr.Status(), restErr)
+		return
+	}
+
+	review, createErr := ctr.reviewsDao.CreateReview(newRecord)
+	if createErr != nil {
+		c.JSON(createErr.Status(), createErr)
+		return
+	}
+
+	resource := review.MemberFor(ctr.visitor.Role)
+	jsonapi := serializers.NewMemberSerializer(resource, nil, nil, nil)
+	c.JSON(http.StatusCreated, jsonapi)
+}
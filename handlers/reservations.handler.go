@@ -0,0 +1,385 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"resturants-hub.com/m/v2/authorizer"
+	"resturants-hub.com/m/v2/dao"
+	"resturants-hub.com/m/v2/dto"
+	"resturants-hub.com/m/v2/packages/hours"
+	rest_errors "resturants-hub.com/m/v2/packages/utils"
+	"resturants-hub.com/m/v2/serializers"
+)
+
+type ReservationsHandler interface {
+	Create(c *gin.Context)
+	Get(c *gin.Context)
+	List(c *gin.Context)
+	Update(c *gin.Context)
+	Transition(c *gin.Context)
+	Availability(c *gin.Context)
+}
+
+type reservationsHandler struct {
+	dao            dao.ReservationsDao
+	tablesDao      dao.TablesDao
+	restaurantsDao dao.RestaurantDao
+	base           BaseHandler
+}
+
+func NewReservationsHandler() ReservationsHandler {
+	return &reservationsHandler{
+		dao:            dao.NewReservationsDao(),
+		tablesDao:      dao.NewTablesDao(),
+		restaurantsDao: dao.NewRestaurantDao(),
+		base:           NewBaseHandler(),
+	}
+}
+
+/* Attributes a reservation is made with, by the restaurant or by a diner */
+var reservationAttributes = []string{"tableId", "partySize", "startsAt", "durationMinutes", "customerName", "customerEmail", "customerPhone", "notes"}
+
+/* reservationPayload reads the reservation of the request body, times are RFC3339 timestamps */
+func reservationPayload(c *gin.Context) (*dto.CreateReservationPayload, rest_errors.RestErr) {
+	reservation := &dto.CreateReservationPayload{}
+	if restErr := DecodeAttributes(c, reservationAttributes, reservation); restErr != nil {
+		return nil, restErr
+	}
+	return reservation, nil
+}
+
+/*
+bookReservation makes the reservation at the restaurant: it has to start in the future, while the restaurant is open
+for its whole duration, at a table seating the party (the given one or the smallest free one).
+*/
+func bookReservation(restaurant *dto.Restaurant, payload *dto.CreateReservationPayload, tablesDao dao.TablesDao, reservationsDao dao.ReservationsDao) (*dto.Reservation, rest_errors.RestErr) {
+	payload.RestaurantId = restaurant.Id
+	if err := Validate.Struct(payload); err != nil {
+		return nil, rest_errors.NewValidationError(rest_errors.StructValidationErrors(err))
+	}
+
+	start := payload.StartsAt
+	end := start.Add(payload.Duration())
+	invalidPeriod := func(cause string) rest_errors.RestErr {
+		return rest_errors.NewValidationError(&rest_errors.ValidationErrs{"startsAt": []interface{}{map[string]interface{}{"error": cause}}})
+	}
+	if !start.After(time.Now()) {
+		return nil, invalidPeriod("must_be_in_the_future")
+	}
+	if !restaurant.OpeningHours.Covers(restaurant.Timezone, start, end) {
+		return nil, invalidPeriod("restaurant_closed")
+	}
+
+	tables, tablesErr := tablesDao.BookableTables(restaurant.Id, payload.PartySize)
+	if tablesErr != nil {
+		return nil, tablesErr
+	}
+	if payload.TableId != 0 {
+		requested := dto.Tables{}
+		for _, table := range tables {
+			if table.Id == payload.TableId {
+				requested = append(requested, table)
+			}
+		}
+		if len(requested) == 0 {
+			cause := map[string]interface{}{"error": "unavailable_table", "partySize": payload.PartySize}
+			return nil, rest_errors.NewValidationError(&rest_errors.ValidationErrs{"tableId": []interface{}{cause}})
+		}
+		tables = requested
+	}
+
+	/* Tables known to be booked are skipped, the database still rejects bookings made in the meantime */
+	booked, bookedErr := reservationsDao.BookedReservations(restaurant.Id, start, end)
+	if bookedErr != nil {
+		return nil, bookedErr
+	}
+	return reservationsDao.CreateReservation(payload, tables.FreeTables(booked, payload.PartySize, start, end))
+}
+
+/*
+searchAvailability lists the slots at which a party can book a table: ?partySize=4&date=2024-06-14&from=18:00&to=21:00.
+The date and the time window are local to the restaurant, the window defaults to the whole day.
+*/
+func searchAvailability(c *gin.Context, restaurant *dto.Restaurant, tablesDao dao.TablesDao, reservationsDao dao.ReservationsDao) {
+	causes := rest_errors.ValidationErrs{}
+	invalid := func(param string, cause string, expected string) {
+		causes[param] = append(causes[param], map[string]interface{}{"error": cause, "expected": expected})
+	}
+
+	partySize, err := strconv.Atoi(c.Query("partySize"))
+	if err != nil || partySize <= 0 {
+		invalid("partySize", "invalid_value", "a positive number")
+	}
+	duration := dto.ReservationDuration
+	if c.Query("durationMinutes") != "" {
+		minutes, err := strconv.Atoi(c.Query("durationMinutes"))
+		if err != nil || minutes < 30 || minutes > 360 {
+			invalid("durationMinutes", "invalid_value", "a number of minutes between 30 and 360")
+		}
+		duration = time.Duration(minutes) * time.Minute
+	}
+	var windowStart, windowEnd time.Time
+	if _, validDate := hours.At(restaurant.Timezone, c.Query("date"), "00:00"); !validDate {
+		invalid("date", "invalid_date", "YYYY-MM-DD")
+	} else {
+		var validFrom, validTo bool
+		windowStart, validFrom = hours.At(restaurant.Timezone, c.Query("date"), c.DefaultQuery("from", "00:00"))
+		windowEnd, validTo = hours.At(restaurant.Timezone, c.Query("date"), c.DefaultQuery("to", "24:00"))
+		if !validFrom {
+			invalid("from", "invalid_time", "HH:MM")
+		}
+		if !validTo {
+			invalid("to", "invalid_time", "HH:MM")
+		} else if validFrom && windowEnd.Before(windowStart) {
+			invalid("to", "invalid_time", "a time after from")
+		}
+	}
+	if len(causes) > 0 {
+		restErr := rest_errors.NewRestError("Invalid query parameters", http.StatusBadRequest, "bad_request", causes)
+		c.JSON(restErr.Status(), restErr)
+		return
+	}
+
+	tables, tablesErr := tablesDao.BookableTables(restaurant.Id, partySize)
+	if tablesErr != nil {
+		c.JSON(tablesErr.Status(), tablesErr)
+		return
+	}
+	booked, bookedErr := reservationsDao.BookedReservations(restaurant.Id, windowStart, windowEnd.Add(duration))
+	if bookedErr != nil {
+		c.JSON(bookedErr.Status(), bookedErr)
+		return
+	}
+
+	slots := restaurant.AvailableSlots(tables, booked, partySize, windowStart, windowEnd, duration, time.Now())
+	c.JSON(http.StatusOK, map[string]interface{}{
+		"data": slots,
+		"meta": map[string]interface{}{
+			"total":     len(slots),
+			"partySize": partySize,
+			"timezone":  restaurant.Timezone,
+		},
+	})
+}
+
+/* restaurant finds the restaurant the reservations are made at and authorizes the action for the current user */
+func (ctr *reservationsHandler) restaurant(c *gin.Context, action string) (*dto.Restaurant, interface{}, rest_errors.RestErr) {
+	restaurantId, idErr := managedRestaurantId(c, ctr.base.CurrentUser(c))
+	if idErr != nil {
+		return nil, nil, idErr
+	}
+	restaurant, getErr := ctr.restaurantsDao.GetRestaurant(&restaurantId)
+	if getErr != nil {
+		return nil, nil, getErr
+	}
+
+	authorizer := authorizer.NewReservationAuthorizer(ctr.base.CurrentUser(c), restaurant.Id)
+	permissions, restErr := authorizer.Authorize(action)
+	if restErr != nil {
+		return nil, nil, restErr
+	}
+	return restaurant, permissions, nil
+}
+
+/* reservation finds the reservation of the :reservationId param and authorizes the action on it for the current user */
+func (ctr *reservationsHandler) reservation(c *gin.Context, action string) (*dto.Reservation, interface{}, rest_errors.RestErr) {
+	restaurantId, idErr := managedRestaurantId(c, ctr.base.CurrentUser(c))
+	if idErr != nil {
+		return nil, nil, idErr
+	}
+	reservationId, idErr := GetNumericParamFromUrl(c, "reservationId")
+	if idErr != nil {
+		return nil, nil, idErr
+	}
+
+	reservation, getErr := ctr.dao.GetReservation(&restaurantId, &reservationId)
+	if getErr != nil {
+		return nil, nil, getErr
+	}
+
+	authorizer := authorizer.NewReservationAuthorizer(ctr.base.CurrentUser(c), reservation.RestaurantId)
+	permissions, restErr := authorizer.Authorize(action)
+	if restErr != nil {
+		return nil, nil, restErr
+	}
+	return reservation, permissions, nil
+}
+
+/* Create books a table on behalf of a diner, e.g. for a reservation taken over the phone */
+func (ctr *reservationsHandler) Create(c *gin.Context) {
+	restaurant, permissions, restErr := ctr.restaurant(c, "create")
+	if restErr != nil {
+		c.JSON(restErr.Status(), restErr)
+		return
+	}
+
+	payload, payloadErr := reservationPayload(c)
+	if payloadErr != nil {
+		c.JSON(payloadErr.Status(), payloadErr)
+		return
+	}
+
+	reservation, bookErr := bookReservation(restaurant, payload, ctr.tablesDao, ctr.dao)
+	if bookErr != nil {
+		c.JSON(bookErr.Status(), bookErr)
+		return
+	}
+
+	meta := map[string]interface{}{
+		"permissions": permissions,
+	}
+	resource := reservation.MemberFor(ctr.base.CurrentUser(c).Role)
+	jsonPayload := serializers.NewMemberSerializer(resource, nil, nil, meta)
+	c.JSON(http.StatusOK, jsonPayload)
+}
+
+func (ctr *reservationsHandler) Get(c *gin.Context) {
+	reservation, permissions, restErr := ctr.reservation(c, "access")
+	if restErr != nil {
+		c.JSON(restErr.Status(), restErr)
+		return
+	}
+
+	meta := map[string]interface{}{
+		"permissions": permissions,
+	}
+	resource := reservation.MemberFor(ctr.base.CurrentUser(c).Role)
+	jsonapi := serializers.NewMemberSerializer(resource, nil, nil, meta)
+	c.JSON(http.StatusOK, jsonapi)
+}
+
+func (ctr *reservationsHandler) List(c *gin.Context) {
+	restaurant, _, restErr := ctr.restaurant(c, "accessCollection")
+	if restErr != nil {
+		c.JSON(restErr.Status(), restErr)
+		return
+	}
+
+	params, paramsErr := WhitelistQueryParams(c, []string{"table_id", "party_size", "starts_at", "status", "customer_name", "customer_email", "created_at"})
+	if paramsErr != nil {
+		c.JSON(paramsErr.Status(), paramsErr)
+		return
+	}
+	/* Upcoming reservations first unless asked otherwise */
+	if params.Get("sort") == "" {
+		params.Set("sort", "starts_at")
+	}
+	params.Set("restaurant_id", fmt.Sprint(restaurant.Id))
+
+	result, total, err := ctr.dao.SearchReservations(params)
+	if err != nil {
+		c.JSON(err.Status(), err)
+		return
+	}
+	meta, links := PaginationMeta(c, params, total, result)
+
+	collection := result.CollectionFor(ctr.base.CurrentUser(c).Role)
+	jsonapi := serializers.NewCollectionSerializer(collection, meta, links)
+	c.JSON(http.StatusOK, jsonapi)
+}
+
+/* Update changes the details of the diner, the table and the time are changed by cancelling and booking again */
+func (ctr *reservationsHandler) Update(c *gin.Context) {
+	record, permissions, restErr := ctr.reservation(c, "update")
+	if restErr != nil {
+		c.JSON(restErr.Status(), restErr)
+		return
+	}
+
+	/* Extract request body as map */
+	var mapBody map[string]interface{}
+	jsonData, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		restErr := rest_errors.NewBadRequestError("invalid json body")
+		c.JSON(restErr.Status(), restErr)
+		return
+	}
+
+	currentUser := ctr.base.CurrentUser(c)
+	json.Unmarshal(jsonData, &mapBody)
+	payload := ctr.base.SetData(mapBody)
+	payload.Permit(record.UpdableAttributes(currentUser.Role))
+
+	/* Skip empty data and patch with only new data if the update is partial(PATCH) */
+	isPartial := c.Request.Method == http.MethodPatch
+	if isPartial {
+		payload.ClearEmpty()
+	}
+
+	/* Return error if payload has eroor for require/permit */
+	if len(payload.Errors) > 0 {
+		c.JSON(payload.Errors[0].Status(), payload.Errors)
+		return
+	}
+
+	result, updateErr := ctr.dao.UpdateReservation(record, payload.Data)
+	if updateErr != nil {
+		c.JSON(updateErr.Status(), updateErr)
+		return
+	}
+
+	meta := map[string]interface{}{
+		"permissions": permissions,
+	}
+	resource := result.MemberFor(currentUser.Role)
+	jsonPayload := serializers.NewMemberSerializer(resource, nil, nil, meta)
+	c.JSON(http.StatusOK, jsonPayload)
+}
+
+/* Transition applies an event of the reservation lifecycle: POST /reservations/:reservationId/transitions/:event */
+func (ctr *reservationsHandler) Transition(c *gin.Context) {
+	event := c.Param("event")
+	if _, exists := dto.ReservationTransitions[event]; !exists {
+		restErr := rest_errors.NewNotFoundError(fmt.Sprintf("Sorry, %s is not a reservation transition", event))
+		c.JSON(restErr.Status(), restErr)
+		return
+	}
+
+	record, permissions, restErr := ctr.reservation(c, event)
+	if restErr != nil {
+		c.JSON(restErr.Status(), restErr)
+		return
+	}
+
+	transition, allowed := record.Transition(event)
+	if !allowed {
+		restErr := rest_errors.NewRestError(fmt.Sprintf("A %s reservation can't be %s", record.Status, event), http.StatusConflict, "conflict", nil)
+		c.JSON(restErr.Status(), restErr)
+		return
+	}
+	/* Diners can only miss a reservation once it has started */
+	if transition.To == dto.ReservationNoShow && time.Now().Before(record.StartsAt) {
+		restErr := rest_errors.NewRestError("The reservation hasn't started yet", http.StatusConflict, "conflict", nil)
+		c.JSON(restErr.Status(), restErr)
+		return
+	}
+
+	result, transitionErr := ctr.dao.TransitionReservation(record, transition.To)
+	if transitionErr != nil {
+		c.JSON(transitionErr.Status(), transitionErr)
+		return
+	}
+
+	meta := map[string]interface{}{
+		"permissions": permissions,
+	}
+	resource := result.MemberFor(ctr.base.CurrentUser(c).Role)
+	jsonPayload := serializers.NewMemberSerializer(resource, nil, nil, meta)
+	c.JSON(http.StatusOK, jsonPayload)
+}
+
+func (ctr *reservationsHandler) Availability(c *gin.Context) {
+	restaurant, _, restErr := ctr.restaurant(c, "accessCollection")
+	if restErr != nil {
+		c.JSON(restErr.Status(), restErr)
+		return
+	}
+
+	searchAvailability(c, restaurant, ctr.tablesDao, ctr.dao)
+}
@@ -10,6 +10,7 @@ import (
 	"resturants-hub.com/m/v2/authorizer"
 	"resturants-hub.com/m/v2/dao"
 	"resturants-hub.com/m/v2/dto"
+	"resturants-hub.com/m/v2/packages/hours"
 	rest_errors "resturants-hub.com/m/v2/packages/utils"
 	"resturants-hub.com/m/v2/serializers"
 )
@@ -18,8 +19,12 @@ type RestaurantsHandler interface {
 	Create(c *gin.Context)
 	Get(c *gin.Context)
 	MyRestaurant(c *gin.Context)
+	Hours(c *gin.Context)
+	UpdateHours(c *gin.Context)
 	List(c *gin.Context)
 	Update(c *gin.Context)
+	Delete(c *gin.Context)
+	Restore(c *gin.Context)
 }
 
 type restaurantsHandler struct {
@@ -73,6 +78,14 @@ func (ctr *restaurantsHandler) Create(c *gin.Context) {
 		"permissions": permissions,
 	}
 
+	/* Generate slug for new record */
+	restaurantSlug, slugErr := ctr.dao.GenerateRestaurantSlug(newRestaurant.Name)
+	if slugErr != nil {
+		c.JSON(slugErr.Status(), slugErr)
+		return
+	}
+	newRestaurant.Slug = restaurantSlug
+
 	if err := Validate.Struct(newRestaurant); err != nil {
 		restErr := rest_errors.NewValidationError(rest_errors.StructValidationErrors(err))
 		c.JSON(restErr.Status(), restErr)
@@ -157,6 +170,88 @@ func (ctr *restaurantsHandler) MyRestaurant(c *gin.Context) {
 	c.JSON(http.StatusOK, jsonapi)
 }
 
+func (ctr *restaurantsHandler) Hours(c *gin.Context) {
+	restaurant, getErr := ctr.dao.RestaurantByOwnerId(&ctr.base.CurrentUser(c).Id)
+	if getErr != nil {
+		c.JSON(getErr.Status(), getErr)
+		return
+	}
+
+	authorizer := authorizer.NewRestaurantsAuthorizer(ctr.base.CurrentUser(c), restaurant.ManagerId)
+	permissions, restErr := authorizer.Authorize("access")
+	if restErr != nil {
+		c.JSON(restErr.Status(), restErr)
+		return
+	}
+
+	meta := map[string]interface{}{
+		"permissions": permissions,
+	}
+	jsonapi := serializers.NewMemberSerializer(restaurant.OpeningHoursMember(), nil, nil, meta)
+	c.JSON(http.StatusOK, jsonapi)
+}
+
+/* UpdateHours replaces the weekly schedule and the exceptions, the timezone is kept when it's omitted */
+func (ctr *restaurantsHandler) UpdateHours(c *gin.Context) {
+	restaurant, getErr := ctr.dao.RestaurantByOwnerId(&ctr.base.CurrentUser(c).Id)
+	if getErr != nil {
+		c.JSON(getErr.Status(), getErr)
+		return
+	}
+
+	authorizer := authorizer.NewRestaurantsAuthorizer(ctr.base.CurrentUser(c), restaurant.ManagerId)
+	permissions, restErr := authorizer.Authorize("updateHours")
+	if restErr != nil {
+		c.JSON(restErr.Status(), restErr)
+		return
+	}
+
+	/* Extract request body as map */
+	var mapBody map[string]interface{}
+	jsonData, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		restErr := rest_errors.NewBadRequestError("invalid json body")
+		c.JSON(restErr.Status(), restErr)
+		return
+	}
+	json.Unmarshal(jsonData, &mapBody)
+	payload := ctr.base.SetData(mapBody)
+	payload.Permit([]string{"timezone", "weekly", "exceptions"})
+	if len(payload.Errors) > 0 {
+		c.JSON(payload.Errors[0].Status(), payload.Errors)
+		return
+	}
+
+	/* The schedule is nested, decode it through json rather than mapstructure */
+	openingHours := &dto.OpeningHoursPayload{}
+	attributes, _ := json.Marshal(payload.Data)
+	if err := json.Unmarshal(attributes, openingHours); err != nil {
+		restErr := rest_errors.NewBadRequestError("invalid opening hours: " + err.Error())
+		c.JSON(restErr.Status(), restErr)
+		return
+	}
+	if openingHours.Timezone == "" {
+		openingHours.Timezone = restaurant.Timezone
+	}
+	if causes := hours.Validate(openingHours.Timezone, openingHours.Schedule); len(causes) > 0 {
+		restErr := rest_errors.NewValidationError(&causes)
+		c.JSON(restErr.Status(), restErr)
+		return
+	}
+
+	result, updateErr := ctr.dao.UpdateOpeningHours(restaurant, openingHours)
+	if updateErr != nil {
+		c.JSON(updateErr.Status(), updateErr)
+		return
+	}
+
+	meta := map[string]interface{}{
+		"permissions": permissions,
+	}
+	jsonapi := serializers.NewMemberSerializer(result.OpeningHoursMember(), nil, nil, meta)
+	c.JSON(http.StatusOK, jsonapi)
+}
+
 func (ctr *restaurantsHandler) Update(c *gin.Context) {
 	id, idErr := GetIdFromUrl(c, false)
 	if idErr != nil {
@@ -231,19 +326,90 @@ func (ctr *restaurantsHandler) List(c *gin.Context) {
 		return
 	}
 
-	params := WhitelistQueryParams(c, []string{"user_id", "name", "email", "phone"})
+	params, paramsErr := WhitelistQueryParams(c, []string{"user_id", "name", "email", "phone", "address", "created_at", dao.DeletedFilterParam})
+	if paramsErr != nil {
+		c.JSON(paramsErr.Status(), paramsErr)
+		return
+	}
 
 	// Get authorized collection of restaurants
-	result, err := ctr.dao.AuthorizedRestaurantCollection(params, ctr.base.CurrentUser(c))
+	result, total, err := ctr.dao.AuthorizedRestaurantCollection(params, ctr.base.CurrentUser(c))
 	if err != nil {
 		c.JSON(err.Status(), err)
 		return
 	}
-	meta := map[string]interface{}{
-		"total": len(result),
-	}
+	meta, links := PaginationMeta(c, params, total, result)
 
 	collection := result.CollectionFor(currentUser.Role)
-	jsonapi := serializers.NewCollectionSerializer(collection, meta)
+	jsonapi := serializers.NewCollectionSerializer(collection, meta, links)
 	c.JSON(http.StatusOK, jsonapi)
 }
+
+func (ctr *restaurantsHandler) Delete(c *gin.Context) {
+	id, idErr := GetIdFromUrl(c, false)
+	if idErr != nil {
+		c.JSON(idErr.Status(), idErr)
+		return
+	}
+
+	/* Check if restaurant exists with given Id */
+	record, getErr := ctr.dao.GetRestaurant(&id)
+	if getErr != nil {
+		c.JSON(getErr.Status(), getErr)
+		return
+	}
+
+	/* Authorize request for current user */
+	currentUser := ctr.base.CurrentUser(c)
+	authorizer := authorizer.NewRestaurantsAuthorizer(currentUser, record.ManagerId)
+	_, restErr := authorizer.Authorize("delete")
+	if restErr != nil {
+		c.JSON(restErr.Status(), restErr)
+		return
+	}
+
+	if deleteErr := ctr.dao.DeleteRestaurant(record); deleteErr != nil {
+		c.JSON(deleteErr.Status(), deleteErr)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+func (ctr *restaurantsHandler) Restore(c *gin.Context) {
+	id, idErr := GetIdFromUrl(c, false)
+	if idErr != nil {
+		c.JSON(idErr.Status(), idErr)
+		return
+	}
+
+	/* Only deleted restaurants can be restored */
+	record, getErr := ctr.dao.GetDeletedRestaurant(&id)
+	if getErr != nil {
+		c.JSON(getErr.Status(), getErr)
+		return
+	}
+
+	/* Authorize request for current user */
+	currentUser := ctr.base.CurrentUser(c)
+	authorizer := authorizer.NewRestaurantsAuthorizer(currentUser, record.ManagerId)
+	permissions, restErr := authorizer.Authorize("restore")
+	if restErr != nil {
+		c.JSON(restErr.Status(), restErr)
+		return
+	}
+
+	meta := map[string]interface{}{
+		"permissions": permissions,
+	}
+
+	result, restoreErr := ctr.dao.RestoreRestaurant(record)
+	if restoreErr != nil {
+		c.JSON(restoreErr.Status(), restoreErr)
+		return
+	}
+
+	resource := result.MemberFor(currentUser.Role)
+	jsonPayload := serializers.NewMemberSerializer(resource, nil, nil, meta)
+	c.JSON(http.StatusOK, jsonPayload)
+}
@@ -0,0 +1,231 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mitchellh/mapstructure"
+	"resturants-hub.com/m/v2/authorizer"
+	"resturants-hub.com/m/v2/dao"
+	"resturants-hub.com/m/v2/database"
+	"resturants-hub.com/m/v2/dto"
+	rest_errors "resturants-hub.com/m/v2/packages/utils"
+	"resturants-hub.com/m/v2/serializers"
+)
+
+type ReviewsHandler interface {
+	Get(c *gin.Context)
+	List(c *gin.Context)
+	Reply(c *gin.Context)
+	Transition(c *gin.Context)
+	ModerationQueue(c *gin.Context)
+}
+
+type reviewsHandler struct {
+	dao  dao.ReviewsDao
+	base BaseHandler
+}
+
+func NewReviewsHandler() ReviewsHandler {
+	return &reviewsHandler{
+		dao:  dao.NewReviewsDao(),
+		base: NewBaseHandler(),
+	}
+}
+
+/* Query params reviews are filtered and sorted on */
+var reviewFilters = []string{"dish_id", "rating", "status", "author_name", "author_email", "created_at", "updated_at"}
+
+/* review finds the review of the :reviewId param and authorizes the action on it for the current user */
+func (ctr *reviewsHandler) review(c *gin.Context, action string) (*dto.Review, interface{}, rest_errors.RestErr) {
+	restaurantId, idErr := managedRestaurantId(c, ctr.base.CurrentUser(c))
+	if idErr != nil {
+		return nil, nil, idErr
+	}
+	reviewId, idErr := GetNumericParamFromUrl(c, "reviewId")
+	if idErr != nil {
+		return nil, nil, idErr
+	}
+
+	review, getErr := ctr.dao.GetReview(&restaurantId, &reviewId)
+	if getErr != nil {
+		return nil, nil, getErr
+	}
+
+	authorizer := authorizer.NewReviewAuthorizer(ctr.base.CurrentUser(c), review.RestaurantId)
+	permissions, restErr := authorizer.Authorize(action)
+	if restErr != nil {
+		return nil, nil, restErr
+	}
+	return review, permissions, nil
+}
+
+func (ctr *reviewsHandler) Get(c *gin.Context) {
+	review, permissions, restErr := ctr.review(c, "access")
+	if restErr != nil {
+		c.JSON(restErr.Status(), restErr)
+		return
+	}
+
+	meta := map[string]interface{}{
+		"permissions": permissions,
+	}
+	resource := review.MemberFor(ctr.base.CurrentUser(c).Role)
+	jsonapi := serializers.NewMemberSerializer(resource, nil, nil, meta)
+	c.JSON(http.StatusOK, jsonapi)
+}
+
+func (ctr *reviewsHandler) List(c *gin.Context) {
+	currentUser := ctr.base.CurrentUser(c)
+	restaurantId, idErr := managedRestaurantId(c, currentUser)
+	if idErr != nil {
+		c.JSON(idErr.Status(), idErr)
+		return
+	}
+
+	authorizer := authorizer.NewReviewAuthorizer(currentUser, restaurantId)
+	_, restErr := authorizer.Authorize("accessCollection")
+	if restErr != nil {
+		c.JSON(restErr.Status(), restErr)
+		return
+	}
+
+	params, paramsErr := WhitelistQueryParams(c, reviewFilters)
+	if paramsErr != nil {
+		c.JSON(paramsErr.Status(), paramsErr)
+		return
+	}
+	/* Latest reviews first unless asked otherwise */
+	if params.Get("sort") == "" {
+		params.Set("sort", "-created_at")
+	}
+	params.Set("restaurant_id", fmt.Sprint(restaurantId))
+
+	result, total, err := ctr.dao.SearchReviews(params)
+	if err != nil {
+		c.JSON(err.Status(), err)
+		return
+	}
+	meta, links := PaginationMeta(c, params, total, result)
+
+	collection := result.CollectionFor(currentUser.Role)
+	jsonapi := serializers.NewCollectionSerializer(collection, meta, links)
+	c.JSON(http.StatusOK, jsonapi)
+}
+
+/* ModerationQueue lists the reviews hidden by restaurants across restaurants, the ones waiting the longest first */
+func (ctr *reviewsHandler) ModerationQueue(c *gin.Context) {
+	currentUser := ctr.base.CurrentUser(c)
+	authorizer := authorizer.NewReviewAuthorizer(currentUser)
+	_, restErr := authorizer.Authorize("moderationQueue")
+	if restErr != nil {
+		c.JSON(restErr.Status(), restErr)
+		return
+	}
+
+	params, paramsErr := WhitelistQueryParams(c, append([]string{"restaurant_id"}, reviewFilters...))
+	if paramsErr != nil {
+		c.JSON(paramsErr.Status(), paramsErr)
+		return
+	}
+	if params.Get("sort") == "" {
+		params.Set("sort", "updated_at")
+	}
+
+	result, total, err := ctr.dao.SearchReviews(params, database.Scope{"status": dto.ReviewHidden})
+	if err != nil {
+		c.JSON(err.Status(), err)
+		return
+	}
+	meta, links := PaginationMeta(c, params, total, result)
+
+	collection := result.CollectionFor(currentUser.Role)
+	jsonapi := serializers.NewCollectionSerializer(collection, meta, links)
+	c.JSON(http.StatusOK, jsonapi)
+}
+
+/* Reply sets the public reply of the restaurant to a review: PUT /reviews/:reviewId/reply */
+func (ctr *reviewsHandler) Reply(c *gin.Context) {
+	record, permissions, restErr := ctr.review(c, "reply")
+	if restErr != nil {
+		c.JSON(restErr.Status(), restErr)
+		return
+	}
+	if record.Status == dto.ReviewRemoved {
+		restErr := rest_errors.NewRestError("A removed review can't be replied to", http.StatusConflict, "conflict", nil)
+		c.JSON(restErr.Status(), restErr)
+		return
+	}
+
+	/* Extract request body as map */
+	var mapBody map[string]interface{}
+	jsonData, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		restErr := rest_errors.NewBadRequestError("invalid json body")
+		c.JSON(restErr.Status(), restErr)
+		return
+	}
+	json.Unmarshal(jsonData, &mapBody)
+	payload := ctr.base.SetData(mapBody)
+	payload.Permit([]string{"reply"})
+
+	reply := &dto.ReviewReplyPayload{}
+	mapstructure.Decode(payload.Data, reply)
+	if err := Validate.Struct(reply); err != nil {
+		restErr := rest_errors.NewValidationError(rest_errors.StructValidationErrors(err))
+		c.JSON(restErr.Status(), restErr)
+		return
+	}
+
+	result, replyErr := ctr.dao.ReplyReview(record, reply.Reply)
+	if replyErr != nil {
+		c.JSON(replyErr.Status(), replyErr)
+		return
+	}
+
+	meta := map[string]interface{}{
+		"permissions": permissions,
+	}
+	resource := result.MemberFor(ctr.base.CurrentUser(c).Role)
+	jsonPayload := serializers.NewMemberSerializer(resource, nil, nil, meta)
+	c.JSON(http.StatusOK, jsonPayload)
+}
+
+/* Transition applies an event of the review moderation: POST /reviews/:reviewId/transitions/:event */
+func (ctr *reviewsHandler) Transition(c *gin.Context) {
+	event := c.Param("event")
+	if _, exists := dto.ReviewTransitions[event]; !exists {
+		restErr := rest_errors.NewNotFoundError(fmt.Sprintf("Sorry, %s is not a review transition", event))
+		c.JSON(restErr.Status(), restErr)
+		return
+	}
+
+	record, permissions, restErr := ctr.review(c, event)
+	if restErr != nil {
+		c.JSON(restErr.Status(), restErr)
+		return
+	}
+
+	transition, allowed := record.Transition(event)
+	if !allowed {
+		restErr := rest_errors.NewRestError(fmt.Sprintf("A %s review can't be %s", record.Status, event), http.StatusConflict, "conflict", nil)
+		c.JSON(restErr.Status(), restErr)
+		return
+	}
+
+	result, transitionErr := ctr.dao.TransitionReview(record, transition.To)
+	if transitionErr != nil {
+		c.JSON(transitionErr.Status(), transitionErr)
+		return
+	}
+
+	meta := map[string]interface{}{
+		"permissions": permissions,
+	}
+	resource := result.MemberFor(ctr.base.CurrentUser(c).Role)
+	jsonPayload := serializers.NewMemberSerializer(resource, nil, nil, meta)
+	c.JSON(http.StatusOK, jsonPayload)
+}
@@ -0,0 +1,139 @@
+package handlers
+
+import (
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/exp/slices"
+	"resturants-hub.com/m/v2/dao"
+	consts "resturants-hub.com/m/v2/packages/const"
+	rest_errors "resturants-hub.com/m/v2/packages/utils"
+	"resturants-hub.com/m/v2/serializers"
+)
+
+/* Resources searchable through /api/search, in the order their results are listed on equal rank */
+var searchableResources = []consts.ResourceType{consts.Restaurants, consts.Pages, consts.Dishes}
+
+type SearchHandler interface {
+	Search(c *gin.Context)
+}
+
+type searchHandler struct {
+	dao  dao.SearchDao
+	base BaseHandler
+}
+
+func NewSearchHandler() SearchHandler {
+	return &searchHandler{
+		dao:  dao.NewSearchDao(),
+		base: NewBaseHandler(),
+	}
+}
+
+type rankedResource struct {
+	resource interface{}
+	rank     float64
+}
+
+/*
+Search runs a full text search (?q=) over restaurants, pages and dishes, or only the resources listed in ?type=.
+Resources the user can't list are skipped; size limits the number of results of each resource,
+while meta counts all the matching records (total, and per resource in types).
+*/
+func (ctr *searchHandler) Search(c *gin.Context) {
+	currentUser := ctr.base.CurrentUser(c)
+
+	text := strings.TrimSpace(c.Query("q"))
+	if text == "" {
+		restErr := rest_errors.NewBadRequestError("q is required")
+		c.JSON(restErr.Status(), restErr)
+		return
+	}
+
+	resources, restErr := searchTypes(c.Query("type"))
+	if restErr != nil {
+		c.JSON(restErr.Status(), restErr)
+		return
+	}
+
+	params := url.Values{"size": c.QueryArray("size")}
+	results := []rankedResource{}
+	totals := map[string]interface{}{}
+	var total uint64
+	for _, resource := range resources {
+		if !currentUser.Can("accessCollection", resource) {
+			continue
+		}
+
+		var collection []interface{}
+		var ranks []float64
+		var count uint64
+		var err rest_errors.RestErr
+		switch resource {
+		case consts.Restaurants:
+			records, recordRanks, recordCount, searchErr := ctr.dao.FullTextRestaurants(text, params, currentUser)
+			collection, ranks, count, err = records.CollectionFor(currentUser.Role), recordRanks, recordCount, searchErr
+		case consts.Pages:
+			records, recordRanks, recordCount, searchErr := ctr.dao.FullTextPages(text, params, currentUser)
+			collection, ranks, count, err = records.CollectionFor(currentUser.Role), recordRanks, recordCount, searchErr
+		case consts.Dishes:
+			records, recordRanks, recordCount, searchErr := ctr.dao.FullTextDishes(text, params, currentUser)
+			collection, ranks, count, err = records.CollectionFor(currentUser.Role), recordRanks, recordCount, searchErr
+		}
+		if err != nil {
+			c.JSON(err.Status(), err)
+			return
+		}
+
+		for i, item := range collection {
+			results = append(results, rankedResource{resource: item, rank: ranks[i]})
+		}
+		totals[string(resource)] = count
+		total += count
+	}
+
+	/* Interleave the results of all resources, most relevant first */
+	sort.SliceStable(results, func(i, j int) bool {
+		return results[i].rank > results[j].rank
+	})
+	data := make([]interface{}, len(results))
+	for i, result := range results {
+		data[i] = result.resource
+	}
+
+	meta := map[string]interface{}{
+		"total": total,
+		"types": totals,
+	}
+	jsonapi := serializers.NewCollectionSerializer(data, meta, nil)
+	c.JSON(http.StatusOK, jsonapi)
+}
+
+func searchTypes(param string) ([]consts.ResourceType, rest_errors.RestErr) {
+	if param == "" {
+		return searchableResources, nil
+	}
+
+	resources := []consts.ResourceType{}
+	causes := rest_errors.ValidationErrs{}
+	for _, name := range strings.Split(param, ",") {
+		resource := consts.ResourceType(strings.TrimSpace(name))
+		if !slices.Contains(searchableResources, resource) {
+			causes["type"] = append(causes["type"], map[string]interface{}{
+				"error":     "unsupported_type",
+				"type":      resource,
+				"supported": searchableResources,
+			})
+			continue
+		}
+		resources = append(resources, resource)
+	}
+
+	if len(causes) > 0 {
+		return nil, rest_errors.NewRestError("Invalid search type", http.StatusBadRequest, "bad_request", causes)
+	}
+	return resources, nil
+}
@@ -2,97 +2,211 @@ package handlers
 
 import (
 	"context"
+	"crypto/subtle"
+	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"os"
+	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"golang.org/x/oauth2"
 	"resturants-hub.com/m/v2/configs"
 	"resturants-hub.com/m/v2/dao"
 	"resturants-hub.com/m/v2/dto"
-	consts "resturants-hub.com/m/v2/packages/const"
+	"resturants-hub.com/m/v2/packages/types"
 	rest_errors "resturants-hub.com/m/v2/packages/utils"
+	"resturants-hub.com/m/v2/serializers"
 	"resturants-hub.com/m/v2/services"
 )
 
 type SsoHandler interface {
+	ListProviders(c *gin.Context)
 	SsoLogin(c *gin.Context)
+	LinkProvider(c *gin.Context)
 	RenewSession(c *gin.Context)
 	Callback(c *gin.Context)
 	Logout(c *gin.Context)
+	ListSessions(c *gin.Context)
+	RevokeSession(c *gin.Context)
 }
 
 type ssoHandler struct {
 	service        services.SessionService
+	states         services.OAuthStateStore
 	usersDao       dao.UsersDao
 	invitationsDao dao.InvitationsDao
+	identitiesDao  dao.UserIdentitiesDao
 }
 
 func NewSsoHandler() SsoHandler {
 	return &ssoHandler{
 		service:        services.NewSessionService(),
+		states:         services.NewOAuthStateStore(),
 		usersDao:       dao.NewUsersDao(),
 		invitationsDao: dao.NewInvitationDao(),
+		identitiesDao:  dao.NewUserIdentitiesDao(),
 	}
 }
 
+const defaultStateCookieName = "EATERY_OAUTH_STATE"
+
+/* The state of the login in progress is bound to the browser which started it through this cookie */
+func stateCookieName() string {
+	if name := os.Getenv("OAUTH_STATE_COOKIE_NAME"); name != "" {
+		return name
+	}
+	return defaultStateCookieName
+}
+
+/* setStateCookie binds the state to the browser, maxAge -1 clears it once the state is consumed */
+func setStateCookie(c *gin.Context, state string, maxAge int) {
+	cookie := authCookie(stateCookieName(), state, "/api/auth", maxAge)
+	/* Strict would keep the cookie from the top level navigation back from the provider, Lax lets it along */
+	if cookie.SameSite == http.SameSiteStrictMode {
+		cookie.SameSite = http.SameSiteLaxMode
+	}
+	http.SetCookie(c.Writer, cookie)
+}
+
+/* providerConfig configures the provider of the registry named provider, the endpoints of OIDC ones are discovered from their issuer */
+func providerConfig(provider string) (*configs.SsoConfig, rest_errors.RestErr) {
+	if provider == "" {
+		return nil, rest_errors.NewBadRequestError("provider is required")
+	}
+	config, err := configs.NewSsoConfig(provider)
+	if errors.Is(err, configs.ErrUnknownProvider) {
+		return nil, rest_errors.NewNotFoundError(fmt.Sprintf("Sorry, the SSO provider %s doesn't exist", provider))
+	}
+	if err != nil {
+		return nil, rest_errors.NewInternalServerError(err)
+	}
+	return config, nil
+}
+
+/* ListProviders lists the providers of the registry, for the login page to show a button per provider */
+func (handler *ssoHandler) ListProviders(c *gin.Context) {
+	providers := configs.SsoProviders()
+	collection := make([]interface{}, len(providers))
+	for index, provider := range providers {
+		collection[index] = map[string]interface{}{
+			"id":   provider.Name,
+			"type": "ssoProviders",
+			"attributes": map[string]interface{}{
+				"name":     provider.Name,
+				"label":    provider.Label,
+				"loginUrl": "/api/auth/" + provider.Name,
+			},
+		}
+	}
+	c.JSON(http.StatusOK, serializers.NewCollectionSerializer(collection, map[string]interface{}{"total": len(providers)}, nil))
+}
+
 /**
 * SsoLogin handles the initial login process for single sign-on (SSO).
-* This function generates a PKCE verifier and state, sets them in memory cache,
-* and redirects the user to the SSO provider's authorization URL. The SSO provider is determined
-* by the 'provider' query parameter from the request URL. If no provider is specified, it returns
-* a bad request error with an appropriate message.
+* This function generates a random state along with its PKCE verifier, saves them in the state store,
+* binds the state to the browser through a cookie and redirects the user to the SSO provider's authorization URL.
+* The SSO provider is determined by the 'provider' param of the request URL. If no provider is specified,
+* it returns a bad request error with an appropriate message.
 *
 * @param c *gin.Context: The Gin context for handling HTTP requests and responses.
  */
 func (handler *ssoHandler) SsoLogin(c *gin.Context) {
-	// use PKCE to protect against CSRF attacks
-	// https://www.ietf.org/archive/id/draft-ietf-oauth-security-topics-22.html#name-countermeasures-6
-	verifier := oauth2.GenerateVerifier()
-	state := "state"
+	handler.authorize(c, types.NullInt{})
+}
 
-	configs.MemoryCache.Set(state, verifier)
+/* LinkProvider sends the signed in user to the provider of the :provider param, to link their account there on callback */
+func (handler *ssoHandler) LinkProvider(c *gin.Context) {
+	currentSession, exists := c.Get("currentSession")
+	if !exists {
+		restErr := rest_errors.NewUnauthorizedError("Unauthorised user. No active session")
+		c.JSON(restErr.Status(), restErr)
+		return
+	}
+	userId := currentSession.(*dto.Session).UserId
+	handler.authorize(c, types.NullInt{NullInt64: sql.NullInt64{Int64: userId, Valid: true}})
+}
 
+/* authorize redirects to the provider of the :provider param, the login links the provider to userId when it is set */
+func (handler *ssoHandler) authorize(c *gin.Context, userId types.NullInt) {
 	// extract provider from URL and get new SSO config for provider
 	provider := GetIdentifierFromUrl(c, "provider", false)
-	if provider == "" {
-		slugErr := rest_errors.NewBadRequestError("slug is required")
-		c.JSON(slugErr.Status(), slugErr)
+	ssoConfig, configErr := providerConfig(provider)
+	if configErr != nil {
+		c.JSON(configErr.Status(), configErr)
 		return
 	}
-	ssoConfig := configs.NewSsoConfig(consts.SsoProvider(provider))
+
+	// use a random, single use state along with PKCE to protect against CSRF attacks
+	// https://www.ietf.org/archive/id/draft-ietf-oauth-security-topics-22.html#name-countermeasures-6
+	state, err := services.NewOAuthState(provider)
+	if err != nil {
+		restErr := rest_errors.NewInternalServerError(err)
+		c.JSON(restErr.Status(), restErr)
+		return
+	}
+	state.UserId = userId
+	if saveErr := handler.states.Save(state); saveErr != nil {
+		c.JSON(saveErr.Status(), saveErr)
+		return
+	}
+	setStateCookie(c, state.State, int(services.OAuthStateTTL().Seconds()))
 
 	// Redirect user to consent page to ask for permission
 	// for the scopes specified above.
-	url := ssoConfig.AuthCodeURL(state, oauth2.AccessTypeOffline, oauth2.S256ChallengeOption(verifier))
+	options := append(ssoConfig.AuthCodeOptions(), oauth2.S256ChallengeOption(state.Verifier))
+	if ssoConfig.Provider.IsOidc() {
+		options = append(options, oauth2.SetAuthURLParam("nonce", state.Nonce))
+	}
+	url := ssoConfig.AuthCodeURL(state.State, options...)
 	c.Redirect(http.StatusFound, url)
 }
 
-func (handler *ssoHandler) Callback(c *gin.Context) {
-	queryParams := c.Request.URL.Query()
-
-	ctx := context.Background()
-	code := queryParams.Get("code")
-	state := queryParams.Get("state")
+/*
+consumeState checks the state the provider sent back: it has to be the one bound to the browser, to have been
+issued for the same provider and not to have expired. The state is consumed, it can't be used a second time.
+*/
+func (handler *ssoHandler) consumeState(c *gin.Context, provider string) (*dto.OAuthState, rest_errors.RestErr) {
+	state := c.Query("state")
+	bound, _ := c.Cookie(stateCookieName())
+	setStateCookie(c, "", -1)
+	if state == "" || subtle.ConstantTimeCompare([]byte(state), []byte(bound)) != 1 {
+		return nil, rest_errors.NewBadRequestError("OAuth state mismatch")
+	}
 
-	verifier, err := configs.MemoryCache.Get(state)
-	if err != nil {
-		fmt.Println(err)
+	consumed, consumeErr := handler.states.Consume(state)
+	if consumeErr != nil {
+		return nil, consumeErr
+	}
+	if consumed.Provider != provider {
+		return nil, rest_errors.NewBadRequestError("OAuth state mismatch")
 	}
+	return consumed, nil
+}
+
+func (handler *ssoHandler) Callback(c *gin.Context) {
+	ctx := context.Background()
+	code := c.Query("code")
 
 	// extract provider from URL and get new SSO config for provider
 	provider := GetIdentifierFromUrl(c, "provider", false)
-	if provider == "" {
-		slugErr := rest_errors.NewBadRequestError("slug is required")
-		c.JSON(slugErr.Status(), slugErr)
+	ssoConfig, configErr := providerConfig(provider)
+	if configErr != nil {
+		c.JSON(configErr.Status(), configErr)
 		return
 	}
-	ssoConfig := configs.NewSsoConfig(consts.SsoProvider(provider))
 
-	token, err := ssoConfig.Exchange(ctx, code, oauth2.VerifierOption(verifier))
+	state, stateErr := handler.consumeState(c, provider)
+	if stateErr != nil {
+		c.JSON(stateErr.Status(), stateErr)
+		return
+	}
+
+	token, err := ssoConfig.Exchange(ctx, code, oauth2.VerifierOption(state.Verifier))
 	if err != nil {
 		fmt.Println("Error on SSO token exchange:", err)
 		restErr := rest_errors.NewInternalServerError(err)
@@ -100,60 +214,174 @@ func (handler *ssoHandler) Callback(c *gin.Context) {
 		return
 	}
 
-	// Retrieve user data by token
-	client := ssoConfig.Client(c, token)
-	userInfourl := ssoConfig.UserInfoUrl + "?access_token=" + token.AccessToken
-	userData, err := handler.RetrieveUserInfo(client, userInfourl)
-	if err != nil {
-		fmt.Println("Retrieve user error:", err)
-		restErr := rest_errors.NewInternalServerError(err)
+	// The identity of the user of OIDC providers is taken from the verified claims of the ID token
+	idToken, _ := token.Extra("id_token").(string)
+	identity := &dto.SsoUserInfo{}
+	if ssoConfig.Provider.IsOidc() {
+		claims, err := ssoConfig.VerifyIDToken(idToken, state.Nonce)
+		if err != nil {
+			restErr := rest_errors.NewUnauthorizedError(err.Error())
+			c.JSON(restErr.Status(), restErr)
+			return
+		}
+		identity = ssoConfig.Provider.Claims.Identity(claims)
+	}
+
+	// Plain OAuth2 providers, and OIDC ones leaving the profile out of the ID token, serve it from their user info endpoint
+	if identity.Email == "" && ssoConfig.UserInfoUrl() != "" {
+		profile, err := handler.RetrieveUserInfo(ssoConfig.Client(ctx, token), ssoConfig.UserInfoUrl())
+		if err != nil {
+			fmt.Println("Retrieve user error:", err)
+			restErr := rest_errors.NewInternalServerError(err)
+			c.JSON(restErr.Status(), restErr)
+			return
+		}
+		profileIdentity := ssoConfig.Provider.Claims.Identity(profile)
+		if ssoConfig.Provider.IsOidc() && profileIdentity.Sub != identity.Sub {
+			restErr := rest_errors.NewUnauthorizedError("User info is about another user than the ID token")
+			c.JSON(restErr.Status(), restErr)
+			return
+		}
+		identity = profileIdentity
+	}
+	if identity.Sub == "" {
+		restErr := rest_errors.NewUnauthorizedError("The provider didn't share the id of the user")
 		c.JSON(restErr.Status(), restErr)
 		return
 	}
 
-	// Check if user is registered
-	user := handler.usersDao.Where(map[string]interface{}{"email": userData.Email})
+	// Logins started by a signed in user link the provider to their account instead
+	if state.UserId.Valid {
+		handler.linkIdentity(c, provider, identity, state.UserId.Int64)
+		return
+	}
+
+	user := handler.resolveUser(c, provider, identity)
 	if user == nil {
-		// Check if user has a valid invitation
+		return
+	}
+
+	// save session, the tokens of the provider stay on the server
+	session, sessionErr := handler.service.CreateSession(&dto.Session{
+		Provider:          provider,
+		AccessToken:       token.AccessToken,
+		RefreshToken:      token.RefreshToken,
+		ProviderExpiresAt: providerExpiry(token),
+		IDToken:           idToken,
+		Email:             user.Email,
+		UserId:            user.Id,
+	})
+	if sessionErr != nil {
+		c.JSON(sessionErr.Status(), sessionErr)
+		return
+	}
+	// Finally, we set the client cookie for "token"
+	setCookie(c, session)
+
+	c.JSON(http.StatusOK, session)
+}
+
+/*
+resolveUser finds the user signing in through the identity linked to their account at the provider. Users signing in
+with a provider for the first time are found by email, as long as the provider verified it, and new users sign up
+through their invitation: the identity is linked to them either way. Errors are written to the response, nil is returned then.
+*/
+func (handler *ssoHandler) resolveUser(c *gin.Context, provider string, identity *dto.SsoUserInfo) *dto.User {
+	linked, _ := handler.identitiesDao.FindUserIdentity(provider, identity.Sub)
+	if linked != nil {
+		user, getErr := handler.usersDao.GetUser(&linked.UserId)
+		if getErr != nil {
+			c.JSON(getErr.Status(), getErr)
+			return nil
+		}
+		// The email may have changed at the provider, the identity shows the current one
+		if identity.Email != "" && identity.Email != linked.Email {
+			if _, updateErr := handler.identitiesDao.UpdateUserIdentityEmail(linked, identity.Email); updateErr != nil {
+				c.JSON(updateErr.Status(), updateErr)
+				return nil
+			}
+		}
+		return user
+	}
 
-		invitation := handler.validateInvitation(userData.Email, c)
+	if identity.Email == "" {
+		restErr := rest_errors.NewUnauthorizedError("The provider didn't share the email of the user")
+		c.JSON(restErr.Status(), restErr)
+		return nil
+	}
+	// Anyone could claim an unverified email at the provider
+	if !identity.EmailVerified {
+		restErr := rest_errors.NewForbiddenError("The provider didn't verify the email of the user, sign in with a provider linked to your account")
+		c.JSON(restErr.Status(), restErr)
+		return nil
+	}
 
+	// Check if user is registered
+	user := handler.usersDao.Where(map[string]interface{}{"email": identity.Email})
+	if user == nil {
 		// If user is not registered, check if user has a valid invitation
+		invitation := handler.validateInvitation(identity.Email, c)
 		if invitation == nil {
-			return
+			return nil
 		}
 
 		// Create new user with role from invitation
-		userData.Role = invitation.Role
-		newUser, restErr := handler.usersDao.CreateUser(userData)
+		newUser, restErr := handler.usersDao.CreateUser(&dto.CreateUserPayload{
+			Email:     identity.Email,
+			FirstName: identity.GivenName,
+			LastName:  identity.FamilyName,
+			AvatarURL: identity.Picture,
+			Role:      invitation.Role,
+		})
 		if restErr != nil {
-			fmt.Println("New user created:", restErr)
+			fmt.Println("Failed to create user from invitation:", restErr)
 			c.JSON(restErr.Status(), restErr)
-			return
+			return nil
 		}
 		user = newUser
 	}
 
-	// save session
-	session := (dto.Session{
-		Provider:     "google",
-		AccessToken:  token.AccessToken,
-		ExpiresAt:    token.Expiry,
-		RefreshToken: token.RefreshToken,
-		Email:        user.Email,
-		UserId:       user.Id,
-	})
-	_, error := handler.service.CreateSession(&session)
+	if _, linkErr := handler.identitiesDao.CreateUserIdentity(&dto.CreateUserIdentityPayload{
+		UserId:   user.Id,
+		Provider: provider,
+		Subject:  identity.Sub,
+		Email:    identity.Email,
+	}); linkErr != nil {
+		c.JSON(linkErr.Status(), linkErr)
+		return nil
+	}
+	return user
+}
 
-	// save session and return user
-	if error != nil {
-		c.JSON(error.Status(), err)
+/* linkIdentity links the account of the user at the provider to userId, unless it is linked to another user already */
+func (handler *ssoHandler) linkIdentity(c *gin.Context, provider string, identity *dto.SsoUserInfo, userId int64) {
+	user, getErr := handler.usersDao.GetUser(&userId)
+	if getErr != nil {
+		c.JSON(getErr.Status(), getErr)
 		return
 	}
-	// Finally, we set the client cookie for "token"
-	setCookie(c, &session)
 
-	c.JSON(http.StatusOK, session)
+	linked, _ := handler.identitiesDao.FindUserIdentity(provider, identity.Sub)
+	if linked == nil {
+		var linkErr rest_errors.RestErr
+		linked, linkErr = handler.identitiesDao.CreateUserIdentity(&dto.CreateUserIdentityPayload{
+			UserId:   user.Id,
+			Provider: provider,
+			Subject:  identity.Sub,
+			Email:    identity.Email,
+		})
+		if linkErr != nil {
+			c.JSON(linkErr.Status(), linkErr)
+			return
+		}
+	} else if linked.UserId != user.Id {
+		restErr := rest_errors.NewRestError(fmt.Sprintf("This %s account is linked to another user", provider), http.StatusConflict, "conflict", nil)
+		c.JSON(restErr.Status(), restErr)
+		return
+	}
+
+	resource := linked.MemberFor(user.Role)
+	c.JSON(http.StatusOK, serializers.NewMemberSerializer(resource, nil, nil, nil))
 }
 
 func (handler *ssoHandler) RenewSession(c *gin.Context) {
@@ -166,35 +394,33 @@ func (handler *ssoHandler) RenewSession(c *gin.Context) {
 	}
 	session := currentSession.(*dto.Session)
 
-	// extract provider from URL and get new SSO config for provider
-	provider := GetIdentifierFromUrl(c, "provider", false)
-	if provider == "" {
-		slugErr := rest_errors.NewBadRequestError("slug is required")
-		c.JSON(slugErr.Status(), slugErr)
-		return
-	}
-	ssoConfig := configs.NewSsoConfig(consts.SsoProvider(provider))
+	renewal := *session
 
-	tokenSource := ssoConfig.TokenSource(context.Background(), &oauth2.Token{
-		RefreshToken: session.RefreshToken,
-	})
+	// the tokens of the provider the user logged in with are refreshed along, when it issued a refresh token
+	if session.RefreshToken != "" {
+		ssoConfig, configErr := providerConfig(session.Provider)
+		if configErr != nil {
+			c.JSON(configErr.Status(), configErr)
+			return
+		}
 
-	token, error := tokenSource.Token()
-	if error != nil {
-		restErr := rest_errors.NewUnauthorizedError("Failed to renew session")
-		c.JSON(restErr.Status(), restErr)
-		return
-	}
+		tokenSource := ssoConfig.TokenSource(context.Background(), &oauth2.Token{
+			RefreshToken: session.RefreshToken,
+		})
 
-	// fmt.Println(token.Expiry, token.AccessToken)
+		token, err := tokenSource.Token()
+		if err != nil {
+			restErr := rest_errors.NewUnauthorizedError("Failed to renew session")
+			c.JSON(restErr.Status(), restErr)
+			return
+		}
+		renewal.AccessToken = token.AccessToken
+		renewal.RefreshToken = token.RefreshToken
+		renewal.ProviderExpiresAt = providerExpiry(token)
+	}
 
-	// save session
-	newSession, restErr := handler.service.RenewSession(&dto.Session{
-		Id:           session.Id,
-		AccessToken:  token.AccessToken,
-		ExpiresAt:    token.Expiry,
-		RefreshToken: token.RefreshToken,
-	})
+	// save session under a new token
+	newSession, restErr := handler.service.RenewSession(&renewal)
 
 	// save session and return user
 	if restErr != nil {
@@ -218,50 +444,137 @@ func (handler *ssoHandler) Logout(c *gin.Context) {
 		return
 	}
 
-	// Invalidate token by setting expired to current DateTime.
+	// Revoke the session, its token is no longer accepted
 	session := currentSession.(*dto.Session)
-	_, err := handler.service.InvalidateToken(session)
-	if err != nil {
+	if _, err := handler.service.RevokeSession(session.UserId, session.Id); err != nil {
 		c.JSON(err.Status(), err)
 		return
 	}
 
 	// Clear cookie and return success response
-	c.SetCookie(os.Getenv("AUTH_COOKIE_NAME"), "", -1, "/", "localhost", false, true)
+	clearCookie(c)
 	c.JSON(http.StatusOK, "Success")
 }
 
-func (handler *ssoHandler) RetrieveUserInfo(client *http.Client, userInfourl string) (*dto.CreateUserPayload, error) {
-	response, err := client.Get(userInfourl)
+/* ListSessions lists the sessions the current user is signed in with, on any device */
+func (handler *ssoHandler) ListSessions(c *gin.Context) {
+	currentSession, exists := c.Get("currentSession")
+	if !exists {
+		restErr := rest_errors.NewUnauthorizedError("Unauthorised user. No active session")
+		c.JSON(restErr.Status(), restErr)
+		return
+	}
+	session := currentSession.(*dto.Session)
+
+	sessions, listErr := handler.service.ListSessions(session.UserId)
+	if listErr != nil {
+		c.JSON(listErr.Status(), listErr)
+		return
+	}
+
+	meta := map[string]interface{}{
+		"total":            len(sessions),
+		"currentSessionId": session.Id,
+	}
+	currentUser := c.MustGet("currentUser").(*dto.BaseUser)
+	collection := sessions.CollectionFor(currentUser.Role)
+	c.JSON(http.StatusOK, serializers.NewCollectionSerializer(collection, meta, nil))
+}
+
+/* RevokeSession signs the current user out of the session of the :id param, e.g. on a lost device */
+func (handler *ssoHandler) RevokeSession(c *gin.Context) {
+	currentSession, exists := c.Get("currentSession")
+	if !exists {
+		restErr := rest_errors.NewUnauthorizedError("Unauthorised user. No active session")
+		c.JSON(restErr.Status(), restErr)
+		return
+	}
+	session := currentSession.(*dto.Session)
+
+	sessionId, idErr := GetIdFromUrl(c, false)
+	if idErr != nil {
+		c.JSON(idErr.Status(), idErr)
+		return
+	}
+
+	if _, revokeErr := handler.service.RevokeSession(session.UserId, sessionId); revokeErr != nil {
+		c.JSON(revokeErr.Status(), revokeErr)
+		return
+	}
+	if sessionId == session.Id {
+		clearCookie(c)
+	}
 
+	c.Status(http.StatusNoContent)
+}
+
+/* RetrieveUserInfo reads the claims of the profile of the user from the user info endpoint of the provider */
+func (handler *ssoHandler) RetrieveUserInfo(client *http.Client, userInfoUrl string) (map[string]interface{}, error) {
+	// The client sends the access token in the Authorization header
+	response, err := client.Get(userInfoUrl)
 	if err != nil {
 		return nil, err
 	}
-
 	defer response.Body.Close()
 
+	if response.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("user info responded with status %d", response.StatusCode)
+	}
 	contents, err := io.ReadAll(response.Body)
-
 	if err != nil {
 		return nil, fmt.Errorf("failed read response: %s", err.Error())
 	}
 
-	data := &dto.SsoUserInfo{}
-	err = json.Unmarshal(contents, data)
-	if err != nil {
-		panic(err)
+	data := map[string]interface{}{}
+	if err := json.Unmarshal(contents, &data); err != nil {
+		return nil, fmt.Errorf("failed to parse user info: %s", err.Error())
 	}
+	return data, nil
+}
 
-	return &dto.CreateUserPayload{
-		Email:     data.Email,
-		FirstName: data.GivenName,
-		LastName:  data.FamilyName,
-		AvatarURL: data.Picture,
-	}, nil
+/* providerExpiry is when the access token of the provider expires, providers may leave it out */
+func providerExpiry(token *oauth2.Token) types.NullTime {
+	return types.NullTime{NullTime: sql.NullTime{Time: token.Expiry.UTC(), Valid: !token.Expiry.IsZero()}}
 }
 
+/* setCookie hands the token of the session to the browser, for as long as the session lasts */
 func setCookie(c *gin.Context, session *dto.Session) {
-	c.SetCookie(os.Getenv("AUTH_COOKIE_NAME"), session.AccessToken, 2000, "/", "localhost", false, false)
+	setSessionCookie(c, session.Token, int(time.Until(session.ExpiresAt).Seconds()))
+}
+
+func clearCookie(c *gin.Context) {
+	setSessionCookie(c, "", -1)
+}
+
+/* setSessionCookie keeps the token out of the reach of scripts, see authCookie */
+func setSessionCookie(c *gin.Context, token string, maxAge int) {
+	http.SetCookie(c.Writer, authCookie(os.Getenv("AUTH_COOKIE_NAME"), token, "/", maxAge))
+}
+
+/*
+authCookie is a cookie of the login (session or OAuth state), hidden from scripts. It is scoped to AUTH_COOKIE_DOMAIN
+(the host of the request when unset), it is only sent over HTTPS unless AUTH_COOKIE_SECURE is "false" (local development)
+and AUTH_COOKIE_SAME_SITE is one of "lax" (the default), "strict" or "none".
+*/
+func authCookie(name string, value string, path string, maxAge int) *http.Cookie {
+	sameSite := http.SameSiteLaxMode
+	switch strings.ToLower(os.Getenv("AUTH_COOKIE_SAME_SITE")) {
+	case "strict":
+		sameSite = http.SameSiteStrictMode
+	case "none":
+		sameSite = http.SameSiteNoneMode
+	}
+
+	return &http.Cookie{
+		Name:     name,
+		Value:    value,
+		Path:     path,
+		Domain:   os.Getenv("AUTH_COOKIE_DOMAIN"),
+		MaxAge:   maxAge,
+		Secure:   os.Getenv("AUTH_COOKIE_SECURE") != "false",
+		HttpOnly: true,
+		SameSite: sameSite,
+	}
 }
 
 func (handler *ssoHandler) validateInvitation(email string, c *gin.Context) *dto.Invitation {
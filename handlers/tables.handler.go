@@ -0,0 +1,245 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mitchellh/mapstructure"
+	"resturants-hub.com/m/v2/authorizer"
+	"resturants-hub.com/m/v2/dao"
+	"resturants-hub.com/m/v2/dto"
+	rest_errors "resturants-hub.com/m/v2/packages/utils"
+	"resturants-hub.com/m/v2/serializers"
+)
+
+type TablesHandler interface {
+	Create(c *gin.Context)
+	Get(c *gin.Context)
+	List(c *gin.Context)
+	Update(c *gin.Context)
+	Delete(c *gin.Context)
+}
+
+type tablesHandler struct {
+	dao  dao.TablesDao
+	base BaseHandler
+}
+
+func NewTablesHandler() TablesHandler {
+	return &tablesHandler{
+		dao:  dao.NewTablesDao(),
+		base: NewBaseHandler(),
+	}
+}
+
+/*
+managedRestaurantId resolves the restaurant of resources nested in it. Admin routes carry the restaurant id
+in the URL (/restaurants/:id/tables) while manager routes (/my-restaurant/tables) use the restaurant of the current user.
+*/
+func managedRestaurantId(c *gin.Context, currentUser *dto.BaseUser) (int64, rest_errors.RestErr) {
+	if c.Param("id") != "" {
+		return GetIdFromUrl(c, false)
+	}
+
+	if !currentUser.RestaurantId.Valid {
+		return 0, rest_errors.NewNotFoundError("Sorry, you are not managing any restaurant yet")
+	}
+	return currentUser.RestaurantId.Int64, nil
+}
+
+func (ctr *tablesHandler) Create(c *gin.Context) {
+	currentUser := ctr.base.CurrentUser(c)
+	restaurantId, idErr := managedRestaurantId(c, currentUser)
+	if idErr != nil {
+		c.JSON(idErr.Status(), idErr)
+		return
+	}
+
+	/* Extract request body as map */
+	var mapBody map[string]interface{}
+	data, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		restErr := rest_errors.NewBadRequestError("invalid json body")
+		c.JSON(restErr.Status(), restErr)
+		return
+	}
+	json.Unmarshal(data, &mapBody)
+
+	/* Parse jsonapi payload and set attributes to data*/
+	payload := ctr.base.SetData(mapBody)
+	newRecord := &dto.CreateTablePayload{}
+	mapstructure.Decode(payload.Data, &newRecord)
+
+	/* Tables are always created for the restaurant in the URL (or the manager's restaurant) */
+	newRecord.RestaurantId = restaurantId
+
+	authorizer := authorizer.NewTableAuthorizer(currentUser, newRecord.RestaurantId)
+	permissions, restErr := authorizer.Authorize("create")
+	if restErr != nil {
+		c.JSON(restErr.Status(), restErr)
+		return
+	}
+
+	meta := map[string]interface{}{
+		"permissions": permissions,
+	}
+
+	if err := Validate.Struct(newRecord); err != nil {
+		restErr := rest_errors.NewValidationError(rest_errors.StructValidationErrors(err))
+		c.JSON(restErr.Status(), restErr)
+		return
+	}
+
+	table, createErr := ctr.dao.CreateTable(newRecord)
+	if createErr != nil {
+		c.JSON(createErr.Status(), createErr)
+		return
+	}
+
+	resource := table.MemberFor(currentUser.Role)
+	jsonPayload := serializers.NewMemberSerializer(resource, nil, nil, meta)
+	c.JSON(http.StatusOK, jsonPayload)
+}
+
+/* table finds the table of the :tableId param and authorizes the action on it for the current user */
+func (ctr *tablesHandler) table(c *gin.Context, action string) (*dto.Table, interface{}, rest_errors.RestErr) {
+	currentUser := ctr.base.CurrentUser(c)
+	restaurantId, idErr := managedRestaurantId(c, currentUser)
+	if idErr != nil {
+		return nil, nil, idErr
+	}
+
+	tableId, idErr := GetNumericParamFromUrl(c, "tableId")
+	if idErr != nil {
+		return nil, nil, idErr
+	}
+
+	table, getErr := ctr.dao.GetTable(&restaurantId, &tableId)
+	if getErr != nil {
+		return nil, nil, getErr
+	}
+
+	authorizer := authorizer.NewTableAuthorizer(currentUser, table.RestaurantId)
+	permissions, restErr := authorizer.Authorize(action)
+	if restErr != nil {
+		return nil, nil, restErr
+	}
+	return table, permissions, nil
+}
+
+func (ctr *tablesHandler) Get(c *gin.Context) {
+	table, permissions, restErr := ctr.table(c, "access")
+	if restErr != nil {
+		c.JSON(restErr.Status(), restErr)
+		return
+	}
+
+	meta := map[string]interface{}{
+		"permissions": permissions,
+	}
+
+	resource := table.MemberFor(ctr.base.CurrentUser(c).Role)
+	jsonapi := serializers.NewMemberSerializer(resource, nil, nil, meta)
+	c.JSON(http.StatusOK, jsonapi)
+}
+
+func (ctr *tablesHandler) Update(c *gin.Context) {
+	record, permissions, restErr := ctr.table(c, "update")
+	if restErr != nil {
+		c.JSON(restErr.Status(), restErr)
+		return
+	}
+
+	meta := map[string]interface{}{
+		"permissions": permissions,
+	}
+
+	/* Extract request body as map */
+	var mapBody map[string]interface{}
+	jsonData, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		restErr := rest_errors.NewBadRequestError("invalid json body")
+		c.JSON(restErr.Status(), restErr)
+		return
+	}
+
+	/* Validate required params and whitelisted payload data */
+	currentUser := ctr.base.CurrentUser(c)
+	json.Unmarshal(jsonData, &mapBody)
+	payload := ctr.base.SetData(mapBody)
+	payload.Permit(record.UpdableAttributes(currentUser.Role))
+
+	/* Skip empty data and patch with only new data if the update is partial(PATCH) */
+	isPartial := c.Request.Method == http.MethodPatch
+	if isPartial {
+		payload.ClearEmpty()
+	}
+
+	/* Return error if payload has eroor for require/permit */
+	if len(payload.Errors) > 0 {
+		c.JSON(payload.Errors[0].Status(), payload.Errors)
+		return
+	}
+
+	result, updateErr := ctr.dao.UpdateTable(record, payload.Data)
+	if updateErr != nil {
+		c.JSON(updateErr.Status(), updateErr)
+		return
+	}
+
+	resource := result.MemberFor(currentUser.Role)
+	jsonPayload := serializers.NewMemberSerializer(resource, nil, nil, meta)
+	c.JSON(http.StatusOK, jsonPayload)
+}
+
+func (ctr *tablesHandler) Delete(c *gin.Context) {
+	record, _, restErr := ctr.table(c, "delete")
+	if restErr != nil {
+		c.JSON(restErr.Status(), restErr)
+		return
+	}
+
+	if deleteErr := ctr.dao.DeleteTable(record); deleteErr != nil {
+		c.JSON(deleteErr.Status(), deleteErr)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+func (ctr *tablesHandler) List(c *gin.Context) {
+	currentUser := ctr.base.CurrentUser(c)
+	restaurantId, idErr := managedRestaurantId(c, currentUser)
+	if idErr != nil {
+		c.JSON(idErr.Status(), idErr)
+		return
+	}
+
+	authorizer := authorizer.NewTableAuthorizer(currentUser, restaurantId)
+	_, restErr := authorizer.Authorize("accessCollection")
+	if restErr != nil {
+		c.JSON(restErr.Status(), restErr)
+		return
+	}
+
+	params, paramsErr := WhitelistQueryParams(c, []string{"name", "seats", "active", "created_at"})
+	if paramsErr != nil {
+		c.JSON(paramsErr.Status(), paramsErr)
+		return
+	}
+	params.Set("restaurant_id", fmt.Sprint(restaurantId))
+
+	result, total, err := ctr.dao.SearchTables(params)
+	if err != nil {
+		c.JSON(err.Status(), err)
+		return
+	}
+	meta, links := PaginationMeta(c, params, total, result)
+
+	collection := result.CollectionFor(currentUser.Role)
+	jsonapi := serializers.NewCollectionSerializer(collection, meta, links)
+	c.JSON(http.StatusOK, jsonapi)
+}
@@ -20,20 +20,26 @@ type UsersHandler interface {
 	Update(c *gin.Context)
 	Get(c *gin.Context)
 	Profile(c *gin.Context)
+	ListIdentities(c *gin.Context)
+	UnlinkIdentity(c *gin.Context)
 	List(c *gin.Context)
+	Delete(c *gin.Context)
+	Restore(c *gin.Context)
 }
 
 type usersHandler struct {
-	service services.UsersService
-	dao     dao.UsersDao
-	base    BaseHandler
+	service       services.UsersService
+	dao           dao.UsersDao
+	identitiesDao dao.UserIdentitiesDao
+	base          BaseHandler
 }
 
 func NewUsersHandler() UsersHandler {
 	return &usersHandler{
-		service: services.NewUsersService(),
-		dao:     dao.NewUsersDao(),
-		base:    NewBaseHandler(),
+		service:       services.NewUsersService(),
+		dao:           dao.NewUsersDao(),
+		identitiesDao: dao.NewUserIdentitiesDao(),
+		base:          NewBaseHandler(),
 	}
 }
 
@@ -150,6 +156,58 @@ func (ctr *usersHandler) Profile(c *gin.Context) {
 	c.JSON(http.StatusOK, jsonapi)
 }
 
+/* ListIdentities lists the providers linked to the account of the current user, who signs in with any of them */
+func (ctr *usersHandler) ListIdentities(c *gin.Context) {
+	currentUser := ctr.base.CurrentUser(c)
+	identities, searchErr := ctr.identitiesDao.SearchUserIdentities(currentUser.Id)
+	if searchErr != nil {
+		c.JSON(searchErr.Status(), searchErr)
+		return
+	}
+
+	meta := map[string]interface{}{
+		"total": len(identities),
+	}
+	collection := identities.CollectionFor(currentUser.Role)
+	jsonapi := serializers.NewCollectionSerializer(collection, meta, nil)
+	c.JSON(http.StatusOK, jsonapi)
+}
+
+/* UnlinkIdentity unlinks the provider of the :identityId param from the account of the current user */
+func (ctr *usersHandler) UnlinkIdentity(c *gin.Context) {
+	identityId, idErr := GetNumericParamFromUrl(c, "identityId")
+	if idErr != nil {
+		c.JSON(idErr.Status(), idErr)
+		return
+	}
+
+	currentUser := ctr.base.CurrentUser(c)
+	identity, getErr := ctr.identitiesDao.GetUserIdentity(&currentUser.Id, &identityId)
+	if getErr != nil {
+		c.JSON(getErr.Status(), getErr)
+		return
+	}
+
+	/* Prevent users from locking themselves out */
+	identities, searchErr := ctr.identitiesDao.SearchUserIdentities(currentUser.Id)
+	if searchErr != nil {
+		c.JSON(searchErr.Status(), searchErr)
+		return
+	}
+	if len(identities) <= 1 {
+		restErr := rest_errors.NewBadRequestError("You can't unlink the last provider of your account")
+		c.JSON(restErr.Status(), restErr)
+		return
+	}
+
+	if deleteErr := ctr.identitiesDao.DeleteUserIdentity(identity); deleteErr != nil {
+		c.JSON(deleteErr.Status(), deleteErr)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
 func (ctr *usersHandler) Update(c *gin.Context) {
 	userId, idErr := GetIdFromUrl(c, false)
 	if idErr != nil {
@@ -224,18 +282,96 @@ func (ctr *usersHandler) List(c *gin.Context) {
 		return
 	}
 
-	params := WhitelistQueryParams(c, []string{"first_name", "email", "id", "last_name"})
-	result, err := ctr.dao.AuthorizedUsersCollection(params, currentUser)
+	params, paramsErr := WhitelistQueryParams(c, []string{"first_name", "email", "id", "last_name", "role", "restaurant_id", "created_at", dao.DeletedFilterParam})
+	if paramsErr != nil {
+		c.JSON(paramsErr.Status(), paramsErr)
+		return
+	}
+	result, total, err := ctr.dao.AuthorizedUsersCollection(params, currentUser)
 	if err != nil {
 		c.JSON(err.Status(), err)
 		return
 	}
 
+	meta, links := PaginationMeta(c, params, total, result)
+
+	collection := result.CollectionFor(currentUser.Role)
+	jsonapi := serializers.NewCollectionSerializer(collection, meta, links)
+	c.JSON(http.StatusOK, jsonapi)
+}
+
+func (ctr *usersHandler) Delete(c *gin.Context) {
+	userId, idErr := GetIdFromUrl(c, false)
+	if idErr != nil {
+		c.JSON(idErr.Status(), idErr)
+		return
+	}
+
+	/* Check if user exists with given Id */
+	user, getErr := ctr.service.GetUser(userId)
+	if getErr != nil {
+		c.JSON(getErr.Status(), getErr)
+		return
+	}
+
+	/* Authorize request for current user */
+	currentUser := ctr.base.CurrentUser(c)
+	authorizer := authorizer.NewUsersAuthorizer(currentUser, user.Id)
+	_, restErr := authorizer.Authorize("delete")
+	if restErr != nil {
+		c.JSON(restErr.Status(), restErr)
+		return
+	}
+
+	/* Prevent admins from locking themselves out */
+	if user.Id == currentUser.Id {
+		restErr := rest_errors.NewBadRequestError("You can't delete your own account")
+		c.JSON(restErr.Status(), restErr)
+		return
+	}
+
+	if deleteErr := ctr.dao.DeleteUser(user); deleteErr != nil {
+		c.JSON(deleteErr.Status(), deleteErr)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+func (ctr *usersHandler) Restore(c *gin.Context) {
+	userId, idErr := GetIdFromUrl(c, false)
+	if idErr != nil {
+		c.JSON(idErr.Status(), idErr)
+		return
+	}
+
+	/* Only deleted users can be restored */
+	user, getErr := ctr.dao.GetDeletedUser(&userId)
+	if getErr != nil {
+		c.JSON(getErr.Status(), getErr)
+		return
+	}
+
+	/* Authorize request for current user */
+	currentUser := ctr.base.CurrentUser(c)
+	authorizer := authorizer.NewUsersAuthorizer(currentUser, user.Id)
+	permissions, restErr := authorizer.Authorize("restore")
+	if restErr != nil {
+		c.JSON(restErr.Status(), restErr)
+		return
+	}
+
 	meta := map[string]interface{}{
-		"total": len(result),
+		"permissions": permissions,
 	}
 
-	collection := result.CollectionFor(currentUser.Role)
-	jsonapi := serializers.NewCollectionSerializer(collection, meta)
+	restoredUser, restoreErr := ctr.dao.RestoreUser(user)
+	if restoreErr != nil {
+		c.JSON(restoreErr.Status(), restoreErr)
+		return
+	}
+
+	resource := restoredUser.MemberFor(currentUser.Role)
+	jsonapi := serializers.NewMemberSerializer(resource, nil, nil, meta)
 	c.JSON(http.StatusOK, jsonapi)
 }
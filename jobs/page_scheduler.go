@@ -0,0 +1,46 @@
+package jobs
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"resturants-hub.com/m/v2/dao"
+)
+
+const defaultPageSchedulerInterval = time.Minute
+
+/* PageSchedulerInterval is how often scheduled pages are checked (PAGE_SCHEDULER_INTERVAL, e.g. "30s") */
+func PageSchedulerInterval() time.Duration {
+	interval, err := time.ParseDuration(os.Getenv("PAGE_SCHEDULER_INTERVAL"))
+	if err != nil || interval <= 0 {
+		return defaultPageSchedulerInterval
+	}
+	return interval
+}
+
+/* StartPageScheduler publishes and archives pages when their publication window opens or closes, every PageSchedulerInterval */
+func StartPageScheduler() {
+	go func() {
+		pagesDao := dao.NewPageDao()
+		ticker := time.NewTicker(PageSchedulerInterval())
+		defer ticker.Stop()
+		for {
+			PublishScheduledPages(pagesDao)
+			<-ticker.C
+		}
+	}()
+}
+
+func PublishScheduledPages(pagesDao dao.PagesDao) {
+	published, archived, err := pagesDao.PublishScheduled(time.Now().UTC())
+	for _, page := range published {
+		fmt.Printf("Published scheduled page %s (%d)\n", page.Slug, page.Id)
+	}
+	for _, page := range archived {
+		fmt.Printf("Archived page %s (%d) at the end of its publication\n", page.Slug, page.Id)
+	}
+	if err != nil {
+		fmt.Printf("Failed to publish scheduled pages: %v\n", err)
+	}
+}
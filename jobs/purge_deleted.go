@@ -0,0 +1,78 @@
+package jobs
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"resturants-hub.com/m/v2/dao"
+)
+
+const (
+	defaultRetentionDays = 30
+	defaultPurgeInterval = 24 * time.Hour
+)
+
+/*
+Soft deleted tables, in the order they are purged, with the tables (=> foreign key) whose rows are purged along with them,
+and the tables (=> foreign key) whose rows keep a record from being purged while they reference it.
+Such records (e.g. a user who authored live pages, a page with live sub pages) are skipped and retried on the next run,
+the rest of the table is purged
+*/
+var purgedTables = []struct {
+	name       string
+	dependents map[string]string
+	referrers  map[string]string
+}{
+	{name: "pages", referrers: map[string]string{"pages": "parent_page_id"}},
+	/* Reviews and tags of the dishes are deleted by cascade, order items keep their copy of the name and price */
+	{name: "dishes"},
+	{name: "restaurants", dependents: map[string]string{"dishes": "restaurant_id", "menu_categories": "restaurant_id", "pages": "restaurant_id", "orders": "restaurant_id", "reservations": "restaurant_id", "reviews": "restaurant_id", "tables": "restaurant_id", "tags": "restaurant_id"}},
+	{name: "users", dependents: map[string]string{"sessions": "user_id"}, referrers: map[string]string{"pages": "author_id", "restaurants": "manager_id"}},
+}
+
+/* RetentionPeriod is how long soft deleted records can be restored before being purged (SOFT_DELETE_RETENTION_DAYS) */
+func RetentionPeriod() time.Duration {
+	days, err := strconv.Atoi(os.Getenv("SOFT_DELETE_RETENTION_DAYS"))
+	if err != nil || days < 1 {
+		days = defaultRetentionDays
+	}
+	return time.Duration(days) * 24 * time.Hour
+}
+
+/* PurgeInterval is how often the purge runs (PURGE_INTERVAL, e.g. "12h") */
+func PurgeInterval() time.Duration {
+	interval, err := time.ParseDuration(os.Getenv("PURGE_INTERVAL"))
+	if err != nil || interval <= 0 {
+		return defaultPurgeInterval
+	}
+	return interval
+}
+
+/* StartPurgeDeleted purges records deleted longer than the retention period, now and then every PurgeInterval */
+func StartPurgeDeleted() {
+	go func() {
+		purgeDao := dao.NewPurgeDao()
+		ticker := time.NewTicker(PurgeInterval())
+		defer ticker.Stop()
+		for {
+			PurgeDeleted(purgeDao)
+			<-ticker.C
+		}
+	}()
+}
+
+func PurgeDeleted(purgeDao dao.PurgeDao) {
+	before := time.Now().Add(-RetentionPeriod())
+	for _, table := range purgedTables {
+		purged, err := purgeDao.PurgeDeleted(table.name, before, table.dependents, table.referrers)
+		if err != nil {
+			fmt.Printf("Failed to purge deleted %s: %v\n", table.name, err)
+			continue
+		}
+		if purged > 0 {
+			fmt.Printf("Purged %d %s deleted before %s\n", purged, table.name, before.Format(time.RFC3339))
+		}
+	}
+}
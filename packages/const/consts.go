@@ -11,15 +11,14 @@ const (
 type ResourceType string
 
 const (
-	Restaurants ResourceType = "restaurants"
-	Users                    = "users"
-	Invitations              = "invitations"
-	Pages                    = "pages"
-)
-
-type SsoProvider string
-
-const (
-	Google    SsoProvider = "google"
-	Authentik             = "authentik"
+	Restaurants    ResourceType = "restaurants"
+	Users                       = "users"
+	Invitations                 = "invitations"
+	Pages                       = "pages"
+	Dishes                      = "dishes"
+	Tables                      = "tables"
+	Reservations                = "reservations"
+	Orders                      = "orders"
+	Reviews                     = "reviews"
+	MenuCategories              = "menu_categories"
 )
@@ -0,0 +1,203 @@
+package pagination
+
+import (
+	"bytes"
+	"database/sql/driver"
+	"encoding/base64"
+	"encoding/json"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/jmoiron/sqlx/reflectx"
+	rest_errors "resturants-hub.com/m/v2/packages/utils"
+)
+
+/*
+Keyset pagination: instead of skipping OFFSET rows, page[after]/page[before] carry an opaque cursor
+holding the sort key values (plus the id tiebreaker) of the last/first record of the previous page,
+along with the sort it was issued for. Pages stay stable while rows are being inserted and don't get
+slower as the table grows. An empty cursor (page[after]=) starts at the beginning of the collection.
+*/
+const (
+	AfterParam  = "page[after]"
+	BeforeParam = "page[before]"
+)
+
+/* Records are read by their db column names, as the sort param references columns */
+var columnMapper = reflectx.NewMapperFunc("db", strings.ToLower)
+
+type cursorPayload struct {
+	Sort   string        `json:"sort"`
+	Values []interface{} `json:"values"`
+}
+
+/* SortKey identifies the sort a cursor is issued for, e.g. "-created_at,id" */
+func SortKey(fields []SortField) string {
+	keys := make([]string, len(fields))
+	for i, field := range fields {
+		keys[i] = field.Attr
+		if field.Desc {
+			keys[i] = "-" + field.Attr
+		}
+	}
+	return strings.Join(keys, ",")
+}
+
+func (p *Pagination) value(key string) (string, bool) {
+	value, exists := p.Params[key]
+	if !exists {
+		return "", false
+	}
+	values, ok := value.([]string)
+	if !ok || len(values) == 0 {
+		return "", true
+	}
+	return values[0], true
+}
+
+/* IsKeyset tells if the request opted into cursor pagination with page[after] or page[before] */
+func (p *Pagination) IsKeyset() bool {
+	_, after := p.Params[AfterParam]
+	_, before := p.Params[BeforeParam]
+	return after || before
+}
+
+/* IsBackward is true when paging towards the beginning of the collection (page[before]) */
+func (p *Pagination) IsBackward() bool {
+	_, after := p.Params[AfterParam]
+	_, before := p.Params[BeforeParam]
+	return before && !after
+}
+
+/*
+Cursor returns the decoded sort values of the requested cursor, one per sort field.
+It returns nil for an empty cursor, and a bad request error if the cursor was tampered with
+or was issued for a different sort.
+*/
+func (p *Pagination) Cursor(fields []SortField) ([]interface{}, rest_errors.RestErr) {
+	param := AfterParam
+	if p.IsBackward() {
+		param = BeforeParam
+	}
+	cursor, _ := p.value(param)
+	if cursor == "" {
+		return nil, nil
+	}
+
+	sort, values, err := DecodeCursor(cursor)
+	if err != nil || len(values) != len(fields) {
+		return nil, rest_errors.NewBadRequestError("Invalid pagination cursor")
+	}
+	/* The values of the cursor would be compared with other columns */
+	if sort != SortKey(fields) {
+		return nil, rest_errors.NewBadRequestError("The pagination cursor was issued for another sort, start over without it")
+	}
+	return values, nil
+}
+
+/* EncodeCursor serializes the sort values of fields into an opaque, url safe cursor */
+func EncodeCursor(fields []SortField, values []interface{}) string {
+	data, _ := json.Marshal(cursorPayload{Sort: SortKey(fields), Values: values})
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+/* DecodeCursor returns the sort key the cursor was issued for along with its sort values, NULLs decode to nil */
+func DecodeCursor(cursor string) (string, []interface{}, error) {
+	data, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return "", nil, err
+	}
+
+	/* Keep numbers as json.Number so that ids aren't rounded through float64 */
+	var payload cursorPayload
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	if err := decoder.Decode(&payload); err != nil {
+		return "", nil, err
+	}
+	for i, value := range payload.Values {
+		if number, ok := value.(json.Number); ok {
+			payload.Values[i] = number.String()
+		}
+	}
+	return payload.Sort, payload.Values, nil
+}
+
+/* CursorFor builds the cursor pointing at record, for the given sort fields */
+func CursorFor(record interface{}, fields []SortField) string {
+	v := reflect.Indirect(reflect.ValueOf(record))
+	values := make([]interface{}, len(fields))
+	for i, field := range fields {
+		column := columnMapper.FieldByName(v, field.Attr)
+		if !column.IsValid() {
+			continue
+		}
+		values[i] = column.Interface()
+		if valuer, ok := values[i].(driver.Valuer); ok {
+			values[i], _ = valuer.Value()
+		}
+	}
+	return EncodeCursor(fields, values)
+}
+
+/*
+KeysetMeta returns the meta and links of a page fetched with a cursor. records must be the slice
+returned by the query: the next cursor points at its last record and the prev cursor at its first.
+next (prev when paging backward) is null once the end of the collection is reached.
+*/
+func (p *Pagination) KeysetMeta(requestUrl *url.URL, total uint64, records interface{}, fields []SortField) (map[string]interface{}, map[string]interface{}) {
+	var next, prev interface{}
+	rows := reflect.Indirect(reflect.ValueOf(records))
+	count := 0
+	if rows.Kind() == reflect.Slice {
+		count = rows.Len()
+	}
+
+	if count > 0 {
+		first := CursorFor(rows.Index(0).Interface(), fields)
+		last := CursorFor(rows.Index(count-1).Interface(), fields)
+		if p.IsBackward() {
+			next = last
+			if uint(count) == p.Size() {
+				prev = first
+			}
+		} else {
+			if uint(count) == p.Size() {
+				next = last
+			}
+			if current, _ := p.value(AfterParam); current != "" {
+				prev = first
+			}
+		}
+	}
+
+	cursorLink := func(param string, cursor interface{}) interface{} {
+		if cursor == nil {
+			return nil
+		}
+		query := requestUrl.Query()
+		query.Del(AfterParam)
+		query.Del(BeforeParam)
+		query.Set(param, cursor.(string))
+		query.Set("size", strconv.FormatUint(uint64(p.Size()), 10))
+		return requestUrl.Path + "?" + query.Encode()
+	}
+
+	meta := map[string]interface{}{
+		"total": total,
+		"size":  p.Size(),
+		"cursors": map[string]interface{}{
+			"next": next,
+			"prev": prev,
+		},
+	}
+	links := map[string]interface{}{
+		"self":  requestUrl.RequestURI(),
+		"first": cursorLink(AfterParam, ""),
+		"prev":  cursorLink(BeforeParam, prev),
+		"next":  cursorLink(AfterParam, next),
+	}
+	return meta, links
+}
@@ -0,0 +1,110 @@
+package pagination
+
+import (
+	"database/sql"
+	"net/http"
+	"net/url"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestCursorRoundTrip(t *testing.T) {
+	fields := []SortField{{Attr: "created_at", Desc: true}, {Attr: "id"}}
+	tests := []struct {
+		name   string
+		values []interface{}
+		want   []interface{}
+	}{
+		{name: "strings and ids", values: []interface{}{"2024-06-01T10:00:00Z", int64(42)}, want: []interface{}{"2024-06-01T10:00:00Z", "42"}},
+		{name: "large ids aren't rounded", values: []interface{}{"a", int64(9007199254740993)}, want: []interface{}{"a", "9007199254740993"}},
+		{name: "nulls", values: []interface{}{nil, int64(7)}, want: []interface{}{nil, "7"}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			sort, values, err := DecodeCursor(EncodeCursor(fields, test.values))
+			if err != nil {
+				t.Fatalf("DecodeCursor() error = %v", err)
+			}
+			if sort != "-created_at,id" {
+				t.Errorf("sort = %q, want %q", sort, "-created_at,id")
+			}
+			if !reflect.DeepEqual(values, test.want) {
+				t.Errorf("values = %#v, want %#v", values, test.want)
+			}
+		})
+	}
+}
+
+func TestPaginationCursor(t *testing.T) {
+	nameSort := []SortField{{Attr: "name"}, {Attr: "id"}}
+	issued := EncodeCursor(nameSort, []interface{}{"pasta", 3})
+
+	tests := []struct {
+		name       string
+		query      string
+		fields     []SortField
+		want       []interface{}
+		wantStatus int
+	}{
+		{name: "empty cursor starts over", query: "page[after]=", fields: nameSort, want: nil},
+		{name: "after", query: "page[after]=" + issued, fields: nameSort, want: []interface{}{"pasta", "3"}},
+		{name: "before", query: "page[before]=" + issued, fields: nameSort, want: []interface{}{"pasta", "3"}},
+		{name: "issued for another sort", query: "page[after]=" + issued, fields: []SortField{{Attr: "price"}, {Attr: "id"}}, wantStatus: http.StatusBadRequest},
+		{name: "issued for another direction", query: "page[after]=" + issued, fields: []SortField{{Attr: "name", Desc: true}, {Attr: "id"}}, wantStatus: http.StatusBadRequest},
+		{name: "tampered", query: "page[after]=not-a-cursor", fields: nameSort, wantStatus: http.StatusBadRequest},
+		{name: "missing values", query: "page[after]=" + EncodeCursor(nameSort, []interface{}{"pasta"}), fields: nameSort, wantStatus: http.StatusBadRequest},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			params, _ := url.ParseQuery(test.query)
+			values, err := NewPagination(params).Cursor(test.fields)
+			if test.wantStatus != 0 {
+				if err == nil || err.Status() != test.wantStatus {
+					t.Fatalf("Cursor() error = %v, want status %d", err, test.wantStatus)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Cursor() error = %v", err)
+			}
+			if !reflect.DeepEqual(values, test.want) {
+				t.Errorf("Cursor() = %#v, want %#v", values, test.want)
+			}
+		})
+	}
+}
+
+func TestCursorFor(t *testing.T) {
+	type record struct {
+		Id        int64        `db:"id"`
+		Name      string       `db:"name"`
+		DeletedAt sql.NullTime `db:"deleted_at"`
+	}
+	deletedAt := time.Date(2024, 6, 1, 10, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name   string
+		record record
+		fields []SortField
+		want   []interface{}
+	}{
+		{name: "columns", record: record{Id: 3, Name: "pasta"}, fields: []SortField{{Attr: "name"}, {Attr: "id"}}, want: []interface{}{"pasta", "3"}},
+		{name: "null values", record: record{Id: 3}, fields: []SortField{{Attr: "deleted_at"}, {Attr: "id"}}, want: []interface{}{nil, "3"}},
+		{name: "valid values", record: record{Id: 3, DeletedAt: sql.NullTime{Time: deletedAt, Valid: true}}, fields: []SortField{{Attr: "deleted_at"}, {Attr: "id"}}, want: []interface{}{"2024-06-01T10:00:00Z", "3"}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			sort, values, err := DecodeCursor(CursorFor(&test.record, test.fields))
+			if err != nil {
+				t.Fatalf("DecodeCursor() error = %v", err)
+			}
+			if sort != SortKey(test.fields) {
+				t.Errorf("sort = %q, want %q", sort, SortKey(test.fields))
+			}
+			if !reflect.DeepEqual(values, test.want) {
+				t.Errorf("values = %#v, want %#v", values, test.want)
+			}
+		})
+	}
+}
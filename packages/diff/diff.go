@@ -0,0 +1,142 @@
+package diff
+
+import "strings"
+
+type Operation string
+
+const (
+	Equal  Operation = "equal"
+	Insert Operation = "insert"
+	Delete Operation = "delete"
+)
+
+/*
+maxEdits bounds the work spent on a diff: texts needing more line insertions and deletions than that
+are shown as the removal of the differing lines followed by the addition of the new ones.
+The shortest edit is searched in O((N+M)·maxEdits) time and O(maxEdits²) memory at most.
+*/
+const maxEdits = 1000
+
+/* Line of a diff: kept (equal), only in the new text (insert) or only in the old text (delete) */
+type Line struct {
+	Operation Operation `json:"op"`
+	Text      string    `json:"text"`
+}
+
+/* Lines compares two texts line by line, keeping as many lines as possible (Myers' O(ND) algorithm) */
+func Lines(from string, to string) []Line {
+	a := strings.Split(from, "\n")
+	b := strings.Split(to, "\n")
+
+	/* Lines common to the start and the end of both texts are kept without searching */
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+
+	lines := make([]Line, 0, len(a)+len(b)-prefix-suffix)
+	for _, text := range a[:prefix] {
+		lines = append(lines, Line{Equal, text})
+	}
+	changed := shortestEdit(a[prefix:len(a)-suffix], b[prefix:len(b)-suffix])
+	if changed == nil {
+		changed = replace(a[prefix:len(a)-suffix], b[prefix:len(b)-suffix])
+	}
+	lines = append(lines, changed...)
+	for _, text := range a[len(a)-suffix:] {
+		lines = append(lines, Line{Equal, text})
+	}
+	return lines
+}
+
+/*
+shortestEdit walks the edit graph of a and b breadth first on the number of edits d: v[k] is the furthest x
+reached on diagonal k (x - y) with d edits. The v of every step is kept to trace the path back once b is reached.
+It returns nil when more than maxEdits edits are needed.
+*/
+func shortestEdit(a []string, b []string) []Line {
+	n, m := len(a), len(b)
+	limit := min(n+m, maxEdits)
+	offset := limit + 1
+	v := make([]int, 2*limit+3)
+	trace := [][]int{}
+
+	for d := 0; d <= limit; d++ {
+		/* Step d only reads the diagonals -d-1 to d+1 of the previous step */
+		trace = append(trace, append([]int(nil), v[offset-d-1:offset+d+2]...))
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && v[offset+k-1] < v[offset+k+1]) {
+				x = v[offset+k+1]
+			} else {
+				x = v[offset+k-1] + 1
+			}
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+			v[offset+k] = x
+			if x >= n && y >= m {
+				return backtrack(a, b, trace)
+			}
+		}
+	}
+	return nil
+}
+
+/* backtrack follows the edits found by shortestEdit from the end of both texts back to their start */
+func backtrack(a []string, b []string, trace [][]int) []Line {
+	lines := []Line{}
+	x, y := len(a), len(b)
+	for d := len(trace) - 1; d >= 0; d-- {
+		previous := trace[d]
+		furthest := func(k int) int { return previous[k+d+1] }
+
+		k := x - y
+		var previousK int
+		if k == -d || (k != d && furthest(k-1) < furthest(k+1)) {
+			previousK = k + 1
+		} else {
+			previousK = k - 1
+		}
+		previousX := furthest(previousK)
+		previousY := previousX - previousK
+
+		for x > previousX && y > previousY {
+			x--
+			y--
+			lines = append(lines, Line{Equal, a[x]})
+		}
+		if d > 0 {
+			if x == previousX {
+				y--
+				lines = append(lines, Line{Insert, b[y]})
+			} else {
+				x--
+				lines = append(lines, Line{Delete, a[x]})
+			}
+		}
+	}
+
+	for i, j := 0, len(lines)-1; i < j; i, j = i+1, j-1 {
+		lines[i], lines[j] = lines[j], lines[i]
+	}
+	return lines
+}
+
+/* replace deletes all the lines of a, then inserts all the lines of b */
+func replace(a []string, b []string) []Line {
+	lines := make([]Line, 0, len(a)+len(b))
+	for _, text := range a {
+		lines = append(lines, Line{Delete, text})
+	}
+	for _, text := range b {
+		lines = append(lines, Line{Insert, text})
+	}
+	return lines
+}
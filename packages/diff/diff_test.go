@@ -0,0 +1,118 @@
+package diff
+
+import (
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestLines(t *testing.T) {
+	tests := []struct {
+		name string
+		from string
+		to   string
+		want []Line
+	}{
+		{
+			name: "same text",
+			from: "a\nb",
+			to:   "a\nb",
+			want: []Line{{Equal, "a"}, {Equal, "b"}},
+		},
+		{
+			name: "from empty",
+			from: "",
+			to:   "a",
+			want: []Line{{Delete, ""}, {Insert, "a"}},
+		},
+		{
+			name: "inserted line",
+			from: "a\nc",
+			to:   "a\nb\nc",
+			want: []Line{{Equal, "a"}, {Insert, "b"}, {Equal, "c"}},
+		},
+		{
+			name: "deleted line",
+			from: "a\nb\nc",
+			to:   "a\nc",
+			want: []Line{{Equal, "a"}, {Delete, "b"}, {Equal, "c"}},
+		},
+		{
+			name: "changed line",
+			from: "a\nb\nc",
+			to:   "a\nB\nc",
+			want: []Line{{Equal, "a"}, {Delete, "b"}, {Insert, "B"}, {Equal, "c"}},
+		},
+		{
+			name: "moved line",
+			from: "a\nb\nc\nd",
+			to:   "b\nc\na\nd",
+			want: []Line{{Delete, "a"}, {Equal, "b"}, {Equal, "c"}, {Insert, "a"}, {Equal, "d"}},
+		},
+		{
+			name: "changes apart",
+			from: "a\nb\nc\nd\ne",
+			to:   "x\nb\nc\nd\ny",
+			want: []Line{{Delete, "a"}, {Insert, "x"}, {Equal, "b"}, {Equal, "c"}, {Equal, "d"}, {Delete, "e"}, {Insert, "y"}},
+		},
+		{
+			name: "nothing in common",
+			from: "a\nb",
+			to:   "c",
+			want: []Line{{Delete, "a"}, {Delete, "b"}, {Insert, "c"}},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := Lines(test.from, test.to)
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("Lines() = %v, want %v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestLinesOfLargeTexts(t *testing.T) {
+	numbered := func(count int, prefix string) string {
+		lines := make([]string, count)
+		for i := range lines {
+			lines[i] = prefix + strconv.Itoa(i)
+		}
+		return strings.Join(lines, "\n")
+	}
+	tests := []struct {
+		name      string
+		from      string
+		to        string
+		wantEqual int
+	}{
+		{name: "few changes", from: numbered(50000, "line "), to: strings.Replace(numbered(50000, "line "), "line 25000\n", "changed\n", 1), wantEqual: 49999},
+		{name: "too many changes", from: numbered(50000, "old "), to: numbered(50000, "new "), wantEqual: 0},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			lines := Lines(test.from, test.to)
+
+			/* The diff must still rebuild both texts */
+			from, to, equal := []string{}, []string{}, 0
+			for _, line := range lines {
+				if line.Operation != Insert {
+					from = append(from, line.Text)
+				}
+				if line.Operation != Delete {
+					to = append(to, line.Text)
+				}
+				if line.Operation == Equal {
+					equal++
+				}
+			}
+			if strings.Join(from, "\n") != test.from || strings.Join(to, "\n") != test.to {
+				t.Fatal("Lines() doesn't rebuild the compared texts")
+			}
+			if equal != test.wantEqual {
+				t.Errorf("Lines() kept %d lines, want %d", equal, test.wantEqual)
+			}
+		})
+	}
+}
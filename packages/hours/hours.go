@@ -0,0 +1,267 @@
+package hours
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	/* Timezones have to resolve on hosts without a zoneinfo database too */
+	_ "time/tzdata"
+
+	rest_errors "resturants-hub.com/m/v2/packages/utils"
+)
+
+const (
+	dateLayout = "2006-01-02"
+	/* Exceptions can close a restaurant for a long time, the next opening isn't searched further than that */
+	lookahead = 366
+)
+
+var Weekdays = []string{"sunday", "monday", "tuesday", "wednesday", "thursday", "friday", "saturday"}
+
+/* Interval the restaurant is open, in local "HH:MM" times. An interval closing before it opens spans midnight */
+type Interval struct {
+	Opens  string `json:"opens"`
+	Closes string `json:"closes"`
+}
+
+/* Exception replaces the weekly schedule on a date (YYYY-MM-DD): the restaurant is either closed or open on its own intervals */
+type Exception struct {
+	Date      string     `json:"date"`
+	Closed    bool       `json:"closed"`
+	Intervals []Interval `json:"intervals"`
+	Reason    string     `json:"reason"`
+}
+
+/* Schedule is the weekly schedule of a restaurant, by lowercase weekday, along with its dated exceptions */
+type Schedule struct {
+	Weekly     map[string][]Interval `json:"weekly"`
+	Exceptions []Exception           `json:"exceptions"`
+}
+
+func (schedule *Schedule) Scan(src interface{}) error {
+	switch source := src.(type) {
+	case string:
+		return json.Unmarshal([]byte(source), schedule)
+	case []byte:
+		return json.Unmarshal(source, schedule)
+	case nil:
+		*schedule = Schedule{}
+		return nil
+	default:
+		return fmt.Errorf("cannot convert %T to Schedule", src)
+	}
+}
+
+func (schedule Schedule) Value() (driver.Value, error) {
+	if schedule.Weekly == nil {
+		schedule.Weekly = map[string][]Interval{}
+	}
+	if schedule.Exceptions == nil {
+		schedule.Exceptions = []Exception{}
+	}
+	return json.Marshal(schedule)
+}
+
+/* minutes parses a "HH:MM" time, "24:00" is only allowed as a closing time */
+func minutes(value string, closing bool) (int, bool) {
+	var hour, minute int
+	if len(value) != 5 || value[2] != ':' {
+		return 0, false
+	}
+	if _, err := fmt.Sscanf(value, "%02d:%02d", &hour, &minute); err != nil {
+		return 0, false
+	}
+	if closing && hour == 24 && minute == 0 {
+		return 24 * 60, true
+	}
+	if hour < 0 || hour > 23 || minute < 0 || minute > 59 {
+		return 0, false
+	}
+	return hour*60 + minute, true
+}
+
+/* bounds returns the opening and closing minutes of the interval, the closing one is past 24:00 for overnight intervals */
+func (interval Interval) bounds() (int, int) {
+	opens, _ := minutes(interval.Opens, false)
+	closes, _ := minutes(interval.Closes, true)
+	if closes <= opens {
+		closes += 24 * 60
+	}
+	return opens, closes
+}
+
+func validateIntervals(intervals []Interval) []interface{} {
+	causes := []interface{}{}
+	for index, interval := range intervals {
+		opens, validOpens := minutes(interval.Opens, false)
+		closes, validCloses := minutes(interval.Closes, true)
+		if !validOpens || !validCloses {
+			causes = append(causes, map[string]interface{}{"error": "invalid_time", "index": index, "expected": "HH:MM"})
+		} else if opens == closes {
+			causes = append(causes, map[string]interface{}{"error": "empty_interval", "index": index})
+		}
+	}
+	if len(causes) > 0 {
+		return causes
+	}
+
+	sorted := append([]Interval{}, intervals...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Opens < sorted[j].Opens })
+	for index := 1; index < len(sorted); index++ {
+		_, previousCloses := sorted[index-1].bounds()
+		if opens, _ := sorted[index].bounds(); opens < previousCloses {
+			causes = append(causes, map[string]interface{}{"error": "overlapping_intervals", "opens": sorted[index].Opens})
+		}
+	}
+	return causes
+}
+
+/* Validate checks the timezone and the schedule, causes are keyed by attribute (weekly.monday, exceptions) */
+func Validate(timezone string, schedule Schedule) rest_errors.ValidationErrs {
+	causes := rest_errors.ValidationErrs{}
+	if _, err := time.LoadLocation(timezone); timezone == "" || err != nil {
+		causes["timezone"] = append(causes["timezone"], map[string]interface{}{"error": "invalid_timezone"})
+	}
+
+	for day, intervals := range schedule.Weekly {
+		key := "weekly." + day
+		if !isWeekday(day) {
+			causes[key] = append(causes[key], map[string]interface{}{"error": "invalid_weekday", "expected": Weekdays})
+			continue
+		}
+		if dayCauses := validateIntervals(intervals); len(dayCauses) > 0 {
+			causes[key] = append(causes[key], dayCauses...)
+		}
+	}
+
+	dates := map[string]bool{}
+	for index, exception := range schedule.Exceptions {
+		key := fmt.Sprintf("exceptions.%d", index)
+		if _, err := time.Parse(dateLayout, exception.Date); err != nil {
+			causes[key] = append(causes[key], map[string]interface{}{"error": "invalid_date", "expected": "YYYY-MM-DD"})
+		} else if dates[exception.Date] {
+			causes[key] = append(causes[key], map[string]interface{}{"error": "duplicate_date", "date": exception.Date})
+		}
+		dates[exception.Date] = true
+
+		switch {
+		case exception.Closed && len(exception.Intervals) > 0:
+			causes[key] = append(causes[key], map[string]interface{}{"error": "closed_with_intervals"})
+		case !exception.Closed && len(exception.Intervals) == 0:
+			causes[key] = append(causes[key], map[string]interface{}{"error": "closed_or_intervals_required"})
+		default:
+			if intervalCauses := validateIntervals(exception.Intervals); len(intervalCauses) > 0 {
+				causes[key] = append(causes[key], intervalCauses...)
+			}
+		}
+	}
+	return causes
+}
+
+func isWeekday(day string) bool {
+	for _, weekday := range Weekdays {
+		if day == weekday {
+			return true
+		}
+	}
+	return false
+}
+
+/* Location resolves the timezone of a schedule, unknown timezones fall back to UTC */
+func Location(timezone string) *time.Location {
+	location, err := time.LoadLocation(timezone)
+	if err != nil {
+		return time.UTC
+	}
+	return location
+}
+
+/* At returns the time of a local date (YYYY-MM-DD) and clock ("HH:MM", "24:00" being the end of the day) in the timezone */
+func At(timezone string, date string, clock string) (time.Time, bool) {
+	location := Location(timezone)
+	day, err := time.ParseInLocation(dateLayout, date, location)
+	if err != nil {
+		return time.Time{}, false
+	}
+	minute, valid := minutes(clock, true)
+	if !valid {
+		return time.Time{}, false
+	}
+	return time.Date(day.Year(), day.Month(), day.Day(), minute/60, minute%60, 0, 0, location), true
+}
+
+/* intervalsOn returns the intervals of a local date: those of its exception if there is one, otherwise those of its weekday */
+func (schedule Schedule) intervalsOn(date time.Time) []Interval {
+	day := date.Format(dateLayout)
+	for _, exception := range schedule.Exceptions {
+		if exception.Date == day {
+			if exception.Closed {
+				return nil
+			}
+			return exception.Intervals
+		}
+	}
+	return schedule.Weekly[strings.ToLower(date.Weekday().String())]
+}
+
+/* span is the time range of an interval on a date, overnight intervals end on the next day */
+func span(date time.Time, interval Interval) (time.Time, time.Time) {
+	opens, closes := interval.bounds()
+	/* time.Date normalizes hours past 24 into the next day */
+	start := time.Date(date.Year(), date.Month(), date.Day(), opens/60, opens%60, 0, 0, date.Location())
+	end := time.Date(date.Year(), date.Month(), date.Day(), closes/60, closes%60, 0, 0, date.Location())
+	return start, end
+}
+
+/*
+Status tells whether the restaurant is open at now in its timezone, and when it opens next (nil when it has no upcoming opening).
+Intervals of the previous day are taken into account, as they may run past midnight
+*/
+func (schedule Schedule) Status(timezone string, now time.Time) (bool, *time.Time) {
+	location := Location(timezone)
+	local := now.In(location)
+	today := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, location)
+
+	openNow := false
+	var nextOpening *time.Time
+	for offset := -1; offset <= lookahead; offset++ {
+		date := today.AddDate(0, 0, offset)
+		for _, interval := range schedule.intervalsOn(date) {
+			start, end := span(date, interval)
+			if !start.After(now) && now.Before(end) {
+				openNow = true
+			}
+			if start.After(now) && (nextOpening == nil || start.Before(*nextOpening)) {
+				opening := start
+				nextOpening = &opening
+			}
+		}
+		/* Intervals of later dates can't open earlier */
+		if nextOpening != nil && offset >= 0 {
+			break
+		}
+	}
+	return openNow, nextOpening
+}
+
+/* Covers tells whether the restaurant is open during the whole [start, end) range, within a single interval */
+func (schedule Schedule) Covers(timezone string, start time.Time, end time.Time) bool {
+	location := Location(timezone)
+	local := start.In(location)
+	day := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, location)
+
+	for offset := -1; offset <= 0; offset++ {
+		date := day.AddDate(0, 0, offset)
+		for _, interval := range schedule.intervalsOn(date) {
+			opens, closes := span(date, interval)
+			if !start.Before(opens) && !end.After(closes) {
+				return true
+			}
+		}
+	}
+	return false
+}
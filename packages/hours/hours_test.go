@@ -0,0 +1,162 @@
+package hours
+
+import (
+	"testing"
+	"time"
+)
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name      string
+		timezone  string
+		schedule  Schedule
+		wantKey   string
+		wantError string
+	}{
+		{
+			name:     "valid",
+			timezone: "Europe/Berlin",
+			schedule: Schedule{
+				Weekly:     map[string][]Interval{"monday": {{"10:00", "14:00"}, {"18:00", "23:00"}}, "friday": {{"18:00", "02:00"}}, "sunday": {{"00:00", "24:00"}}},
+				Exceptions: []Exception{{Date: "2024-12-25", Closed: true}, {Date: "2024-12-31", Intervals: []Interval{{"20:00", "03:00"}}}},
+			},
+		},
+		{name: "missing timezone", timezone: "", wantKey: "timezone", wantError: "invalid_timezone"},
+		{name: "unknown timezone", timezone: "Europe/Atlantis", wantKey: "timezone", wantError: "invalid_timezone"},
+		{name: "unknown weekday", timezone: "UTC", schedule: Schedule{Weekly: map[string][]Interval{"Monday": {{"10:00", "14:00"}}}}, wantKey: "weekly.Monday", wantError: "invalid_weekday"},
+		{name: "hour out of range", timezone: "UTC", schedule: Schedule{Weekly: map[string][]Interval{"monday": {{"10:00", "25:00"}}}}, wantKey: "weekly.monday", wantError: "invalid_time"},
+		{name: "single digit hour", timezone: "UTC", schedule: Schedule{Weekly: map[string][]Interval{"monday": {{"9:00", "14:00"}}}}, wantKey: "weekly.monday", wantError: "invalid_time"},
+		{name: "opening at 24:00", timezone: "UTC", schedule: Schedule{Weekly: map[string][]Interval{"monday": {{"24:00", "02:00"}}}}, wantKey: "weekly.monday", wantError: "invalid_time"},
+		{name: "empty interval", timezone: "UTC", schedule: Schedule{Weekly: map[string][]Interval{"monday": {{"10:00", "10:00"}}}}, wantKey: "weekly.monday", wantError: "empty_interval"},
+		{name: "overlapping intervals", timezone: "UTC", schedule: Schedule{Weekly: map[string][]Interval{"monday": {{"18:00", "23:00"}, {"10:00", "19:00"}}}}, wantKey: "weekly.monday", wantError: "overlapping_intervals"},
+		{name: "overlapping overnight interval", timezone: "UTC", schedule: Schedule{Weekly: map[string][]Interval{"monday": {{"22:00", "02:00"}, {"23:00", "23:30"}}}}, wantKey: "weekly.monday", wantError: "overlapping_intervals"},
+		{name: "invalid date", timezone: "UTC", schedule: Schedule{Exceptions: []Exception{{Date: "2024-02-30", Closed: true}}}, wantKey: "exceptions.0", wantError: "invalid_date"},
+		{name: "duplicate date", timezone: "UTC", schedule: Schedule{Exceptions: []Exception{{Date: "2024-12-25", Closed: true}, {Date: "2024-12-25", Closed: true}}}, wantKey: "exceptions.1", wantError: "duplicate_date"},
+		{name: "closed with intervals", timezone: "UTC", schedule: Schedule{Exceptions: []Exception{{Date: "2024-12-25", Closed: true, Intervals: []Interval{{"10:00", "12:00"}}}}}, wantKey: "exceptions.0", wantError: "closed_with_intervals"},
+		{name: "neither closed nor intervals", timezone: "UTC", schedule: Schedule{Exceptions: []Exception{{Date: "2024-12-25"}}}, wantKey: "exceptions.0", wantError: "closed_or_intervals_required"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			causes := Validate(test.timezone, test.schedule)
+			if test.wantKey == "" {
+				if len(causes) > 0 {
+					t.Fatalf("Validate() = %v, want no causes", causes)
+				}
+				return
+			}
+			if len(causes) != 1 || len(causes[test.wantKey]) != 1 {
+				t.Fatalf("Validate() = %v, want one cause for %s", causes, test.wantKey)
+			}
+			if cause := causes[test.wantKey][0].(map[string]interface{}); cause["error"] != test.wantError {
+				t.Errorf("cause = %v, want error %s", cause, test.wantError)
+			}
+		})
+	}
+}
+
+func TestStatus(t *testing.T) {
+	berlin := Location("Europe/Berlin")
+	newYork := Location("America/New_York")
+	at := func(location *time.Location, year int, month time.Month, day int, hour int, minute int) time.Time {
+		return time.Date(year, month, day, hour, minute, 0, 0, location)
+	}
+	/* June 3rd 2024 is a monday */
+	schedule := Schedule{
+		Weekly: map[string][]Interval{
+			"monday": {{"10:00", "14:00"}, {"18:00", "22:00"}},
+			"friday": {{"18:00", "02:00"}},
+		},
+		Exceptions: []Exception{
+			{Date: "2024-06-10", Closed: true},
+			{Date: "2024-06-12", Intervals: []Interval{{"12:00", "13:00"}}},
+		},
+	}
+
+	tests := []struct {
+		name         string
+		schedule     Schedule
+		timezone     string
+		now          time.Time
+		wantOpen     bool
+		wantNextOpen time.Time
+	}{
+		{name: "open", schedule: schedule, timezone: "Europe/Berlin", now: at(berlin, 2024, time.June, 3, 12, 0), wantOpen: true, wantNextOpen: at(berlin, 2024, time.June, 3, 18, 0)},
+		{name: "at the opening", schedule: schedule, timezone: "Europe/Berlin", now: at(berlin, 2024, time.June, 3, 10, 0), wantOpen: true, wantNextOpen: at(berlin, 2024, time.June, 3, 18, 0)},
+		{name: "at the closing", schedule: schedule, timezone: "Europe/Berlin", now: at(berlin, 2024, time.June, 3, 14, 0), wantOpen: false, wantNextOpen: at(berlin, 2024, time.June, 3, 18, 0)},
+		{name: "closed until a later day", schedule: schedule, timezone: "Europe/Berlin", now: at(berlin, 2024, time.June, 3, 23, 0), wantOpen: false, wantNextOpen: at(berlin, 2024, time.June, 7, 18, 0)},
+		{name: "overnight after midnight", schedule: schedule, timezone: "Europe/Berlin", now: at(berlin, 2024, time.June, 8, 1, 30), wantOpen: true, wantNextOpen: at(berlin, 2024, time.June, 12, 12, 0)},
+		{name: "overnight closed", schedule: schedule, timezone: "Europe/Berlin", now: at(berlin, 2024, time.June, 8, 2, 0), wantOpen: false, wantNextOpen: at(berlin, 2024, time.June, 12, 12, 0)},
+		{name: "now in another timezone", schedule: schedule, timezone: "Europe/Berlin", now: time.Date(2024, time.June, 3, 9, 30, 0, 0, time.UTC), wantOpen: true, wantNextOpen: at(berlin, 2024, time.June, 3, 18, 0)},
+		{name: "local day behind UTC", schedule: schedule, timezone: "America/New_York", now: time.Date(2024, time.June, 4, 1, 0, 0, 0, time.UTC), wantOpen: true, wantNextOpen: at(newYork, 2024, time.June, 7, 18, 0)},
+		{name: "unknown timezone is UTC", schedule: schedule, timezone: "Europe/Atlantis", now: time.Date(2024, time.June, 3, 11, 0, 0, 0, time.UTC), wantOpen: true, wantNextOpen: time.Date(2024, time.June, 3, 18, 0, 0, 0, time.UTC)},
+		{name: "never open", schedule: Schedule{}, timezone: "Europe/Berlin", now: at(berlin, 2024, time.June, 3, 12, 0), wantOpen: false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			open, nextOpening := test.schedule.Status(test.timezone, test.now)
+			if open != test.wantOpen {
+				t.Errorf("Status() open = %v, want %v", open, test.wantOpen)
+			}
+			switch {
+			case test.wantNextOpen.IsZero() && nextOpening != nil:
+				t.Errorf("Status() next opening = %v, want none", nextOpening)
+			case !test.wantNextOpen.IsZero() && (nextOpening == nil || !nextOpening.Equal(test.wantNextOpen)):
+				t.Errorf("Status() next opening = %v, want %v", nextOpening, test.wantNextOpen)
+			}
+		})
+	}
+}
+
+func TestCovers(t *testing.T) {
+	berlin := Location("Europe/Berlin")
+	schedule := Schedule{Weekly: map[string][]Interval{
+		"monday": {{"10:00", "14:00"}, {"14:00", "22:00"}},
+		"friday": {{"18:00", "02:00"}},
+	}}
+	tests := []struct {
+		name  string
+		start time.Time
+		end   time.Time
+		want  bool
+	}{
+		{name: "within an interval", start: time.Date(2024, time.June, 3, 11, 0, 0, 0, berlin), end: time.Date(2024, time.June, 3, 13, 0, 0, 0, berlin), want: true},
+		{name: "up to the closing", start: time.Date(2024, time.June, 3, 20, 0, 0, 0, berlin), end: time.Date(2024, time.June, 3, 22, 0, 0, 0, berlin), want: true},
+		{name: "across two intervals", start: time.Date(2024, time.June, 3, 13, 0, 0, 0, berlin), end: time.Date(2024, time.June, 3, 15, 0, 0, 0, berlin), want: false},
+		{name: "before the opening", start: time.Date(2024, time.June, 7, 17, 0, 0, 0, berlin), end: time.Date(2024, time.June, 7, 19, 0, 0, 0, berlin), want: false},
+		{name: "across midnight", start: time.Date(2024, time.June, 7, 23, 0, 0, 0, berlin), end: time.Date(2024, time.June, 8, 1, 0, 0, 0, berlin), want: true},
+		{name: "after midnight", start: time.Date(2024, time.June, 8, 0, 30, 0, 0, berlin), end: time.Date(2024, time.June, 8, 1, 30, 0, 0, berlin), want: true},
+		{name: "past the overnight closing", start: time.Date(2024, time.June, 8, 1, 30, 0, 0, berlin), end: time.Date(2024, time.June, 8, 2, 30, 0, 0, berlin), want: false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := schedule.Covers("Europe/Berlin", test.start, test.end); got != test.want {
+				t.Errorf("Covers() = %v, want %v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestAt(t *testing.T) {
+	berlin := Location("Europe/Berlin")
+	tests := []struct {
+		name  string
+		date  string
+		clock string
+		want  time.Time
+		valid bool
+	}{
+		{name: "summer time", date: "2024-06-03", clock: "18:30", want: time.Date(2024, time.June, 3, 16, 30, 0, 0, time.UTC), valid: true},
+		{name: "winter time", date: "2024-12-03", clock: "18:30", want: time.Date(2024, time.December, 3, 17, 30, 0, 0, time.UTC), valid: true},
+		{name: "end of the day", date: "2024-06-03", clock: "24:00", want: time.Date(2024, time.June, 4, 0, 0, 0, 0, berlin), valid: true},
+		{name: "invalid date", date: "2024-06-31", clock: "18:30"},
+		{name: "invalid clock", date: "2024-06-03", clock: "18h30"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, valid := At("Europe/Berlin", test.date, test.clock)
+			if valid != test.valid || (valid && !got.Equal(test.want)) {
+				t.Errorf("At() = %v, %v, want %v, %v", got, valid, test.want, test.valid)
+			}
+		})
+	}
+}
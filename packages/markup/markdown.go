@@ -0,0 +1,169 @@
+package markup
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+var (
+	headingLine     = regexp.MustCompile(`^(#{1,6})\s+(.*?)\s*#*\s*$`)
+	ruleLine        = regexp.MustCompile(`^\s*([-*_])(\s*([-*_])){2,}\s*$`)
+	unorderedItem   = regexp.MustCompile(`^\s*[-*+]\s+(.*)$`)
+	orderedItem     = regexp.MustCompile(`^\s*(\d+)[.)]\s+(.*)$`)
+	codeSpan        = regexp.MustCompile("`([^`]+)`")
+	imageSpan       = regexp.MustCompile(`!\[([^\]]*)\]\(([^)\s]+)(?:\s+"([^"]*)")?\)`)
+	linkSpan        = regexp.MustCompile(`\[([^\]]+)\]\(([^)\s]+)(?:\s+"([^"]*)")?\)`)
+	strongSpan      = regexp.MustCompile(`\*\*(.+?)\*\*|__(.+?)__`)
+	emphasisSpan    = regexp.MustCompile(`\*([^*\s][^*]*)\*|\b_([^_\s][^_]*)_\b`)
+	placeholderSpan = regexp.MustCompile("\x00(\\d+)\x00")
+	blankLines      = regexp.MustCompile(`\n\s*\n`)
+)
+
+/*
+markdownToHtml renders the commonly used subset of markdown: headings, paragraphs, emphasis, code,
+links, images, lists, block quotes and rules. Raw HTML is passed through, the result has to be sanitized.
+*/
+func markdownToHtml(source string) string {
+	lines := strings.Split(strings.ReplaceAll(source, "\r\n", "\n"), "\n")
+	var builder strings.Builder
+	paragraph := []string{}
+
+	closeParagraph := func() {
+		if len(paragraph) > 0 {
+			builder.WriteString("<p>" + inlineMarkdown(strings.Join(paragraph, "\n")) + "</p>\n")
+			paragraph = paragraph[:0]
+		}
+	}
+
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+		trimmed := strings.TrimSpace(line)
+
+		switch {
+		case trimmed == "":
+			closeParagraph()
+		case strings.HasPrefix(trimmed, "```"):
+			closeParagraph()
+			code := []string{}
+			for i++; i < len(lines) && !strings.HasPrefix(strings.TrimSpace(lines[i]), "```"); i++ {
+				code = append(code, lines[i])
+			}
+			builder.WriteString("<pre><code>" + html.EscapeString(strings.Join(code, "\n")) + "</code></pre>\n")
+		case headingLine.MatchString(trimmed):
+			closeParagraph()
+			match := headingLine.FindStringSubmatch(trimmed)
+			level := len(match[1])
+			builder.WriteString(fmt.Sprintf("<h%d>%s</h%d>\n", level, inlineMarkdown(match[2]), level))
+		case ruleLine.MatchString(trimmed):
+			closeParagraph()
+			builder.WriteString("<hr>\n")
+		case strings.HasPrefix(trimmed, ">"):
+			closeParagraph()
+			quote := []string{}
+			for ; i < len(lines) && strings.HasPrefix(strings.TrimSpace(lines[i]), ">"); i++ {
+				quote = append(quote, strings.TrimPrefix(strings.TrimPrefix(strings.TrimSpace(lines[i]), ">"), " "))
+			}
+			i--
+			builder.WriteString("<blockquote>\n" + markdownToHtml(strings.Join(quote, "\n")) + "</blockquote>\n")
+		case unorderedItem.MatchString(line) || orderedItem.MatchString(line):
+			closeParagraph()
+			i = renderList(&builder, lines, i) - 1
+		default:
+			paragraph = append(paragraph, trimmed)
+		}
+	}
+	closeParagraph()
+	return builder.String()
+}
+
+/* renderList renders the list starting at lines[start] and returns the index of the line following it */
+func renderList(builder *strings.Builder, lines []string, start int) int {
+	ordered := orderedItem.MatchString(lines[start])
+	tag := "ul"
+	if ordered {
+		tag = "ol"
+		if number := orderedItem.FindStringSubmatch(lines[start])[1]; number != "1" {
+			tag = fmt.Sprintf(`ol start="%s"`, number)
+		}
+	}
+	builder.WriteString("<" + tag + ">\n")
+
+	items := []string{}
+	i := start
+lines:
+	for ; i < len(lines); i++ {
+		line := lines[i]
+		switch {
+		case ordered && orderedItem.MatchString(line):
+			items = append(items, orderedItem.FindStringSubmatch(line)[2])
+		case !ordered && unorderedItem.MatchString(line):
+			items = append(items, unorderedItem.FindStringSubmatch(line)[1])
+		case strings.TrimSpace(line) != "" && (strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")):
+			/* Indented lines continue the previous item */
+			items[len(items)-1] += "\n" + strings.TrimSpace(line)
+		default:
+			break lines
+		}
+	}
+	for _, item := range items {
+		builder.WriteString("<li>" + inlineMarkdown(item) + "</li>\n")
+	}
+	builder.WriteString("</" + strings.Fields(tag)[0] + ">\n")
+	return i
+}
+
+/*
+inlineMarkdown renders code spans, images, links and emphasis. Code spans are set aside so that their content is left as is,
+behind placeholders made of NUL characters: the NULs of the text are replaced beforehand, as HTML parsers do
+*/
+func inlineMarkdown(text string) string {
+	text = strings.ReplaceAll(text, "\x00", "\uFFFD")
+	codes := []string{}
+	text = codeSpan.ReplaceAllStringFunc(text, func(span string) string {
+		codes = append(codes, "<code>"+html.EscapeString(codeSpan.FindStringSubmatch(span)[1])+"</code>")
+		return fmt.Sprintf("\x00%d\x00", len(codes)-1)
+	})
+
+	text = imageSpan.ReplaceAllStringFunc(text, func(span string) string {
+		match := imageSpan.FindStringSubmatch(span)
+		return fmt.Sprintf(`<img src="%s" alt="%s"%s>`, html.EscapeString(match[2]), html.EscapeString(match[1]), titleAttr(match[3]))
+	})
+	text = linkSpan.ReplaceAllStringFunc(text, func(span string) string {
+		match := linkSpan.FindStringSubmatch(span)
+		return fmt.Sprintf(`<a href="%s"%s>%s</a>`, html.EscapeString(match[2]), titleAttr(match[3]), match[1])
+	})
+	text = strongSpan.ReplaceAllString(text, "<strong>$1$2</strong>")
+	text = emphasisSpan.ReplaceAllString(text, "<em>$1$2</em>")
+
+	return placeholderSpan.ReplaceAllStringFunc(text, func(placeholder string) string {
+		index, err := strconv.Atoi(strings.Trim(placeholder, "\x00"))
+		if err != nil || index >= len(codes) {
+			return ""
+		}
+		return codes[index]
+	})
+}
+
+func titleAttr(title string) string {
+	if title == "" {
+		return ""
+	}
+	return fmt.Sprintf(` title="%s"`, html.EscapeString(title))
+}
+
+/* plainToHtml escapes plain text, blank lines separate paragraphs and line breaks are kept */
+func plainToHtml(source string) string {
+	var builder strings.Builder
+	for _, paragraph := range blankLines.Split(strings.ReplaceAll(source, "\r\n", "\n"), -1) {
+		paragraph = strings.TrimSpace(paragraph)
+		if paragraph == "" {
+			continue
+		}
+		builder.WriteString("<p>" + strings.ReplaceAll(html.EscapeString(paragraph), "\n", "<br>") + "</p>\n")
+	}
+	return builder.String()
+}
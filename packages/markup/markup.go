@@ -0,0 +1,22 @@
+package markup
+
+/* Formats of the source of rich text, e.g. the body of a page */
+const (
+	Markdown = "markdown"
+	Html     = "html"
+	Plain    = "plain"
+)
+
+var Formats = []string{Markdown, Html, Plain}
+
+/* Render turns source written in format into HTML which is safe to embed, unknown formats are handled as HTML */
+func Render(format string, source string) string {
+	switch format {
+	case Markdown:
+		return Sanitize(markdownToHtml(source))
+	case Plain:
+		return plainToHtml(source)
+	default:
+		return Sanitize(source)
+	}
+}
@@ -0,0 +1,60 @@
+package markup
+
+import "testing"
+
+func TestRenderMarkdown(t *testing.T) {
+	tests := []struct {
+		name   string
+		source string
+		want   string
+	}{
+		{name: "heading and emphasis", source: "# Title\n\nSome *em* and **strong** text", want: "<h1>Title</h1>\n<p>Some <em>em</em> and <strong>strong</strong> text</p>\n"},
+		{name: "lists", source: "- a\n- b\n\n3. c\n4. d", want: "<ul>\n<li>a</li>\n<li>b</li>\n</ul>\n<ol start=\"3\">\n<li>c</li>\n<li>d</li>\n</ol>\n"},
+		{name: "block quote", source: "> quoted\n> more", want: "<blockquote>\n<p>quoted\nmore</p>\n</blockquote>\n"},
+		{name: "rule", source: "---", want: "<hr>\n"},
+		{name: "code block", source: "```\n<b>x</b>\n```", want: "<pre><code>&lt;b&gt;x&lt;/b&gt;</code></pre>\n"},
+		{name: "code span", source: "a `<i>code</i>` span", want: "<p>a <code>&lt;i&gt;code&lt;/i&gt;</code> span</p>\n"},
+		{name: "link", source: `[link](https://example.com "Title")`, want: "<p><a href=\"https://example.com\" title=\"Title\" rel=\"nofollow noopener noreferrer\">link</a></p>\n"},
+		{name: "image", source: "![alt](/img.png)", want: "<p><img src=\"/img.png\" alt=\"alt\"></p>\n"},
+		{name: "placeholder lookalike", source: "x \x000\x00 y", want: "<p>x �0� y</p>\n"},
+		{name: "placeholder lookalike out of range", source: "x \x009\x00 `c` y", want: "<p>x �9� <code>c</code> y</p>\n"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := Render(Markdown, test.source); got != test.want {
+				t.Errorf("Render() = %q, want %q", got, test.want)
+			}
+		})
+	}
+}
+
+func TestRenderUnsafeMarkup(t *testing.T) {
+	tests := []struct {
+		name   string
+		format string
+		source string
+		want   string
+	}{
+		{name: "javascript link", format: Markdown, source: "[a](JaVaScRiPt:alert)", want: "<p><a rel=\"nofollow noopener noreferrer\">a</a></p>\n"},
+		{name: "entity encoded scheme in markdown", format: Markdown, source: "[a](&#106;avascript:alert)", want: "<p><a href=\"&amp;#106;avascript:alert\" rel=\"nofollow noopener noreferrer\">a</a></p>\n"},
+		{name: "entity encoded scheme in html", format: Markdown, source: `<a href="&#106;avascript:alert(1)">x</a>`, want: "<p><a rel=\"nofollow noopener noreferrer\">x</a></p>\n"},
+		{name: "scheme split by a tab", format: Html, source: "<a href=\"java\tscript:alert(1)\">x</a>", want: "<a rel=\"nofollow noopener noreferrer\">x</a>"},
+		{name: "data url", format: Html, source: `<a href="data:text/html,x">d</a>`, want: "<a rel=\"nofollow noopener noreferrer\">d</a>"},
+		{name: "event handler", format: Html, source: "<img src=x onerror=alert(1)>", want: "<img src=\"x\">"},
+		{name: "event handler on an allowed element", format: Html, source: `<p onclick="x()">p</p>`, want: "<p>p</p>"},
+		{name: "style attribute", format: Html, source: `<img src="https://x/y.png" style="x">`, want: "<img src=\"https://x/y.png\">"},
+		{name: "script", format: Html, source: "<div><script>alert(1)</script><b>ok</b></div>", want: "<div><b>ok</b></div>"},
+		{name: "script in svg", format: Html, source: "<svg><script>alert(1)</script></svg>", want: ""},
+		{name: "nested tags", format: Html, source: "<scr<script>ipt>alert(1)</script>", want: "ipt&gt;alert(1)"},
+		{name: "script in a code span", format: Markdown, source: "`<script>alert(1)</script>`", want: "<p><code>&lt;script&gt;alert(1)&lt;/script&gt;</code></p>\n"},
+		{name: "comment", format: Html, source: "<!-- c --><p>t</p>", want: "<p>t</p>"},
+		{name: "plain text is escaped", format: Plain, source: "a <b>\nc\n\nd", want: "<p>a &lt;b&gt;<br>c</p>\n<p>d</p>\n"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := Render(test.format, test.source); got != test.want {
+				t.Errorf("Render() = %q, want %q", got, test.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,113 @@
+package markup
+
+import (
+	"net/url"
+	"strings"
+
+	"golang.org/x/exp/slices"
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+/* Elements kept by Sanitize, with the attributes they may carry. Other elements are dropped but their text is kept */
+var allowedElements = map[atom.Atom][]string{
+	atom.A: {"href", "title"}, atom.Img: {"src", "alt", "title", "width", "height"},
+	atom.P: nil, atom.Br: nil, atom.Hr: nil, atom.Div: nil, atom.Span: nil,
+	atom.H1: nil, atom.H2: nil, atom.H3: nil, atom.H4: nil, atom.H5: nil, atom.H6: nil,
+	atom.Strong: nil, atom.B: nil, atom.Em: nil, atom.I: nil, atom.U: nil, atom.S: nil, atom.Del: nil,
+	atom.Sub: nil, atom.Sup: nil, atom.Small: nil, atom.Mark: nil,
+	atom.Ul: nil, atom.Ol: {"start"}, atom.Li: nil,
+	atom.Blockquote: nil, atom.Pre: nil, atom.Code: nil,
+	atom.Table: nil, atom.Thead: nil, atom.Tbody: nil, atom.Tr: nil, atom.Th: {"colspan", "rowspan"}, atom.Td: {"colspan", "rowspan"},
+	atom.Figure: nil, atom.Figcaption: nil,
+}
+
+/* Elements dropped along with their content */
+var droppedElements = map[atom.Atom]bool{
+	atom.Script: true, atom.Style: true, atom.Iframe: true, atom.Object: true, atom.Embed: true,
+	atom.Noscript: true, atom.Template: true, atom.Svg: true, atom.Math: true, atom.Form: true,
+	atom.Textarea: true, atom.Select: true, atom.Frameset: true, atom.Frame: true,
+}
+
+/* Schemes allowed in href and src, relative urls have none */
+var allowedSchemes = []string{"", "http", "https", "mailto", "tel"}
+
+/*
+Sanitize makes untrusted HTML safe to embed in a page: only an allowlist of elements and attributes is kept,
+scripts, styles and embedded content are removed, as well as event handlers and javascript: urls.
+The result is well-formed, as it is re-rendered from the parsed document.
+*/
+func Sanitize(source string) string {
+	context := &html.Node{Type: html.ElementNode, Data: "div", DataAtom: atom.Div}
+	nodes, err := html.ParseFragment(strings.NewReader(source), context)
+	if err != nil {
+		return html.EscapeString(source)
+	}
+
+	var builder strings.Builder
+	for _, node := range nodes {
+		sanitizeNode(&builder, node)
+	}
+	return builder.String()
+}
+
+func sanitizeNode(builder *strings.Builder, node *html.Node) {
+	switch node.Type {
+	case html.TextNode:
+		builder.WriteString(html.EscapeString(node.Data))
+		return
+	case html.ElementNode:
+	default:
+		/* Comments and doctypes are dropped */
+		return
+	}
+
+	if droppedElements[node.DataAtom] {
+		return
+	}
+	attrs, allowed := allowedElements[node.DataAtom]
+	if allowed {
+		builder.WriteString("<" + node.Data)
+		for _, attr := range node.Attr {
+			if attr.Namespace != "" || !slices.Contains(attrs, attr.Key) {
+				continue
+			}
+			if (attr.Key == "href" || attr.Key == "src") && !safeUrl(attr.Val) {
+				continue
+			}
+			builder.WriteString(" " + attr.Key + `="` + html.EscapeString(attr.Val) + `"`)
+		}
+		/* Links may point anywhere: don't give the target access to the page */
+		if node.DataAtom == atom.A {
+			builder.WriteString(` rel="nofollow noopener noreferrer"`)
+		}
+		builder.WriteString(">")
+	}
+
+	for child := node.FirstChild; child != nil; child = child.NextSibling {
+		sanitizeNode(builder, child)
+	}
+
+	if allowed && !isVoid(node.DataAtom) {
+		builder.WriteString("</" + node.Data + ">")
+	}
+}
+
+func safeUrl(value string) bool {
+	/* Browsers ignore control characters and spaces in schemes, e.g. "java\tscript:" */
+	cleaned := strings.Map(func(r rune) rune {
+		if r <= ' ' || r == 0x7f {
+			return -1
+		}
+		return r
+	}, value)
+	parsed, err := url.Parse(cleaned)
+	if err != nil {
+		return false
+	}
+	return slices.Contains(allowedSchemes, strings.ToLower(parsed.Scheme))
+}
+
+func isVoid(element atom.Atom) bool {
+	return element == atom.Br || element == atom.Hr || element == atom.Img
+}
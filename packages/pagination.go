@@ -1,28 +1,163 @@
 package pagination
 
 import (
+	"math"
+	"net/url"
+	"os"
 	"strconv"
+	"strings"
 )
 
+const (
+	defaultPageSize = 20
+	maxPageSize     = 100
+)
+
+/* Query params driving pagination and sorting, which must never be treated as filters */
+var ReservedParams = []string{"page", "size", "sort", AfterParam, BeforeParam}
+
+/* A sort criteria parsed from the JSON:API sort param, e.g. "-created_at" */
+type SortField struct {
+	Attr string
+	Desc bool
+}
+
+/*
+SortFields parses the sort param (e.g. "-created_at,name"). A "-" prefix sorts descending.
+The id is always appended as a tiebreaker so that the order (and therefore pages) is stable
+*/
+func SortFields(params url.Values) []SortField {
+	fields := []SortField{}
+	hasId := false
+	for _, field := range strings.Split(params.Get("sort"), ",") {
+		field = strings.TrimSpace(field)
+		attr := strings.TrimPrefix(field, "-")
+		if attr == "" {
+			continue
+		}
+		if attr == "id" {
+			hasId = true
+		}
+		fields = append(fields, SortField{Attr: attr, Desc: strings.HasPrefix(field, "-")})
+	}
+	if !hasId {
+		fields = append(fields, SortField{Attr: "id"})
+	}
+	return fields
+}
+
 type Pagination struct {
 	Params map[string]interface{}
 }
 
+func NewPagination(params url.Values) *Pagination {
+	query := map[string]interface{}{}
+	for key, value := range params {
+		query[key] = value
+	}
+	return &Pagination{Params: query}
+}
+
+/* DefaultSize is the page size used when the request doesn't ask for one (PAGINATION_DEFAULT_SIZE) */
+func DefaultSize() uint {
+	return sizeFromEnv("PAGINATION_DEFAULT_SIZE", defaultPageSize)
+}
+
+/* MaxSize is the largest page size a request can ask for (PAGINATION_MAX_SIZE) */
+func MaxSize() uint {
+	return sizeFromEnv("PAGINATION_MAX_SIZE", maxPageSize)
+}
+
+func sizeFromEnv(name string, fallback uint) uint {
+	size, err := strconv.Atoi(os.Getenv(name))
+	if err != nil || size < 1 {
+		return fallback
+	}
+	return uint(size)
+}
+
+func (p *Pagination) param(key string) (int, bool) {
+	value, exists := p.Params[key]
+	if !exists {
+		return 0, false
+	}
+	values, ok := value.([]string)
+	if !ok || len(values) == 0 {
+		return 0, false
+	}
+	i, err := strconv.Atoi(values[0])
+	if err != nil {
+		return 0, false
+	}
+	return i, true
+}
+
 func (p *Pagination) Size() uint {
-	if value, exists := p.Params["size"]; exists {
-		size := value.([]string)[0]
-		i, _ := strconv.Atoi(size)
-		return uint(i)
+	size, ok := p.param("size")
+	if !ok || size < 1 {
+		return DefaultSize()
+	}
+	if uint(size) > MaxSize() {
+		return MaxSize()
 	}
-	return 1
+	return uint(size)
+}
+
+func (p *Pagination) Page() uint {
+	page, ok := p.param("page")
+	if !ok || page < 1 {
+		return 1
+	}
+	return uint(page)
 }
 
 func (p *Pagination) Offset() uint {
-	page := 1
-	if value, exists := p.Params["page"]; exists {
-		size := value.([]string)[0]
-		page, _ = strconv.Atoi(size)
+	return (p.Size() * (p.Page() - 1))
+}
+
+func (p *Pagination) TotalPages(total uint64) uint {
+	return uint(math.Ceil(float64(total) / float64(p.Size())))
+}
+
+/* Meta describes the current page of a collection of total records */
+func (p *Pagination) Meta(total uint64) map[string]interface{} {
+	return map[string]interface{}{
+		"total":      total,
+		"page":       p.Page(),
+		"size":       p.Size(),
+		"totalPages": p.TotalPages(total),
+	}
+}
+
+/*
+Links returns JSON:API pagination links (self, first, prev, next, last) built from the request URL.
+prev and next are null when there is no such page.
+*/
+func (p *Pagination) Links(requestUrl *url.URL, total uint64) map[string]interface{} {
+	lastPage := p.TotalPages(total)
+	if lastPage < 1 {
+		lastPage = 1
 	}
 
-	return (p.Size() * uint(page-1))
+	pageLink := func(page uint) string {
+		query := requestUrl.Query()
+		query.Set("page", strconv.FormatUint(uint64(page), 10))
+		query.Set("size", strconv.FormatUint(uint64(p.Size()), 10))
+		return requestUrl.Path + "?" + query.Encode()
+	}
+
+	links := map[string]interface{}{
+		"self":  pageLink(p.Page()),
+		"first": pageLink(1),
+		"prev":  nil,
+		"next":  nil,
+		"last":  pageLink(lastPage),
+	}
+	if p.Page() > 1 {
+		links["prev"] = pageLink(min(p.Page()-1, lastPage))
+	}
+	if p.Page() < lastPage {
+		links["next"] = pageLink(p.Page() + 1)
+	}
+	return links
 }
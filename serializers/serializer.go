@@ -19,14 +19,16 @@ func NewMemberSerializer(resource interface{}, included []interface{}, relations
 
 // Schema for jsonapi collection response
 type CollectionSerializer struct {
-	Data []interface{}          `json:"data"`
-	Meta map[string]interface{} `json:"meta"`
+	Data  []interface{}          `json:"data"`
+	Meta  map[string]interface{} `json:"meta"`
+	Links map[string]interface{} `json:"links,omitempty"`
 }
 
-func NewCollectionSerializer(collection []interface{}, meta map[string]interface{}) *CollectionSerializer {
+func NewCollectionSerializer(collection []interface{}, meta map[string]interface{}, links map[string]interface{}) *CollectionSerializer {
 	return &CollectionSerializer{
-		Data: collection,
-		Meta: meta,
+		Data:  collection,
+		Meta:  meta,
+		Links: links,
 	}
 }
 
@@ -0,0 +1,136 @@
+package services
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"os"
+	"sync"
+	"time"
+
+	"golang.org/x/oauth2"
+	"resturants-hub.com/m/v2/dao"
+	"resturants-hub.com/m/v2/dto"
+	rest_errors "resturants-hub.com/m/v2/packages/utils"
+)
+
+const defaultOAuthStateTTL = 10 * time.Minute
+
+/*
+OAuthStateStore keeps the logins in progress between the redirect to the provider and its callback.
+States are single use: Consume removes the state it returns.
+*/
+type OAuthStateStore interface {
+	Save(*dto.OAuthState) rest_errors.RestErr
+	Consume(state string) (*dto.OAuthState, rest_errors.RestErr)
+}
+
+/*
+NewOAuthStateStore returns the store selected by OAUTH_STATE_STORE: "memory" (the default) keeps states within
+the process, "postgres" keeps them in the oauth_states table so that several instances of the app can share them.
+*/
+func NewOAuthStateStore() OAuthStateStore {
+	switch os.Getenv("OAUTH_STATE_STORE") {
+	case "postgres":
+		return &postgresStateStore{dao: dao.NewOAuthStatesDao()}
+	default:
+		return memoryStates
+	}
+}
+
+/* OAuthStateTTL is how long a login can take before its state expires (OAUTH_STATE_TTL, e.g. "5m") */
+func OAuthStateTTL() time.Duration {
+	ttl, err := time.ParseDuration(os.Getenv("OAUTH_STATE_TTL"))
+	if err != nil || ttl <= 0 {
+		return defaultOAuthStateTTL
+	}
+	return ttl
+}
+
+/* NewOAuthState starts a login with provider: its state is random and comes with its own PKCE verifier and nonce */
+func NewOAuthState(provider string) (*dto.OAuthState, error) {
+	state, err := RandomToken()
+	if err != nil {
+		return nil, err
+	}
+	nonce, err := RandomToken()
+	if err != nil {
+		return nil, err
+	}
+	return &dto.OAuthState{
+		State:     state,
+		Provider:  provider,
+		Verifier:  oauth2.GenerateVerifier(),
+		Nonce:     nonce,
+		ExpiresAt: time.Now().Add(OAuthStateTTL()).UTC(),
+	}, nil
+}
+
+/* RandomToken returns 32 random bytes, URL safe base64 encoded */
+func RandomToken() (string, error) {
+	bytes := make([]byte, 32)
+	if _, err := rand.Read(bytes); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(bytes), nil
+}
+
+func unknownOAuthState() rest_errors.RestErr {
+	return rest_errors.NewBadRequestError("Invalid or expired OAuth state")
+}
+
+/* States of the memory store are shared by all the handlers of the process */
+var memoryStates = &memoryStateStore{states: map[string]dto.OAuthState{}}
+
+type memoryStateStore struct {
+	mutex  sync.Mutex
+	states map[string]dto.OAuthState
+}
+
+/* Save keeps the state, dropping the expired ones of abandoned logins along the way */
+func (store *memoryStateStore) Save(state *dto.OAuthState) rest_errors.RestErr {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	for key, existing := range store.states {
+		if existing.IsExpired() {
+			delete(store.states, key)
+		}
+	}
+	store.states[state.State] = *state
+	return nil
+}
+
+func (store *memoryStateStore) Consume(state string) (*dto.OAuthState, rest_errors.RestErr) {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	existing, exists := store.states[state]
+	if !exists {
+		return nil, unknownOAuthState()
+	}
+	delete(store.states, state)
+	if existing.IsExpired() {
+		return nil, unknownOAuthState()
+	}
+	return &existing, nil
+}
+
+type postgresStateStore struct {
+	dao dao.OAuthStatesDao
+}
+
+/* Save keeps the state, dropping the expired ones of abandoned logins along the way */
+func (store *postgresStateStore) Save(state *dto.OAuthState) rest_errors.RestErr {
+	if purgeErr := store.dao.PurgeExpiredOAuthStates(time.Now().UTC()); purgeErr != nil {
+		return purgeErr
+	}
+	return store.dao.CreateOAuthState(state)
+}
+
+func (store *postgresStateStore) Consume(state string) (*dto.OAuthState, rest_errors.RestErr) {
+	existing, consumeErr := store.dao.ConsumeOAuthState(state)
+	if consumeErr != nil || existing.IsExpired() {
+		return nil, unknownOAuthState()
+	}
+	return existing, nil
+}
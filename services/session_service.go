@@ -1,6 +1,8 @@
 package services
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 	"os"
 	"time"
@@ -13,6 +15,8 @@ import (
 
 var JwtLifeSpan int = 5 * 60 // 5 minutes
 
+const defaultSessionTTL = 12 * time.Hour
+
 // Create a struct that will be encoded to a JWT.
 // We add jwt.RegisteredClaims as an embedded type, to provide fields like expiry time
 type Claims struct {
@@ -28,7 +32,8 @@ type Jwt struct {
 
 type SessionService interface {
 	CreateSession(*dto.Session) (*dto.Session, rest_errors.RestErr)
-	InvalidateToken(*dto.Session) (bool, rest_errors.RestErr)
+	ListSessions(userId int64) (dto.Sessions, rest_errors.RestErr)
+	RevokeSession(userId int64, id int64) (*dto.Session, rest_errors.RestErr)
 	ValidateSessionToken(string) (*dto.Session, rest_errors.RestErr)
 	RenewSession(*dto.Session) (*dto.Session, rest_errors.RestErr)
 	GenerateJwtToken() (*Jwt, error)
@@ -53,23 +58,67 @@ func NewSessionService() SessionService {
 	}
 }
 
+/* SessionTTL is how long a session lasts before it has to be renewed (SESSION_TTL, e.g. "8h") */
+func SessionTTL() time.Duration {
+	ttl, err := time.ParseDuration(os.Getenv("SESSION_TTL"))
+	if err != nil || ttl <= 0 {
+		return defaultSessionTTL
+	}
+	return ttl
+}
+
+/* hashSessionToken is what sessions are stored under, tokens are random enough for a plain SHA-256 */
+func hashSessionToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+/* mintToken gives the session a new random token, only its hash is stored. The session lasts SessionTTL from now on */
+func mintToken(session *dto.Session) error {
+	token, err := RandomToken()
+	if err != nil {
+		return err
+	}
+	session.Token = token
+	session.TokenHash = hashSessionToken(token)
+	session.ExpiresAt = time.Now().Add(SessionTTL()).UTC()
+	return nil
+}
+
+/* CreateSession saves the session under a new token, the returned session carries the token for the cookie */
 func (service *sessionService) CreateSession(userSession *dto.Session) (*dto.Session, rest_errors.RestErr) {
-	// copier.Copy(userSession, gothUser)
+	if err := mintToken(userSession); err != nil {
+		return nil, rest_errors.NewInternalServerError(err)
+	}
 	session, sessionError := service.sessionDao.CreateSession(userSession)
 	if sessionError != nil {
 		fmt.Println(sessionError)
 		return nil, sessionError
 	}
+	session.Token = userSession.Token
 	return session, nil
 }
 
+/* RenewSession extends the session under a new token, the previous one is no longer accepted */
 func (service *sessionService) RenewSession(session *dto.Session) (*dto.Session, rest_errors.RestErr) {
-	fmt.Println(session.Id, session.ExpiresAt, session.AccessToken)
-	session, tokenError := service.sessionDao.UpdateSession(session, &session.Id)
+	if err := mintToken(session); err != nil {
+		return nil, rest_errors.NewInternalServerError(err)
+	}
+	renewed, tokenError := service.sessionDao.UpdateSession(session, &session.Id)
 	if tokenError != nil {
 		return nil, tokenError
 	}
-	return session, nil
+	renewed.Token = session.Token
+	return renewed, nil
+}
+
+/* ListSessions lists the sessions the user is signed in with */
+func (service *sessionService) ListSessions(userId int64) (dto.Sessions, rest_errors.RestErr) {
+	return service.sessionDao.SearchActiveSessions(userId)
+}
+
+func (service *sessionService) RevokeSession(userId int64, id int64) (*dto.Session, rest_errors.RestErr) {
+	return service.sessionDao.RevokeSession(userId, id)
 }
 
 /* func (service *sessionService) FindUserByLoginPayload(payload users.LoginPayload) (*users.User, rest_errors.RestErr) {
@@ -110,7 +159,8 @@ func (service *sessionService) GenerateJwtToken() (*Jwt, error) {
 
 func (service *sessionService) ValidateSessionToken(token string) (*dto.Session, rest_errors.RestErr) {
 	params := map[string]interface{}{
-		"access_token": token,
+		"token_hash": hashSessionToken(token),
+		"revoked_at": nil,
 	}
 	sessionToken, err := service.sessionDao.FindSession(params)
 
@@ -124,11 +174,3 @@ func (service *sessionService) ValidateSessionToken(token string) (*dto.Session,
 
 	return sessionToken, nil
 }
-
-func (service *sessionService) InvalidateToken(session *dto.Session) (bool, rest_errors.RestErr) {
-	_, sessionError := service.sessionDao.ExpireToken(session)
-	if sessionError != nil {
-		return false, sessionError
-	}
-	return true, nil
-}